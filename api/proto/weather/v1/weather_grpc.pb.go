@@ -0,0 +1,301 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: weather/v1/weather.proto
+
+package weatherv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	WeatherService_GetToday_FullMethodName      = "/weather.v1.WeatherService/GetToday"
+	WeatherService_GetHourly_FullMethodName     = "/weather.v1.WeatherService/GetHourly"
+	WeatherService_GetDaily_FullMethodName      = "/weather.v1.WeatherService/GetDaily"
+	WeatherService_GetMultiDay_FullMethodName   = "/weather.v1.WeatherService/GetMultiDay"
+	WeatherService_BatchGetToday_FullMethodName = "/weather.v1.WeatherService/BatchGetToday"
+)
+
+// WeatherServiceClient is the client API for WeatherService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// WeatherService mirrors the HTTP forecast API. It is served by weatherd on
+// GRPC_PORT and shares the forecast service and cache with the HTTP listener.
+type WeatherServiceClient interface {
+	// GetToday returns today's forecast period for a location, like GET /v1/forecast.
+	GetToday(ctx context.Context, in *GetTodayRequest, opts ...grpc.CallOption) (*TodayForecast, error)
+	// GetHourly returns the hourly forecast periods that have not yet ended.
+	// FAILED_PRECONDITION for locations without an hourly forecast (outside NWS coverage).
+	GetHourly(ctx context.Context, in *GetHourlyRequest, opts ...grpc.CallOption) (*HourlyForecast, error)
+	// GetDaily returns the daytime and overnight periods for one local calendar date,
+	// like GET /v1/forecast/daily/{date}. NOT_FOUND when the date is outside the horizon.
+	GetDaily(ctx context.Context, in *GetDailyRequest, opts ...grpc.CallOption) (*DailyForecast, error)
+	// GetMultiDay returns consecutive local dates starting today, up to the end of the
+	// forecast horizon.
+	GetMultiDay(ctx context.Context, in *GetMultiDayRequest, opts ...grpc.CallOption) (*MultiDayForecast, error)
+	// BatchGetToday streams today's forecast for each requested location as soon as it
+	// is available. Results may arrive out of order; match them by index.
+	BatchGetToday(ctx context.Context, in *BatchGetTodayRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BatchGetTodayResponse], error)
+}
+
+type weatherServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWeatherServiceClient(cc grpc.ClientConnInterface) WeatherServiceClient {
+	return &weatherServiceClient{cc}
+}
+
+func (c *weatherServiceClient) GetToday(ctx context.Context, in *GetTodayRequest, opts ...grpc.CallOption) (*TodayForecast, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TodayForecast)
+	err := c.cc.Invoke(ctx, WeatherService_GetToday_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *weatherServiceClient) GetHourly(ctx context.Context, in *GetHourlyRequest, opts ...grpc.CallOption) (*HourlyForecast, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HourlyForecast)
+	err := c.cc.Invoke(ctx, WeatherService_GetHourly_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *weatherServiceClient) GetDaily(ctx context.Context, in *GetDailyRequest, opts ...grpc.CallOption) (*DailyForecast, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DailyForecast)
+	err := c.cc.Invoke(ctx, WeatherService_GetDaily_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *weatherServiceClient) GetMultiDay(ctx context.Context, in *GetMultiDayRequest, opts ...grpc.CallOption) (*MultiDayForecast, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MultiDayForecast)
+	err := c.cc.Invoke(ctx, WeatherService_GetMultiDay_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *weatherServiceClient) BatchGetToday(ctx context.Context, in *BatchGetTodayRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BatchGetTodayResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WeatherService_ServiceDesc.Streams[0], WeatherService_BatchGetToday_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BatchGetTodayRequest, BatchGetTodayResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WeatherService_BatchGetTodayClient = grpc.ServerStreamingClient[BatchGetTodayResponse]
+
+// WeatherServiceServer is the server API for WeatherService service.
+// All implementations must embed UnimplementedWeatherServiceServer
+// for forward compatibility.
+//
+// WeatherService mirrors the HTTP forecast API. It is served by weatherd on
+// GRPC_PORT and shares the forecast service and cache with the HTTP listener.
+type WeatherServiceServer interface {
+	// GetToday returns today's forecast period for a location, like GET /v1/forecast.
+	GetToday(context.Context, *GetTodayRequest) (*TodayForecast, error)
+	// GetHourly returns the hourly forecast periods that have not yet ended.
+	// FAILED_PRECONDITION for locations without an hourly forecast (outside NWS coverage).
+	GetHourly(context.Context, *GetHourlyRequest) (*HourlyForecast, error)
+	// GetDaily returns the daytime and overnight periods for one local calendar date,
+	// like GET /v1/forecast/daily/{date}. NOT_FOUND when the date is outside the horizon.
+	GetDaily(context.Context, *GetDailyRequest) (*DailyForecast, error)
+	// GetMultiDay returns consecutive local dates starting today, up to the end of the
+	// forecast horizon.
+	GetMultiDay(context.Context, *GetMultiDayRequest) (*MultiDayForecast, error)
+	// BatchGetToday streams today's forecast for each requested location as soon as it
+	// is available. Results may arrive out of order; match them by index.
+	BatchGetToday(*BatchGetTodayRequest, grpc.ServerStreamingServer[BatchGetTodayResponse]) error
+	mustEmbedUnimplementedWeatherServiceServer()
+}
+
+// UnimplementedWeatherServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWeatherServiceServer struct{}
+
+func (UnimplementedWeatherServiceServer) GetToday(context.Context, *GetTodayRequest) (*TodayForecast, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetToday not implemented")
+}
+func (UnimplementedWeatherServiceServer) GetHourly(context.Context, *GetHourlyRequest) (*HourlyForecast, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHourly not implemented")
+}
+func (UnimplementedWeatherServiceServer) GetDaily(context.Context, *GetDailyRequest) (*DailyForecast, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDaily not implemented")
+}
+func (UnimplementedWeatherServiceServer) GetMultiDay(context.Context, *GetMultiDayRequest) (*MultiDayForecast, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMultiDay not implemented")
+}
+func (UnimplementedWeatherServiceServer) BatchGetToday(*BatchGetTodayRequest, grpc.ServerStreamingServer[BatchGetTodayResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BatchGetToday not implemented")
+}
+func (UnimplementedWeatherServiceServer) mustEmbedUnimplementedWeatherServiceServer() {}
+func (UnimplementedWeatherServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeWeatherServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WeatherServiceServer will
+// result in compilation errors.
+type UnsafeWeatherServiceServer interface {
+	mustEmbedUnimplementedWeatherServiceServer()
+}
+
+func RegisterWeatherServiceServer(s grpc.ServiceRegistrar, srv WeatherServiceServer) {
+	// If the following call pancis, it indicates UnimplementedWeatherServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&WeatherService_ServiceDesc, srv)
+}
+
+func _WeatherService_GetToday_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTodayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WeatherServiceServer).GetToday(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WeatherService_GetToday_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WeatherServiceServer).GetToday(ctx, req.(*GetTodayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WeatherService_GetHourly_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHourlyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WeatherServiceServer).GetHourly(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WeatherService_GetHourly_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WeatherServiceServer).GetHourly(ctx, req.(*GetHourlyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WeatherService_GetDaily_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDailyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WeatherServiceServer).GetDaily(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WeatherService_GetDaily_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WeatherServiceServer).GetDaily(ctx, req.(*GetDailyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WeatherService_GetMultiDay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMultiDayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WeatherServiceServer).GetMultiDay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WeatherService_GetMultiDay_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WeatherServiceServer).GetMultiDay(ctx, req.(*GetMultiDayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WeatherService_BatchGetToday_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BatchGetTodayRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WeatherServiceServer).BatchGetToday(m, &grpc.GenericServerStream[BatchGetTodayRequest, BatchGetTodayResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WeatherService_BatchGetTodayServer = grpc.ServerStreamingServer[BatchGetTodayResponse]
+
+// WeatherService_ServiceDesc is the grpc.ServiceDesc for WeatherService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WeatherService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "weather.v1.WeatherService",
+	HandlerType: (*WeatherServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetToday",
+			Handler:    _WeatherService_GetToday_Handler,
+		},
+		{
+			MethodName: "GetHourly",
+			Handler:    _WeatherService_GetHourly_Handler,
+		},
+		{
+			MethodName: "GetDaily",
+			Handler:    _WeatherService_GetDaily_Handler,
+		},
+		{
+			MethodName: "GetMultiDay",
+			Handler:    _WeatherService_GetMultiDay_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BatchGetToday",
+			Handler:       _WeatherService_BatchGetToday_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "weather/v1/weather.proto",
+}
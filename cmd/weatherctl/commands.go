@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-service/internal/config"
+	"weather-service/internal/forecast"
+)
+
+const batchWorkers = 4
+
+// todayGetter is the part of forecast.Service the forecast commands need.
+type todayGetter interface {
+	GetTodaysForcast(ctx context.Context, lat, lon float64) (forecast.Result, error)
+}
+
+type coord struct {
+	lat, lon float64
+}
+
+// forecast prints today's forecast for the coordinates in args, or for each line
+// of stdin when there are none.
+func (a *app) forecast(ctx context.Context, args []string) (table, error) {
+	var coords []coord
+	switch len(args) {
+	case 0:
+		var err error
+		if coords, err = readCoords(a.stdin); err != nil {
+			return table{}, err
+		}
+	case 2:
+		c, err := parseCoord(args[0], args[1])
+		if err != nil {
+			return table{}, err
+		}
+		coords = []coord{c}
+	default:
+		return table{}, fmt.Errorf("%w: forecast takes lat lon or coordinates on stdin", errUsage)
+	}
+	return a.todays(ctx, coords)
+}
+
+// batch prints today's forecast for every coordinate line in a file ("-" is stdin).
+func (a *app) batch(ctx context.Context, args []string) (table, error) {
+	if len(args) != 1 {
+		return table{}, fmt.Errorf("%w: batch takes one file", errUsage)
+	}
+	r := a.stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return table{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+	coords, err := readCoords(r)
+	if err != nil {
+		return table{}, err
+	}
+	return a.todays(ctx, coords)
+}
+
+// batchRow is the JSON shape of one forecast or batch result.
+type batchRow struct {
+	Lat    float64          `json:"lat"`
+	Lon    float64          `json:"lon"`
+	Result *forecast.Result `json:"result,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// todays fetches the coordinates concurrently, keeping input order. A failed
+// location is reported in its row and makes the command fail after printing.
+func (a *app) todays(ctx context.Context, coords []coord) (table, error) {
+	src := a.forecaster()
+	out := make([]batchRow, len(coords))
+	sem := make(chan struct{}, batchWorkers)
+	var wg sync.WaitGroup
+	for i, c := range coords {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			out[i] = batchRow{Lat: c.lat, Lon: c.lon}
+			res, err := src.GetTodaysForcast(ctx, c.lat, c.lon)
+			if err != nil {
+				out[i].Error = err.Error()
+				return
+			}
+			out[i].Result = &res
+		}()
+	}
+	wg.Wait()
+
+	t := table{
+		header: []string{"lat", "lon", "date", "period", "temp", "class", "forecast", "error"},
+		value:  out,
+	}
+	failed := 0
+	for _, r := range out {
+		lat, lon := ftoa(r.Lat), ftoa(r.Lon)
+		if r.Result == nil {
+			failed++
+			t.add(lat, lon, "", "", "", "", "", r.Error)
+			continue
+		}
+		p := r.Result.Today
+		t.add(lat, lon, r.Result.Date, p.Name, temp(p.Temperature), p.Temperature.Type, p.ShortForecast, "")
+	}
+	if failed > 0 {
+		return t, fmt.Errorf("%d of %d locations failed", failed, len(out))
+	}
+	return t, nil
+}
+
+// hourly prints the NWS hourly forecast.
+func (a *app) hourly(ctx context.Context, args []string) (table, error) {
+	fs := flag.NewFlagSet("hourly", flag.ContinueOnError)
+	hours := fs.Int("n", 24, "number of hours")
+	if err := fs.Parse(args); err != nil {
+		return table{}, fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if *hours < 1 {
+		return table{}, fmt.Errorf("%w: -n must be at least 1", errUsage)
+	}
+	c, err := coordArgs("hourly", fs.Args())
+	if err != nil {
+		return table{}, err
+	}
+	client := a.nwsClient()
+	pts, err := client.Points(ctx, c.lat, c.lon)
+	if err != nil {
+		return table{}, err
+	}
+	if pts.Properties.ForecastHourly == "" {
+		return table{}, errors.New("no hourly forecast for point")
+	}
+	fc, err := client.Forecast(ctx, pts.Properties.ForecastHourly)
+	if err != nil {
+		return table{}, err
+	}
+	periods := fc.Properties.Periods
+	if *hours < len(periods) {
+		periods = periods[:*hours]
+	}
+
+	bands := forecast.Bands{ColdMax: config.ColdMaxDefault, HotMin: config.HotMinDefault}
+	t := table{header: []string{"start", "temp", "class", "forecast"}, value: periods}
+	for _, p := range periods {
+		t.add(p.StartTime.Format(time.RFC3339), fmt.Sprintf("%d°%s", p.Temperature, p.TemperatureUnit),
+			forecast.Classify(p.Temperature, bands), p.ShortForecast)
+	}
+	return t, nil
+}
+
+// alerts prints the NWS alerts in effect at a location.
+func (a *app) alerts(ctx context.Context, args []string) (table, error) {
+	c, err := coordArgs("alerts", args)
+	if err != nil {
+		return table{}, err
+	}
+	alerts, err := a.nwsClient().Alerts(ctx, c.lat, c.lon)
+	if err != nil {
+		return table{}, err
+	}
+	t := table{header: []string{"event", "severity", "urgency", "effective", "expires", "headline"}, value: alerts}
+	for _, al := range alerts {
+		t.add(al.Event, al.Severity, al.Urgency, al.Effective.Format(time.RFC3339), al.Expires.Format(time.RFC3339), al.Headline)
+	}
+	return t, nil
+}
+
+// cache implements "cache stats" and "cache purge" against the admin API.
+func (a *app) cache(ctx context.Context, args []string) (table, error) {
+	if len(args) == 0 {
+		return table{}, fmt.Errorf("%w: cache stats|purge", errUsage)
+	}
+	var method, path string
+	q := url.Values{}
+	switch args[0] {
+	case "stats":
+		method, path = http.MethodGet, "/admin/cache/stats"
+	case "purge":
+		fs := flag.NewFlagSet("cache purge", flag.ContinueOnError)
+		key := fs.String("key", "", "cache key")
+		prefix := fs.String("prefix", "", "cache key prefix")
+		if err := fs.Parse(args[1:]); err != nil {
+			return table{}, fmt.Errorf("%w: %w", errUsage, err)
+		}
+		switch {
+		case *key != "":
+			q.Set("key", *key)
+		case *prefix != "":
+			q.Set("prefix", *prefix)
+		default:
+			c, err := coordArgs("cache purge", fs.Args())
+			if err != nil {
+				return table{}, err
+			}
+			q.Set("lat", ftoa(c.lat))
+			q.Set("lon", ftoa(c.lon))
+		}
+		method, path = http.MethodDelete, "/admin/cache"
+	default:
+		return table{}, fmt.Errorf("%w: unknown cache command %q", errUsage, args[0])
+	}
+
+	var body map[string]any
+	if err := a.call(ctx, method, strings.TrimRight(a.admin, "/")+path+"?"+q.Encode(), &body); err != nil {
+		return table{}, err
+	}
+	keys := make([]string, 0, len(body))
+	for k := range body {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	t := table{header: []string{"name", "value"}, value: body}
+	for _, k := range keys {
+		t.add(k, fmt.Sprint(body[k]))
+	}
+	return t, nil
+}
+
+// raw prints an NWS document as returned, for debugging. The output flag is ignored.
+func (a *app) raw(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: raw points|forecast", errUsage)
+	}
+	client := a.nwsClient()
+	var doc any
+	switch {
+	case args[0] == "points":
+		c, err := coordArgs("raw points", args[1:])
+		if err != nil {
+			return err
+		}
+		if doc, err = client.Points(ctx, c.lat, c.lon); err != nil {
+			return err
+		}
+	case args[0] == "forecast" && len(args) == 2:
+		fc, err := client.Forecast(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		doc = fc
+	case args[0] == "forecast":
+		c, err := coordArgs("raw forecast", args[1:])
+		if err != nil {
+			return err
+		}
+		pts, err := client.Points(ctx, c.lat, c.lon)
+		if err != nil {
+			return err
+		}
+		if doc, err = client.Forecast(ctx, pts.Properties.Forecast); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown raw command %q", errUsage, args[0])
+	}
+	return render(a.stdout, formatJSON, table{value: doc})
+}
+
+// serverClient fetches today's forecast from a weatherd server.
+type serverClient struct {
+	base string
+	http *http.Client
+}
+
+func (s *serverClient) GetTodaysForcast(ctx context.Context, lat, lon float64) (forecast.Result, error) {
+	q := url.Values{"lat": {ftoa(lat)}, "lon": {ftoa(lon)}}
+	var res forecast.Result
+	err := call(ctx, s.http, http.MethodGet, s.base+"/v1/forecast?"+q.Encode(), &res)
+	return res, err
+}
+
+func (a *app) call(ctx context.Context, method, u string, out any) error {
+	return call(ctx, a.http, method, u, out)
+}
+
+// call performs a weatherd API request and decodes the JSON answer into out.
+// Error answers are turned into errors carrying the server's message.
+func call(ctx context.Context, client *http.Client, method, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var e struct {
+			Msg string `json:"msg"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Msg != "" {
+			return fmt.Errorf("%s: %s", resp.Status, e.Msg)
+		}
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// readCoords reads one coordinate per line as "lat,lon" or "lat lon", skipping
+// blank lines and # comments.
+func readCoords(r io.Reader) ([]coord, error) {
+	var out []coord
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want lat,lon", n)
+		}
+		c, err := parseCoord(fields[0], fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		out = append(out, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no coordinates given")
+	}
+	return out, nil
+}
+
+func coordArgs(cmd string, args []string) (coord, error) {
+	if len(args) != 2 {
+		return coord{}, fmt.Errorf("%w: %s takes lat lon", errUsage, cmd)
+	}
+	return parseCoord(args[0], args[1])
+}
+
+func parseCoord(latStr, lonStr string) (coord, error) {
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil || math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return coord{}, fmt.Errorf("invalid lat %q", latStr)
+	}
+	lon, err := strconv.ParseFloat(lonStr, 64)
+	if err != nil || math.IsNaN(lon) || lon < -180 || lon > 180 {
+		return coord{}, fmt.Errorf("invalid lon %q", lonStr)
+	}
+	return coord{lat: lat, lon: lon}, nil
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func temp(t forecast.Temperature) string {
+	return fmt.Sprintf("%d°%s", t.Value, t.Unit)
+}
@@ -0,0 +1,192 @@
+// Command weatherctl is a command-line client for on-call engineers. It queries a
+// running weatherd (or NWS directly), inspects and purges the weatherd cache
+// through the admin API, and prints raw NWS documents for debugging.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"weather-service/internal/cache"
+	"weather-service/internal/config"
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+	"weather-service/internal/openmeteo"
+)
+
+const usage = `Usage: weatherctl [flags] <command> [args]
+
+Commands:
+  forecast [lat lon]           today's forecast; reads "lat,lon" lines from stdin without arguments
+  batch <file|->               today's forecast for every "lat,lon" line in file
+  hourly [-n hours] lat lon    hourly forecast from NWS (not through -server)
+  alerts lat lon               active NWS alerts (not through -server)
+  cache stats                  weatherd cache statistics (admin API)
+  cache purge --key K | --prefix P | lat lon
+                               evict weatherd cache entries (admin API)
+  raw points lat lon           NWS /points document
+  raw forecast <url | lat lon> NWS forecast document
+
+Flags:
+`
+
+// app holds the global flags and I/O of one invocation.
+type app struct {
+	server    string
+	serverSet bool // -server was given on the command line
+	admin     string
+	direct    bool
+	output    string
+	nwsURL    string
+	ua        string
+	timeout   time.Duration
+
+	stdin  io.Reader
+	stdout io.Writer
+	http   *http.Client
+}
+
+func main() {
+	a, fs, err := newApp(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "weatherctl:", err)
+			fs.Usage()
+		}
+		os.Exit(2)
+	}
+
+	if err = a.run(context.Background(), fs.Arg(0), fs.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "weatherctl:", err)
+		if errors.Is(err, errUsage) {
+			fs.Usage()
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// newApp parses and validates the global flags in args. The returned flag set
+// holds the command and its arguments; its usage text goes to stderr.
+func newApp(args []string, stdin io.Reader, stdout, stderr io.Writer) (*app, *flag.FlagSet, error) {
+	a := &app{stdin: stdin, stdout: stdout}
+	fs := flag.NewFlagSet("weatherctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&a.server, "server", envOr("WEATHERCTL_SERVER", "http://localhost:8080"), "weatherd base URL")
+	fs.StringVar(&a.admin, "admin", envOr("WEATHERCTL_ADMIN", "http://127.0.0.1:9090"), "weatherd admin API base URL")
+	fs.BoolVar(&a.direct, "direct", false, "compute forecasts locally from NWS instead of asking --server")
+	fs.StringVar(&a.output, "o", formatTable, "output format: table, json or csv")
+	fs.StringVar(&a.nwsURL, "nws-url", envOr("NWS_BASE_URL", "https://api.weather.gov"), "NWS API base URL")
+	fs.StringVar(&a.ua, "user-agent", os.Getenv("NWS_USER_AGENT"), "User-Agent for NWS requests (include contact info)")
+	fs.DurationVar(&a.timeout, "timeout", config.HTTPTimeoutDefault, "HTTP timeout")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, fs, err
+	}
+	fs.Visit(func(f *flag.Flag) { a.serverSet = a.serverSet || f.Name == "server" })
+	if err := a.validate(); err != nil {
+		return nil, fs, err
+	}
+	if fs.NArg() == 0 {
+		return nil, fs, fmt.Errorf("%w: no command given", errUsage)
+	}
+	a.http = &http.Client{Timeout: a.timeout}
+	return a, fs, nil
+}
+
+// validate checks the global flags, so that a typo fails before any request is made.
+func (a *app) validate() error {
+	switch a.output {
+	case formatTable, formatJSON, formatCSV:
+	default:
+		return fmt.Errorf("%w: unknown output format %q (want table, json or csv)", errUsage, a.output)
+	}
+	if a.timeout <= 0 {
+		return fmt.Errorf("%w: -timeout must be positive", errUsage)
+	}
+	for _, f := range []struct{ name, value string }{{"server", a.server}, {"admin", a.admin}, {"nws-url", a.nwsURL}} {
+		u, err := url.Parse(f.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: -%s %q is not an http(s) URL", errUsage, f.name, f.value)
+		}
+	}
+	return nil
+}
+
+var errUsage = errors.New("invalid usage")
+
+// run dispatches a command and renders its result.
+func (a *app) run(ctx context.Context, cmd string, args []string) error {
+	switch cmd {
+	case "hourly", "alerts", "raw":
+		if a.serverSet {
+			return fmt.Errorf("%w: %s reads NWS directly and does not take -server", errUsage, cmd)
+		}
+	}
+	var t table
+	var err error
+	switch cmd {
+	case "forecast":
+		t, err = a.forecast(ctx, args)
+	case "batch":
+		t, err = a.batch(ctx, args)
+	case "hourly":
+		t, err = a.hourly(ctx, args)
+	case "alerts":
+		t, err = a.alerts(ctx, args)
+	case "cache":
+		t, err = a.cache(ctx, args)
+	case "raw":
+		return a.raw(ctx, args)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, cmd)
+	}
+	if t.header != nil {
+		if rerr := render(a.stdout, a.output, t); rerr != nil {
+			return rerr
+		}
+	}
+	return err
+}
+
+// nwsClient returns a client for direct NWS access.
+func (a *app) nwsClient() *nws.Client {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	return nws.NewClient(a.nwsURL, a.ua, a.http, logger)
+}
+
+// openMeteo returns the Open-Meteo client used outside NWS coverage with --direct.
+func (a *app) openMeteo() *openmeteo.Client {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	return openmeteo.NewClient(envOr("OPEN_METEO_BASE_URL", "https://api.open-meteo.com"), a.http, logger)
+}
+
+// forecaster returns where today's forecasts come from: a local forecast service
+// over NWS (and Open-Meteo outside its coverage) with --direct, otherwise the weatherd server.
+func (a *app) forecaster() todayGetter {
+	if a.direct {
+		bands := forecast.NewBandsVar(forecast.Bands{ColdMax: config.ColdMaxDefault, HotMin: config.HotMinDefault})
+		router := forecast.NewRouter(forecast.NWS(a.nwsClient()), a.openMeteo(), nws.Covers)
+		return forecast.NewService(router, cache.NewCache(config.CacheTTLDefault), bands, nil)
+	}
+	return &serverClient{base: strings.TrimRight(a.server, "/"), http: a.http}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"weather-service/internal/config"
+)
+
+// newWeatherd serves /v1/forecast like weatherd, answering 502 for lat 0, and
+// counts the requests it gets.
+func newWeatherd(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/v1/forecast" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"msg":"not found"}`))
+			return
+		}
+		if r.URL.Query().Get("lat") == "0" {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"msg":"upstream down"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"coords":{"lat":39.7392,"lon":-104.9903},"date":"2025-08-13","timeZone":"America/Denver",
+			"today":{"name":"Today","shortForecast":"Sunny, hot","temperature":{"value":91,"unit":"F","type":"hot"}},
+			"isDaytime":true,"source":"api.weather.gov"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestNewApp(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string // error substring; empty for success
+		cmd     string
+		output  string
+		timeout time.Duration
+	}{
+		{name: "defaults", args: []string{"forecast", "1", "2"}, cmd: "forecast", output: "table", timeout: config.HTTPTimeoutDefault},
+		{name: "flags", args: []string{"-o", "csv", "-timeout", "3s", "alerts", "1", "2"}, cmd: "alerts", output: "csv", timeout: 3 * time.Second},
+		{name: "no command", args: []string{"-o", "json"}, want: "no command given"},
+		{name: "bad output", args: []string{"-o", "xml", "forecast", "1", "2"}, want: `unknown output format "xml"`},
+		{name: "zero timeout", args: []string{"-timeout", "0s", "forecast"}, want: "-timeout must be positive"},
+		{name: "bad server", args: []string{"-server", "localhost:8080", "forecast"}, want: `-server "localhost:8080"`},
+		{name: "bad nws url", args: []string{"-nws-url", "ftp://example.com", "alerts", "1", "2"}, want: "-nws-url"},
+		{name: "unknown flag", args: []string{"-x", "forecast"}, want: "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WEATHERCTL_SERVER", "")
+			a, fs, err := newApp(tt.args, nil, &bytes.Buffer{}, &bytes.Buffer{})
+			if tt.want != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Fatalf("err=%v want %q", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newApp: %v", err)
+			}
+			if fs.Arg(0) != tt.cmd || a.output != tt.output || a.timeout != tt.timeout || a.http.Timeout != tt.timeout {
+				t.Fatalf("cmd=%q output=%q timeout=%v", fs.Arg(0), a.output, a.timeout)
+			}
+		})
+	}
+}
+
+func TestRunRejectsBadArgumentsOffline(t *testing.T) {
+	srv, hits := newWeatherd(t)
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "unknown command", args: []string{"forcast"}, want: `unknown command "forcast"`},
+		{name: "forecast arity", args: []string{"forecast", "1"}, want: "forecast takes lat lon"},
+		{name: "forecast lat", args: []string{"forecast", "91", "0"}, want: `invalid lat "91"`},
+		{name: "forecast lon", args: []string{"forecast", "0", "east"}, want: `invalid lon "east"`},
+		{name: "batch arity", args: []string{"batch"}, want: "batch takes one file"},
+		{name: "hourly hours", args: []string{"hourly", "-n", "0", "1", "2"}, want: "-n must be at least 1"},
+		{name: "hourly arity", args: []string{"hourly", "-n", "6"}, want: "hourly takes lat lon"},
+		{name: "alerts arity", args: []string{"alerts", "1", "2", "3"}, want: "alerts takes lat lon"},
+		{name: "cache", args: []string{"cache"}, want: "cache stats|purge"},
+		{name: "cache unknown", args: []string{"cache", "flush"}, want: `unknown cache command "flush"`},
+		{name: "cache purge", args: []string{"cache", "purge"}, want: "cache purge takes lat lon"},
+		{name: "raw", args: []string{"raw", "station"}, want: `unknown raw command "station"`},
+		{name: "hourly server", args: []string{"-server", srv.URL, "hourly", "1", "2"}, want: "hourly reads NWS directly and does not take -server"},
+		{name: "alerts server", args: []string{"-server", srv.URL, "alerts", "1", "2"}, want: "alerts reads NWS directly"},
+		{name: "raw server", args: []string{"-server", srv.URL, "raw", "points", "1", "2"}, want: "raw reads NWS directly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WEATHERCTL_SERVER", srv.URL)
+			args := append([]string{"-admin", srv.URL, "-nws-url", srv.URL}, tt.args...)
+			a, fs, err := newApp(args, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+			if err != nil {
+				t.Fatalf("newApp: %v", err)
+			}
+			err = a.run(context.Background(), fs.Arg(0), fs.Args()[1:])
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err=%v want %q", err, tt.want)
+			}
+			if n := hits.Load(); n != 0 {
+				t.Fatalf("%d requests sent before the arguments were checked", n)
+			}
+		})
+	}
+}
+
+func TestReadCoords(t *testing.T) {
+	got, err := readCoords(strings.NewReader("# depots\n39.7392,-104.9903\n\n40.7128 -74.0060\n"))
+	if err != nil || len(got) != 2 || got[1] != (coord{lat: 40.7128, lon: -74.006}) {
+		t.Fatalf("coords=%v err=%v", got, err)
+	}
+	if _, err = readCoords(strings.NewReader("1,2\n3\n")); err == nil || err.Error() != "line 2: want lat,lon" {
+		t.Fatalf("err=%v want line 2 error", err)
+	}
+	if _, err = readCoords(strings.NewReader("# nothing\n")); err == nil {
+		t.Fatal("empty input accepted")
+	}
+}
+
+func TestForecastOutput(t *testing.T) {
+	srv, _ := newWeatherd(t)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "table", want: "" +
+			"LAT      LON        DATE        PERIOD  TEMP  CLASS  FORECAST    ERROR\n" +
+			"39.7392  -104.9903  2025-08-13  Today   91°F  hot    Sunny, hot  \n" +
+			"0        0                                                       502 Bad Gateway: upstream down\n"},
+		{format: "csv", want: "" +
+			"lat,lon,date,period,temp,class,forecast,error\n" +
+			"39.7392,-104.9903,2025-08-13,Today,91°F,hot,\"Sunny, hot\",\n" +
+			"0,0,,,,,,502 Bad Gateway: upstream down\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			a, fs, err := newApp([]string{"-server", srv.URL, "-o", tt.format, "forecast"},
+				strings.NewReader("39.7392,-104.9903\n0,0\n"), &out, &bytes.Buffer{})
+			if err != nil {
+				t.Fatalf("newApp: %v", err)
+			}
+			err = a.run(context.Background(), fs.Arg(0), fs.Args()[1:])
+			if err == nil || err.Error() != "1 of 2 locations failed" {
+				t.Fatalf("err=%v want one failed location", err)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("output:\n%s\nwant:\n%s", out.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		a, fs, err := newApp([]string{"-server", srv.URL, "-o", "json", "forecast", "39.7392", "-104.9903"},
+			nil, &out, &bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("newApp: %v", err)
+		}
+		if err = a.run(context.Background(), fs.Arg(0), fs.Args()[1:]); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		var rows []batchRow
+		if err = json.Unmarshal(out.Bytes(), &rows); err != nil {
+			t.Fatalf("decode %s: %v", out.String(), err)
+		}
+		if len(rows) != 1 || rows[0].Lat != 39.7392 || rows[0].Result == nil ||
+			rows[0].Result.Today.Temperature.Value != 91 || rows[0].Error != "" {
+			t.Fatalf("rows=%+v", rows)
+		}
+	})
+}
+
+func TestCacheStatsOutput(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/admin/cache/stats" {
+			http.Error(w, `{"msg":"unexpected request"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"hits":12,"misses":3,"entries":5}`))
+	}))
+	defer admin.Close()
+
+	var out bytes.Buffer
+	a, fs, err := newApp([]string{"-admin", admin.URL, "-o", "csv", "cache", "stats"}, nil, &out, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+	if err = a.run(context.Background(), fs.Arg(0), fs.Args()[1:]); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if want := "name,value\nentries,5\nhits,12\nmisses,3\n"; out.String() != want {
+		t.Fatalf("output=%q want %q", out.String(), want)
+	}
+
+	a.admin = admin.URL + "/missing"
+	err = a.run(context.Background(), "cache", []string{"stats"})
+	if err == nil || errors.Is(err, errUsage) || !strings.Contains(err.Error(), "404 Not Found: unexpected request") {
+		t.Fatalf("err=%v want the server's message", err)
+	}
+}
@@ -2,70 +2,178 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"sync/atomic"
 	"syscall"
 	"time"
+	_ "time/tzdata" // embed the IANA database so point time zones resolve in minimal images
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"weather-service/internal/cache"
 	"weather-service/internal/config"
 	"weather-service/internal/forecast"
+	"weather-service/internal/gql"
+	"weather-service/internal/grpcapi"
+	"weather-service/internal/health"
+	"weather-service/internal/history"
 	logpkg "weather-service/internal/log"
 	"weather-service/internal/nws"
+	"weather-service/internal/openmeteo"
+	"weather-service/internal/prefetch"
+	"weather-service/internal/ratelimit"
 	"weather-service/internal/server"
+	"weather-service/internal/stream"
+	"weather-service/internal/subscription"
+	"weather-service/internal/tlsconfig"
+	"weather-service/internal/verify"
 )
 
 const (
 	ReadHeaderTimeout = 5 * time.Second
 	IdleTimeout       = 60 * time.Second
 	contextTimeout    = 5 * time.Second
+
+	// readiness: not ready when under half of the upstream calls in the last
+	// five minutes succeeded, once at least five calls have been made.
+	readyWindow      = 5 * time.Minute
+	readyMinRate     = 0.5
+	readyMinRequests = 5
+
+	webhookAttempts = 5
+	webhookBackoff  = 2 * time.Second
+
+	streamHeartbeat = 15 * time.Second
 )
 
 func main() {
-	cfg := config.FromEnv()
+	configPath := os.Getenv("CONFIG_FILE")
+	if len(os.Args) > 1 && os.Args[1] == "check-config" {
+		if len(os.Args) > 2 {
+			configPath = os.Args[2]
+		}
+		os.Exit(checkConfig(configPath))
+	}
 
-	logger := logpkg.New(cfg.LogLevel)
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		printConfigErrors(err)
+		os.Exit(1)
+	}
+	var active atomic.Pointer[config.Config]
+	active.Store(&cfg)
+
+	level := &slog.LevelVar{}
+	level.Set(cfg.LogLevel)
+	logger := logpkg.New(cfg.LogFormat, level)
 	slog.SetDefault(logger)
 
-	if cfg.NWSUserAgent == "" {
-		logger.Error("NWS_USER_AGENT is required (include contact info)")
+	transport, err := nws.NewTransport(cfg.NWSMode, cfg.NWSFixtures, http.DefaultTransport)
+	if err != nil {
+		logger.Error("nws transport", "err", err)
 		os.Exit(1)
 	}
-
-	httpClient := &http.Client{Timeout: cfg.HTTPTimeout}
+	httpClient := &http.Client{Timeout: cfg.HTTPTimeout, Transport: transport}
 	nwsClient := nws.NewClient(cfg.NWSBaseURL, cfg.NWSUserAgent, httpClient, logger)
+	nwsClient.SetRetryPolicy(retryPolicy(cfg))
 
 	memCache := cache.NewCache(cfg.CacheTTL)
-	svc := forecast.NewService(nwsClient, memCache, forecast.Bands{
+	bands := forecast.NewBandsVar(forecast.Bands{
 		ColdMax: cfg.ColdMax,
 		HotMin:  cfg.HotMin,
 	})
+	subs, archive := openStores(cfg, logger)
+	defer func() { _ = archive.Close() }()
+	router := newRouter(cfg, nwsClient, httpClient, archive, logger)
+	svc := forecast.NewService(router, memCache, bands, archive.Previous)
 
-	h := server.NewHandler(logger, svc)
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go archive.Run(bgCtx, logger)
+	dispatcher := subscription.NewDispatcher(subs, subscription.NewCallbackClient(cfg.HTTPTimeout), logger,
+		webhookAttempts, webhookBackoff)
+	go dispatcher.Run(bgCtx)
+	alerts := nwsAlerts(nwsClient)
+	go subscription.NewPoller(subs, svc, alerts, dispatcher, cfg.SubscriptionPollInterval, logger).Run(bgCtx)
+	served, targets := startPrefetch(bgCtx, cfg, svc, logger)
+	observer := nwsObserver{Client: nwsClient, svc: svc}
+	verifier := startVerification(bgCtx, cfg, svc, targets, observer, archive, bands, logger)
+
+	readiness := newReadiness(nwsClient, router)
+
+	h := server.NewHandler(logger, served)
 	mux := h.Routes()
+	mux.Handle("GET /readyz", server.ReadyHandler(readiness))
+	subsHandler := server.NewSubscriptionHandler(logger, subs)
+	subsHandler.Register(mux)
+	streamHandler := server.NewStreamHandler(logger, stream.NewHub(svc, svc.GridCell, alerts, cfg.StreamPollInterval, logger), streamHeartbeat)
+	streamHandler.Register(mux)
+	server.NewGraphQLHandler(logger, newGraphQL(served, alerts, nwsObservation(observer), logger)).Register(mux)
+	server.NewCalendarHandler(logger, served, alerts).Register(mux)
+	server.NewHistoryHandler(logger, svc.GridCell, archive, bands).Register(mux)
+	verification := server.NewVerificationHandler(logger, verifier)
+	verification.Register(mux)
 
-	srv := &http.Server{
-		Addr:              ":" + cfg.Port,
-		Handler:           server.WithMiddleware(mux),
-		ReadHeaderTimeout: ReadHeaderTimeout,
-		IdleTimeout:       IdleTimeout,
+	admin := server.NewAdminHandler(logger, memCache, svc, func() map[string]string {
+		return active.Load().Redacted()
+	}, level)
+	adminMux := admin.Routes()
+	adminMux.HandleFunc("GET /admin/subscriptions", subsHandler.List)
+	adminMux.HandleFunc("GET /admin/subscriptions/dead-letters", subsHandler.DeadLetters)
+	tlsCfg, err := configureTLS(bgCtx, cfg, logger)
+	if err != nil {
+		logger.Error("tls setup", "err", err)
+		os.Exit(1)
+	}
+	limiter := ratelimit.New(cfg.RateLimitRPS, cfg.RateLimitBurst)
+	var grpcOpts []grpc.ServerOption
+	if tlsCfg != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsCfg)))
 	}
+	grpcSrv := grpcapi.NewServer(logger, served, limiter, grpcOpts...)
+	adminMux.Handle("GET /metrics", server.MetricsHandler(logger, verifier, grpcSrv))
+	adminSrv := newServer(cfg.AdminAddr, adminMux, cfg.CompressionLevel)
+	srv := newServer(":"+cfg.Port, server.WithCORS(server.WithRateLimit(mux, limiter), corsOptions(cfg)), cfg.CompressionLevel)
+	srv.TLSConfig = tlsCfg
+	srv.RegisterOnShutdown(streamHandler.Close)
+	go listen(logger, "weather-service", srv)
+	go listen(logger, "admin listener", adminSrv)
+	go listenGRPC(logger, ":"+cfg.GRPCPort, tlsCfg != nil, grpcSrv)
 
-	go func() {
-		logger.Info("starting weather-service", "port", cfg.Port)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logger.Error("server startup error", "err", err)
-			os.Exit(1)
-		}
-	}()
+	go reloadOnHUP(logger, configPath, &active, func(next config.Config) {
+		level.Set(next.LogLevel)
+		bands.Store(forecast.Bands{ColdMax: next.ColdMax, HotMin: next.HotMin})
+		memCache.SetTTL(next.CacheTTL)
+		limiter.SetRate(next.RateLimitRPS, next.RateLimitBurst)
+	})
 
 	// graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	readiness.SetShuttingDown()
+	grpcSrv.SetServing(false)
+	stopBackground()
+	shutdown(logger, active.Load().ShutdownDrain, srv, adminSrv, grpcSrv)
+}
+
+// shutdown waits drain, so load balancers notice /readyz failing and stop
+// routing new requests here, then drains the listeners, giving in-flight
+// requests up to contextTimeout.
+func shutdown(logger *slog.Logger, drain time.Duration, srv, adminSrv *http.Server, grpcSrv *grpcapi.Server) {
+	if drain > 0 {
+		logger.Info("not ready; waiting before shutdown", "drain", drain)
+		time.Sleep(drain)
+	}
 	logger.Info("initiating graceful shutdown...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
@@ -75,4 +183,282 @@ func main() {
 	} else {
 		logger.Info("server shutdown complete")
 	}
+	if err := adminSrv.Shutdown(ctx); err != nil {
+		logger.Error("admin server shutdown error", "err", err)
+	}
+	grpcSrv.Stop(ctx)
+}
+
+// retryPolicy returns the NWS retry policy configured by the NWS_RETRY_*
+// settings, retrying the statuses of nws.DefaultRetryPolicy.
+func retryPolicy(cfg config.Config) nws.RetryPolicy {
+	p := nws.DefaultRetryPolicy()
+	p.MaxAttempts = cfg.NWSRetryAttempts
+	p.BaseBackoff = cfg.NWSRetryBackoff
+	p.MaxBackoff = cfg.NWSRetryMaxBackoff
+	p.Budget = cfg.NWSRetryBudget
+	p.MaxRetryAfter = cfg.NWSRetryMaxRetryAfter
+	return p
+}
+
+// newRouter routes forecasts to NWS inside its coverage and to the configured
+// fallback provider elsewhere or while NWS is failing. Every forecast either
+// provider fetches is archived.
+func newRouter(cfg config.Config, nwsClient *nws.Client, httpClient *http.Client, archive *history.Store,
+	logger *slog.Logger) *forecast.Router {
+	var fallback forecast.Provider
+	if cfg.Fallback == "open-meteo" {
+		fallback = history.Archive(openmeteo.NewClient(cfg.OpenMeteoURL, httpClient, logger), archive, logger)
+	}
+	return forecast.NewRouter(history.Archive(forecast.NWS(nwsClient), archive, logger), fallback, nws.Covers)
+}
+
+// openStores opens the subscription store and the forecast history, exiting
+// when either cannot be opened.
+func openStores(cfg config.Config, logger *slog.Logger) (*subscription.Store, *history.Store) {
+	subs, err := subscription.OpenStore(cfg.SubscriptionsFile)
+	if err != nil {
+		logger.Error("open subscription store", "err", err)
+		os.Exit(1)
+	}
+	archive, err := history.Open(cfg.HistoryFile, cfg.HistoryRetention)
+	if err != nil {
+		logger.Error("open forecast history", "err", err)
+		os.Exit(1)
+	}
+	return subs, archive
+}
+
+// startPrefetch keeps the configured locations and the most requested ones
+// warm until ctx is done. It returns svc counting the requests it serves, for
+// the listeners to use, and the locations kept warm.
+func startPrefetch(ctx context.Context, cfg config.Config, svc forecast.Internal,
+	logger *slog.Logger) (forecast.Service, func() []prefetch.Location) {
+	if len(cfg.PrefetchLocations) == 0 && cfg.PrefetchTopN == 0 {
+		return svc, func() []prefetch.Location { return nil }
+	}
+	tracker := prefetch.NewTracker()
+	p := prefetch.New(svc, tracker, prefetch.Options{
+		Locations: cfg.PrefetchLocations,
+		TopN:      cfg.PrefetchTopN,
+		Lead:      cfg.PrefetchLead,
+	}, logger)
+	go p.Run(ctx)
+	return prefetch.Track(svc, tracker), p.Targets
+}
+
+// startVerification scores the forecasts of the locations kept warm against
+// NWS station observations until ctx is done, when verification is enabled.
+// Scores are kept in the forecast history file.
+func startVerification(ctx context.Context, cfg config.Config, svc forecast.Service, targets func() []prefetch.Location,
+	obs verify.Observer, archive *history.Store, bands *forecast.BandsVar, logger *slog.Logger) *verify.Verifier {
+	v := verify.New(svc, obs, bands, verify.Options{
+		Targets:  targets,
+		LeadDays: cfg.VerificationLeadDays,
+		Interval: cfg.VerificationInterval,
+		Store:    archive,
+	}, logger)
+	if cfg.VerificationEnabled {
+		go v.Run(ctx)
+	}
+	return v
+}
+
+// newGraphQL builds the GraphQL executor over svc, exiting when the schema is
+// invalid.
+func newGraphQL(svc forecast.Service, alerts gql.AlertsFunc, obs gql.ObservationFunc, logger *slog.Logger) *gql.Executor {
+	graph, err := gql.NewExecutor(svc, alerts, obs)
+	if err != nil {
+		logger.Error("build graphql schema", "err", err)
+		os.Exit(1)
+	}
+	return graph
+}
+
+// corsOptions returns the CORS settings of the public listener.
+func corsOptions(cfg config.Config) server.CORSOptions {
+	return server.CORSOptions{
+		AllowedOrigins:   cfg.CORSOrigins,
+		AllowedMethods:   cfg.CORSMethods,
+		AllowedHeaders:   cfg.CORSHeaders,
+		AllowCredentials: cfg.CORSCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}
+}
+
+// configureTLS returns the TLS configuration shared by the HTTP and gRPC
+// listeners, reloading the certificate until ctx is done, or nil when no
+// certificate is configured.
+func configureTLS(ctx context.Context, cfg config.Config, logger *slog.Logger) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil //nolint:nilnil // plain HTTP and gRPC
+	}
+	cert, err := tlsconfig.LoadCertificate(cfg.TLSCertFile, cfg.TLSKeyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg, err := tlsconfig.New(tlsconfig.Options{
+		ClientCAFile: cfg.TLSClientCAFile,
+		ClientAuth:   cfg.TLSClientAuth,
+		MinVersion:   cfg.TLSMinVersion,
+		CipherSuites: cfg.TLSCipherSuites,
+	}, cert)
+	if err != nil {
+		return nil, err
+	}
+	go cert.Watch(ctx, tlsconfig.ReloadInterval)
+	return tlsCfg, nil
+}
+
+// nwsAlerts fetches alerts from NWS for locations it covers; elsewhere there are none.
+func nwsAlerts(nwsClient *nws.Client) func(ctx context.Context, lat, lon float64) ([]nws.Alert, error) {
+	return func(ctx context.Context, lat, lon float64) ([]nws.Alert, error) {
+		if !nws.Covers(lat, lon) {
+			return nil, nil
+		}
+		return nwsClient.Alerts(ctx, lat, lon)
+	}
+}
+
+// nwsObserver reads NWS station observations, finding a location's stations
+// through the service's cached point rather than a /points lookup of its own.
+type nwsObserver struct {
+	*nws.Client
+	svc forecast.Internal
+}
+
+// Stations returns the observation stations near lat/lon, nearest first.
+func (o nwsObserver) Stations(ctx context.Context, lat, lon float64) ([]string, error) {
+	u, err := o.svc.StationsURL(ctx, lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	return o.Client.Stations(ctx, u)
+}
+
+// nwsObservation returns the latest observation of the station nearest to a
+// location, or nil outside NWS coverage.
+func nwsObservation(obs nwsObserver) func(ctx context.Context, lat, lon float64) (*nws.Observation, error) {
+	return func(ctx context.Context, lat, lon float64) (*nws.Observation, error) {
+		if !nws.Covers(lat, lon) {
+			return nil, nil //nolint:nilnil // no observation outside coverage
+		}
+		stations, err := obs.Stations(ctx, lat, lon)
+		if err != nil || len(stations) == 0 {
+			return nil, err
+		}
+		o, err := obs.LatestObservation(ctx, stations[0])
+		if err != nil {
+			return nil, err
+		}
+		return &o, nil
+	}
+}
+
+// newReadiness returns the readiness checks of the service.
+func newReadiness(nwsClient *nws.Client, router *forecast.Router) *health.Readiness {
+	readiness := health.NewReadiness()
+	readiness.Add("upstream", health.UpstreamCheck(nwsClient.SuccessRate, readyWindow, readyMinRate, readyMinRequests))
+	readiness.Add("circuit", health.CircuitCheck(router.OpenUntil))
+	return readiness
+}
+
+// newServer returns an http.Server for addr serving h behind the standard middleware.
+func newServer(addr string, h http.Handler, compressionLevel int) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           server.WithMiddleware(h, compressionLevel),
+		ReadHeaderTimeout: ReadHeaderTimeout,
+		IdleTimeout:       IdleTimeout,
+	}
+}
+
+// listen runs srv until it is shut down, exiting the process if it cannot start.
+func listen(logger *slog.Logger, name string, srv *http.Server) {
+	logger.Info("starting "+name, "addr", srv.Addr, "tls", srv.TLSConfig != nil)
+	serve := srv.ListenAndServe
+	if srv.TLSConfig != nil {
+		serve = func() error { return srv.ListenAndServeTLS("", "") } // certificate from TLSConfig
+	}
+	if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error(name+" startup error", "err", err)
+		os.Exit(1)
+	}
+}
+
+// listenGRPC serves the gRPC API on addr until it is stopped, exiting the process
+// if it cannot start.
+func listenGRPC(logger *slog.Logger, addr string, tlsOn bool, srv *grpcapi.Server) {
+	logger.Info("starting grpc listener", "addr", addr, "tls", tlsOn)
+	lis, err := net.Listen("tcp", addr)
+	if err == nil {
+		err = srv.Serve(lis)
+	}
+	if err != nil {
+		logger.Error("grpc listener startup error", "err", err)
+		os.Exit(1)
+	}
+}
+
+// reloadOnHUP reloads the configuration on every SIGHUP. A valid configuration is
+// handed to apply and becomes the active one, except that settings that need a
+// restart keep their current values and are only logged. An invalid
+// configuration is rejected and the current one kept.
+func reloadOnHUP(logger *slog.Logger, configPath string, active *atomic.Pointer[config.Config],
+	apply func(config.Config),
+) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		next, err := config.Load(configPath)
+		if err != nil {
+			logger.Error("config reload rejected; keeping current configuration", "err", err)
+			continue
+		}
+		cur := active.Load()
+		if changed := cur.RestartRequired(next); len(changed) > 0 {
+			logger.Warn("config changes require a restart and were not applied", "settings", changed)
+		}
+		if next, err = cur.Reloaded(next); err != nil {
+			logger.Error("config reload rejected; keeping current configuration", "err", err)
+			continue
+		}
+		apply(next)
+		active.Store(&next)
+		logger.Info("config reloaded", "log_level", next.LogLevel, "cache_ttl", next.CacheTTL)
+	}
+}
+
+// checkConfig implements `weatherd check-config [file]`: it validates the
+// configuration and prints either every problem found or the effective settings.
+func checkConfig(path string) int {
+	cfg, err := config.Load(path)
+	if err != nil {
+		printConfigErrors(err)
+		return 1
+	}
+	fmt.Println("configuration OK")
+	settings := cfg.Redacted()
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("  %s=%s\n", k, settings[k])
+	}
+	return 0
+}
+
+// printConfigErrors writes each joined configuration error on its own line.
+func printConfigErrors(err error) {
+	fmt.Fprintln(os.Stderr, "invalid configuration:")
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		for _, e := range joined.Unwrap() {
+			fmt.Fprintln(os.Stderr, "  -", e)
+		}
+		return
+	}
+	fmt.Fprintln(os.Stderr, "  -", err)
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"weather-service/internal/cache"
+	"weather-service/internal/config"
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+	"weather-service/internal/server"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// TestReplayFixtures serves the API from the committed fixtures in
+// testdata/nws, the way `NWS_MODE=replay make run` does, and fails on any
+// request that would reach the network.
+func TestReplayFixtures(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NWS_MODE", "replay")
+	t.Setenv("NWS_FIXTURES_DIR", filepath.Join("..", "..", "testdata", "nws"))
+	t.Setenv("NWS_USER_AGENT", "weatherd-test (ops@example.com)")
+	t.Setenv("SUBSCRIPTIONS_FILE", filepath.Join(dir, "subscriptions.json"))
+	t.Setenv("HISTORY_FILE", filepath.Join(dir, "history.db"))
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	offline := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		t.Errorf("request reached the network: %s", r.URL)
+		return nil, errors.New("network disabled")
+	})
+	transport, err := nws.NewTransport(cfg.NWSMode, cfg.NWSFixtures, offline)
+	if err != nil {
+		t.Fatalf("transport: %v", err)
+	}
+	var lookups atomic.Int32 // fixtures looked up for the unrecorded point
+	counting := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if strings.HasPrefix(r.URL.Path, "/points/40.000000,-105.000000") {
+			lookups.Add(1)
+		}
+		return transport.RoundTrip(r)
+	})
+	httpClient := &http.Client{Timeout: cfg.HTTPTimeout, Transport: counting}
+	nwsClient := nws.NewClient(cfg.NWSBaseURL, cfg.NWSUserAgent, httpClient, logger)
+	nwsClient.SetRetryPolicy(retryPolicy(cfg))
+	_, archive := openStores(cfg, logger)
+	t.Cleanup(func() { _ = archive.Close() })
+	svc := forecast.NewService(newRouter(cfg, nwsClient, httpClient, archive, logger), cache.NewCache(cfg.CacheTTL),
+		forecast.NewBandsVar(forecast.Bands{ColdMax: cfg.ColdMax, HotMin: cfg.HotMin}), archive.Previous)
+
+	mux := server.NewHandler(logger, svc).Routes()
+	server.NewGraphQLHandler(logger, newGraphQL(svc, nwsAlerts(nwsClient), nwsObservation(nwsObserver{Client: nwsClient, svc: svc}), logger)).Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var res forecast.Result
+	get(t, srv.URL+"/v1/forecast?lat=39.7392&lon=-104.9903", &res)
+	if res.Source != "api.weather.gov" || res.TimeZone != "America/Denver" || res.Today.Temperature.Value != 94 ||
+		res.Meta == nil || res.Meta.Updated != "2025-08-13T14:52:31Z" {
+		t.Fatalf("forecast=%+v", res)
+	}
+	var daily forecast.DailyResult
+	get(t, srv.URL+"/v1/forecast/daily/2025-08-15?lat=39.7392&lon=-104.9903", &daily)
+	if daily.High == nil || daily.High.Value != 86 || daily.Low == nil || daily.Low.Value != 60 {
+		t.Fatalf("daily=%+v", daily)
+	}
+
+	body := strings.NewReader(`{"query":"{ location(lat: 39.7392, lon: -104.9903) { alerts { event } observation { station temperature } } }"}`)
+	resp, err := http.Post(srv.URL+"/graphql", "application/json", body)
+	if err != nil {
+		t.Fatalf("graphql: %v", err)
+	}
+	defer resp.Body.Close()
+	var gq struct {
+		Data struct {
+			Location struct {
+				Alerts      []struct{ Event string }
+				Observation *struct {
+					Station     string
+					Temperature float64
+				}
+			}
+		}
+		Errors []struct{ Message string }
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&gq); err != nil {
+		t.Fatalf("decode graphql: %v", err)
+	}
+	loc := gq.Data.Location
+	if len(gq.Errors) > 0 || len(loc.Alerts) != 1 || loc.Alerts[0].Event != "Heat Advisory" ||
+		loc.Observation == nil || !strings.HasSuffix(loc.Observation.Station, "/KBKF") || loc.Observation.Temperature != 77 {
+		t.Fatalf("graphql=%+v", gq)
+	}
+
+	// A location that was never recorded fails without retrying the lookup.
+	resp, err = http.Get(srv.URL + "/v1/forecast?lat=40&lon=-105")
+	if err != nil {
+		t.Fatalf("unrecorded location: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || lookups.Load() != 1 {
+		t.Fatalf("unrecorded location: %s after %d lookups, want 502 after 1", resp.Status, lookups.Load())
+	}
+}
+
+func get(t *testing.T, url string, out any) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("GET %s: %s %s", url, resp.Status, b)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("decode %s: %v", url, err)
+	}
+}
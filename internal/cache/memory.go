@@ -2,15 +2,27 @@
 package cache
 
 import (
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
 // Memory is a TTL-based in-memory cache safe for concurrent use.
 type Memory struct {
-	mu    sync.Mutex
-	items map[string]entry
-	ttl   time.Duration
+	mu     sync.Mutex
+	items  map[string]entry
+	ttl    time.Duration
+	hits   uint64
+	misses uint64
+}
+
+// Stats is a point-in-time snapshot of cache usage.
+type Stats struct {
+	Entries int           `json:"entries"`
+	Hits    uint64        `json:"hits"`
+	Misses  uint64        `json:"misses"`
+	TTL     time.Duration `json:"ttl"`
 }
 
 type entry struct {
@@ -33,15 +45,55 @@ func (m *Memory) Get(key string) (any, bool) {
 
 	e, ok := m.items[key]
 	if !ok {
+		m.misses++
 		return nil, false
 	}
 	if time.Now().After(e.exp) {
 		delete(m.items, key)
+		m.misses++
 		return nil, false
 	}
+	m.hits++
 	return e.v, true
 }
 
+// Peek is like Get but also returns the entry's expiry and does not count
+// towards hit/miss statistics. It is meant for inspection, not serving.
+func (m *Memory) Peek(key string) (any, time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.items[key]
+	if !ok || time.Now().After(e.exp) {
+		return nil, time.Time{}, false
+	}
+	return e.v, e.exp, true
+}
+
+// Keys returns the sorted keys of unexpired entries starting with prefix.
+func (m *Memory) Keys(prefix string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	keys := make([]string, 0, len(m.items))
+	for k, e := range m.items {
+		if strings.HasPrefix(k, prefix) && !now.After(e.exp) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Stats returns current usage counters. Entries may include expired entries
+// that have not been evicted yet.
+func (m *Memory) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Stats{Entries: len(m.items), Hits: m.hits, Misses: m.misses, TTL: m.ttl}
+}
+
 // Set stores a value by key with the configured TTL.
 func (m *Memory) Set(key string, v any) {
 	m.mu.Lock()
@@ -49,9 +101,33 @@ func (m *Memory) Set(key string, v any) {
 	m.items[key] = entry{v: v, exp: time.Now().Add(m.ttl)}
 }
 
-// Delete removes a key from the cache.
-func (m *Memory) Delete(key string) {
+// SetTTL changes the TTL applied to entries stored from now on. Existing entries
+// keep the expiry they were stored with.
+func (m *Memory) SetTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ttl = ttl
+}
+
+// Delete removes a key from the cache and reports whether it was present.
+func (m *Memory) Delete(key string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	_, ok := m.items[key]
 	delete(m.items, key)
+	return ok
+}
+
+// DeletePrefix removes every key starting with prefix and returns how many were removed.
+func (m *Memory) DeletePrefix(prefix string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for k := range m.items {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.items, k)
+			n++
+		}
+	}
+	return n
 }
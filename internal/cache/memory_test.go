@@ -109,3 +109,44 @@ func TestConcurrentAccess(t *testing.T) {
 		}
 	}
 }
+
+func TestSetTTLAppliesToNewEntries(t *testing.T) {
+	c := cache.NewCache(10 * time.Millisecond)
+	c.Set("old", 1)
+	c.SetTTL(time.Minute)
+	c.Set("new", 2)
+	time.Sleep(15 * time.Millisecond)
+	if _, ok := c.Get("old"); ok {
+		t.Fatalf("expected entry stored before SetTTL to keep its original expiry")
+	}
+	if _, ok := c.Get("new"); !ok {
+		t.Fatalf("expected entry stored after SetTTL to use the new TTL")
+	}
+}
+
+func TestKeysPeekStatsAndDeletePrefix(t *testing.T) {
+	c := cache.NewCache(time.Minute)
+	c.Set("points:1,2", "a")
+	c.Set("points:3,4", "b")
+	c.Set("forecast:x", "c")
+
+	if keys := c.Keys("points:"); len(keys) != 2 || keys[0] != "points:1,2" {
+		t.Fatalf("Keys=%v", keys)
+	}
+
+	if v, exp, ok := c.Peek("forecast:x"); !ok || v != "c" || exp.IsZero() {
+		t.Fatalf("Peek=(%v,%v,%v)", v, exp, ok)
+	}
+	c.Get("forecast:x")
+	c.Get("missing")
+	if st := c.Stats(); st.Entries != 3 || st.Hits != 1 || st.Misses != 1 || st.TTL != time.Minute {
+		t.Fatalf("Stats=%+v", st)
+	}
+
+	if n := c.DeletePrefix("points:"); n != 2 {
+		t.Fatalf("DeletePrefix removed %d want 2", n)
+	}
+	if !c.Delete("forecast:x") || c.Delete("forecast:x") {
+		t.Fatalf("Delete should report presence only once")
+	}
+}
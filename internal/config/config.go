@@ -1,12 +1,26 @@
-// Package config loads application configuration from environment variables.
+// Package config loads application configuration from an optional config file
+// layered under environment variables.
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"net"
+	"net/url"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"weather-service/internal/nws"
+	"weather-service/internal/prefetch"
+	"weather-service/internal/tlsconfig"
 )
 
 const (
@@ -14,69 +28,550 @@ const (
 	CacheTTLDefault    = 10 * time.Minute
 	ColdMaxDefault     = 45
 	HotMinDefault      = 85
+
+	ShutdownDrainDefault = 5 * time.Second
+
+	CompressionLevelDefault = 5
+	CORSMaxAgeDefault       = 10 * time.Minute
+
+	RateLimitBurstDefault = 20
+
+	PrefetchTopNDefault = 10
+	PrefetchLeadDefault = time.Minute
+
+	SubscriptionPollDefault = 5 * time.Minute
+	StreamPollDefault       = time.Minute
+
+	HistoryRetentionDefault = 30 * 24 * time.Hour
+
+	VerificationIntervalDefault = time.Hour
+	VerificationLeadDaysDefault = "0,1,2,3"
+
+	redacted = "[redacted]"
 )
 
+// defaults lists every recognised setting keyed by its environment variable name.
+// Config files use the same names in lower case (e.g. cache_ttl).
+var defaults = map[string]string{
+	"PORT":                "8080",
+	"GRPC_PORT":           "9000",
+	"ADMIN_ADDR":          "127.0.0.1:9090",
+	"LOG_LEVEL":           "INFO",
+	"LOG_FORMAT":          "text",
+	"HTTP_TIMEOUT":        HTTPTimeoutDefault.String(),
+	"NWS_BASE_URL":        "https://api.weather.gov",
+	"NWS_USER_AGENT":      "",
+	"NWS_MODE":            "live",
+	"NWS_FIXTURES_DIR":    "testdata/nws",
+	"FALLBACK_PROVIDER":   "open-meteo",
+	"OPEN_METEO_BASE_URL": "https://api.open-meteo.com",
+	"CACHE_TTL":           CacheTTLDefault.String(),
+	"TEMP_BAND_COLD_MAX":  strconv.Itoa(ColdMaxDefault),
+	"TEMP_BAND_HOT_MIN":   strconv.Itoa(HotMinDefault),
+	"COMPRESSION_LEVEL":   strconv.Itoa(CompressionLevelDefault),
+	"SHUTDOWN_DRAIN":      ShutdownDrainDefault.String(),
+
+	"NWS_RETRY_ATTEMPTS":        strconv.Itoa(nws.DefaultRetryPolicy().MaxAttempts),
+	"NWS_RETRY_BACKOFF":         nws.DefaultRetryPolicy().BaseBackoff.String(),
+	"NWS_RETRY_MAX_BACKOFF":     nws.DefaultRetryPolicy().MaxBackoff.String(),
+	"NWS_RETRY_BUDGET":          nws.DefaultRetryPolicy().Budget.String(),
+	"NWS_RETRY_MAX_RETRY_AFTER": nws.DefaultRetryPolicy().MaxRetryAfter.String(),
+
+	"CORS_ALLOWED_ORIGINS":   "",
+	"CORS_ALLOWED_METHODS":   "GET,HEAD,POST,DELETE",
+	"CORS_ALLOWED_HEADERS":   "Accept,Content-Type,Authorization,X-Request-ID,If-None-Match,If-Modified-Since,Last-Event-ID",
+	"CORS_ALLOW_CREDENTIALS": "false",
+	"CORS_MAX_AGE":           CORSMaxAgeDefault.String(),
+
+	"TLS_CERT_FILE":      "",
+	"TLS_KEY_FILE":       "",
+	"TLS_MIN_VERSION":    "1.2",
+	"TLS_CIPHER_SUITES":  "",
+	"TLS_CLIENT_CA_FILE": "",
+	"TLS_CLIENT_AUTH":    tlsconfig.ClientAuthRequire,
+
+	"RATE_LIMIT_RPS":   "0",
+	"RATE_LIMIT_BURST": strconv.Itoa(RateLimitBurstDefault),
+
+	"PREFETCH_LOCATIONS": "",
+	"PREFETCH_TOP_N":     strconv.Itoa(PrefetchTopNDefault),
+	"PREFETCH_LEAD":      PrefetchLeadDefault.String(),
+
+	"SUBSCRIPTIONS_FILE":         "subscriptions.json",
+	"SUBSCRIPTION_POLL_INTERVAL": SubscriptionPollDefault.String(),
+	"STREAM_POLL_INTERVAL":       StreamPollDefault.String(),
+
+	"HISTORY_FILE":      "history.db",
+	"HISTORY_RETENTION": HistoryRetentionDefault.String(),
+
+	"VERIFICATION_ENABLED":   "false",
+	"VERIFICATION_INTERVAL":  VerificationIntervalDefault.String(),
+	"VERIFICATION_LEAD_DAYS": VerificationLeadDaysDefault,
+}
+
+// secrets are settings whose values are never shown by Redacted.
+var secrets = map[string]bool{
+	"NWS_USER_AGENT": true, // carries operator contact details
+}
+
+// restartOnly are settings that cannot be applied to a running process.
+var restartOnly = []string{"PORT", "GRPC_PORT", "ADMIN_ADDR", "LOG_FORMAT", "HTTP_TIMEOUT", "NWS_BASE_URL", "NWS_USER_AGENT",
+	"NWS_MODE", "NWS_FIXTURES_DIR", "NWS_RETRY_ATTEMPTS", "NWS_RETRY_BACKOFF", "NWS_RETRY_MAX_BACKOFF", "NWS_RETRY_BUDGET",
+	"NWS_RETRY_MAX_RETRY_AFTER", "FALLBACK_PROVIDER", "OPEN_METEO_BASE_URL", "COMPRESSION_LEVEL",
+	"CORS_ALLOWED_ORIGINS", "CORS_ALLOWED_METHODS", "CORS_ALLOWED_HEADERS", "CORS_ALLOW_CREDENTIALS", "CORS_MAX_AGE",
+	"TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_MIN_VERSION", "TLS_CIPHER_SUITES", "TLS_CLIENT_CA_FILE", "TLS_CLIENT_AUTH",
+	"PREFETCH_LOCATIONS", "PREFETCH_TOP_N", "PREFETCH_LEAD", "SUBSCRIPTIONS_FILE", "SUBSCRIPTION_POLL_INTERVAL", "STREAM_POLL_INTERVAL",
+	"HISTORY_FILE", "HISTORY_RETENTION", "VERIFICATION_ENABLED", "VERIFICATION_INTERVAL", "VERIFICATION_LEAD_DAYS",
+}
+
 // Config represents runtime configuration settings for the service.
-// Values are typically sourced from environment variables; see FromEnv.
+// Values are sourced from defaults, then an optional config file, then
+// environment variables; see Load.
 type Config struct {
 	Port         string        // HTTP port to listen on
+	GRPCPort     string        // gRPC port to listen on
+	AdminAddr    string        // host:port of the admin listener (localhost only by default)
 	LogLevel     slog.Level    // Log level (DEBUG|INFO|WARN|ERROR)
+	LogFormat    string        // Log output format (text|json)
 	HTTPTimeout  time.Duration // Timeout for outbound HTTP requests
 	NWSBaseURL   string        // Base URL for api.weather.gov
 	NWSUserAgent string        // Required User-Agent for NWS requests
+	NWSMode      string        // NWS transport: live, record or replay
+	NWSFixtures  string        // Fixtures directory used when recording or replaying
+	Fallback     string        // Provider outside NWS coverage: open-meteo or none
+	OpenMeteoURL string        // Base URL for the Open-Meteo forecast API
 	CacheTTL     time.Duration // In-memory cache TTL
 	ColdMax      int           // Max Temperature in Fahrenheit to be considered "cold"
 	HotMin       int           // Min Temperature in Fahrenheit to be considered "hot"
+
+	ShutdownDrain time.Duration // After SIGTERM, how long /readyz reports not ready before the listeners close
+
+	NWSRetryAttempts      int           // NWS attempts per call, including the first; 1 disables retries
+	NWSRetryBackoff       time.Duration // Backoff ceiling after the first failed attempt, doubled after each one
+	NWSRetryMaxBackoff    time.Duration // Cap on the backoff ceiling
+	NWSRetryBudget        time.Duration // Total time one NWS call may spend waiting between attempts
+	NWSRetryMaxRetryAfter time.Duration // Longest NWS Retry-After waited out; longer ones fail the call
+
+	CompressionLevel int // gzip/zstd response compression level: 1 (fastest) to 9, 0 disables
+
+	CORSOrigins     []string      // Browser origins allowed to call the API (exact, https://*.example.com or *); empty disables CORS
+	CORSMethods     []string      // Methods allowed in CORS requests
+	CORSHeaders     []string      // Request headers allowed in CORS requests (* for any)
+	CORSCredentials bool          // Whether CORS requests may carry cookies or client certificates
+	CORSMaxAge      time.Duration // How long browsers may cache a preflight answer
+
+	TLSCertFile     string   // PEM certificate (chain) served on PORT; empty serves plain HTTP
+	TLSKeyFile      string   // PEM private key of TLSCertFile
+	TLSMinVersion   string   // Minimum TLS version: 1.2 or 1.3
+	TLSCipherSuites []string // TLS 1.2 cipher suite names; empty keeps Go's defaults
+	TLSClientCAFile string   // PEM CA bundle enabling mutual TLS; empty disables it
+	TLSClientAuth   string   // With a client CA: require or verify-if-given
+
+	RateLimitRPS   int // Requests per second each caller may make over HTTP and gRPC; 0 disables limiting
+	RateLimitBurst int // Requests a caller may make at once before RateLimitRPS applies
+
+	PrefetchLocations []prefetch.Location // Locations whose forecasts are always kept warm
+	PrefetchTopN      int                 // Most requested locations also kept warm; 0 disables learning
+	PrefetchLead      time.Duration       // How long before cache expiry prefetched entries are refreshed
+
+	SubscriptionsFile        string        // JSON file persisting webhook subscriptions
+	SubscriptionPollInterval time.Duration // How often subscribed locations are checked
+	StreamPollInterval       time.Duration // How often each streamed grid cell is checked
+
+	HistoryFile      string        // Embedded database archiving every fetched forecast
+	HistoryRetention time.Duration // How long superseded forecasts are kept
+
+	VerificationEnabled  bool          // Score prefetched locations' forecasts against station observations
+	VerificationInterval time.Duration // How often forecasts and observations are recorded
+	VerificationLeadDays []int         // How many days ahead verified forecasts are made
+
+	raw map[string]string
 }
 
-// FromEnv builds a Config from environment variables, applying sensible defaults.
-func FromEnv() Config {
-	return Config{
-		Port:         getenv("PORT", "8080"),
-		LogLevel:     parseLevel(getenv("LOG_LEVEL", "INFO")),
-		HTTPTimeout:  parseDur(getenv("HTTP_TIMEOUT", "5s"), HTTPTimeoutDefault),
-		NWSBaseURL:   getenv("NWS_BASE_URL", "https://api.weather.gov"),
-		NWSUserAgent: getenv("NWS_USER_AGENT", ""),
-		CacheTTL:     parseDur(getenv("CACHE_TTL", "10m"), CacheTTLDefault),
-		ColdMax:      parseInt(getenv("TEMP_BAND_COLD_MAX", "45"), ColdMaxDefault),
-		HotMin:       parseInt(getenv("TEMP_BAND_HOT_MIN", "85"), HotMinDefault),
+// Load builds a Config from defaults, the YAML or JSON file at path (skipped when
+// path is empty) and environment variables, in increasing order of precedence.
+// Invalid values are never replaced by defaults: the returned error joins one
+// error per problem so callers can print them all at once.
+func Load(path string) (Config, error) {
+	raw := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		raw[k] = v
 	}
+	if path != "" {
+		fileVals, err := readFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		for k, v := range fileVals {
+			raw[k] = v
+		}
+	}
+	for k := range defaults {
+		if v := os.Getenv(k); v != "" {
+			raw[k] = v
+		}
+	}
+	return parse(raw)
 }
 
-// getenv returns the value of an environment variable, or the default if it is not set.
-func getenv(k, def string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
+// Redacted returns the effective settings keyed by environment variable name,
+// with secret values replaced so the result is safe to expose.
+func (c Config) Redacted() map[string]string {
+	out := make(map[string]string, len(c.raw))
+	for k, v := range c.raw {
+		if secrets[k] && v != "" {
+			v = redacted
+		}
+		out[k] = v
 	}
-	return def
+	return out
 }
 
-// parseDur converts a string to a time.Duration, returning the default if the conversion fails.
-func parseDur(s string, def time.Duration) time.Duration {
-	if d, err := time.ParseDuration(s); err == nil {
-		return d
+// RestartRequired returns the names of settings that differ between c and next
+// but only take effect after a restart.
+func (c Config) RestartRequired(next Config) []string {
+	var changed []string
+	for _, k := range restartOnly {
+		if c.raw[k] != next.raw[k] {
+			changed = append(changed, k)
+		}
 	}
-	return def
+	return changed
 }
 
-// parseInt converts a string to an integer, returning the default if the conversion fails.
-func parseInt(s string, def int) int {
-	if i, err := strconv.Atoi(s); err == nil {
-		return i
+// Reloaded returns next with c's values for the settings that only take effect
+// after a restart: the configuration a process started with c runs with once
+// next is applied.
+func (c Config) Reloaded(next Config) (Config, error) {
+	raw := maps.Clone(next.raw)
+	for _, k := range restartOnly {
+		raw[k] = c.raw[k]
 	}
-	return def
+	return parse(raw)
 }
 
-// parseLevel converts a string to a slog.Level, returning slog.LevelInfo if the conversion fails.
-func parseLevel(s string) slog.Level {
-	switch strings.ToUpper(strings.TrimSpace(s)) {
+// readFile decodes a flat YAML (or JSON) mapping of lower-case setting names to
+// scalar values. Unknown keys are rejected so that typos do not go unnoticed.
+func readFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // path is operator supplied
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var doc map[string]any
+	if err = yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	var errs []error
+	out := make(map[string]string, len(doc))
+	for k, v := range doc {
+		key := strings.ToUpper(k)
+		if _, ok := defaults[key]; !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, k))
+			continue
+		}
+		switch v.(type) {
+		case map[string]any, []any:
+			errs = append(errs, fmt.Errorf("%s: %s must be a scalar value", path, k))
+			continue
+		case nil:
+			continue
+		}
+		out[key] = fmt.Sprint(v)
+	}
+	sortErrs(errs)
+	return out, errors.Join(errs...)
+}
+
+// parse validates raw settings and converts them into a Config.
+func parse(raw map[string]string) (Config, error) {
+	p := parser{raw: raw}
+	cfg := Config{
+		Port:         p.port("PORT"),
+		GRPCPort:     p.port("GRPC_PORT"),
+		AdminAddr:    p.hostPort("ADMIN_ADDR"),
+		LogLevel:     p.level("LOG_LEVEL"),
+		LogFormat:    p.oneOf("LOG_FORMAT", "text", "json"),
+		HTTPTimeout:  p.duration("HTTP_TIMEOUT"),
+		NWSBaseURL:   p.url("NWS_BASE_URL"),
+		NWSUserAgent: p.required("NWS_USER_AGENT", "include contact info per NWS guidance"),
+		NWSMode:      p.oneOf("NWS_MODE", "live", "record", "replay"),
+		NWSFixtures:  p.required("NWS_FIXTURES_DIR", "directory of recorded NWS responses"),
+		Fallback:     p.oneOf("FALLBACK_PROVIDER", "open-meteo", "none"),
+		OpenMeteoURL: p.url("OPEN_METEO_BASE_URL"),
+		CacheTTL:     p.duration("CACHE_TTL"),
+		ColdMax:      p.int("TEMP_BAND_COLD_MAX"),
+		HotMin:       p.int("TEMP_BAND_HOT_MIN"),
+
+		ShutdownDrain: p.delay("SHUTDOWN_DRAIN"),
+
+		NWSRetryAttempts:      p.int("NWS_RETRY_ATTEMPTS"),
+		NWSRetryBackoff:       p.duration("NWS_RETRY_BACKOFF"),
+		NWSRetryMaxBackoff:    p.duration("NWS_RETRY_MAX_BACKOFF"),
+		NWSRetryBudget:        p.duration("NWS_RETRY_BUDGET"),
+		NWSRetryMaxRetryAfter: p.delay("NWS_RETRY_MAX_RETRY_AFTER"),
+
+		CompressionLevel: p.int("COMPRESSION_LEVEL"),
+
+		CORSOrigins:     p.origins("CORS_ALLOWED_ORIGINS"),
+		CORSMethods:     p.list("CORS_ALLOWED_METHODS"),
+		CORSHeaders:     p.list("CORS_ALLOWED_HEADERS"),
+		CORSCredentials: p.bool("CORS_ALLOW_CREDENTIALS"),
+		CORSMaxAge:      p.duration("CORS_MAX_AGE"),
+
+		TLSCertFile:     strings.TrimSpace(raw["TLS_CERT_FILE"]),
+		TLSKeyFile:      strings.TrimSpace(raw["TLS_KEY_FILE"]),
+		TLSMinVersion:   p.oneOf("TLS_MIN_VERSION", "1.2", "1.3"),
+		TLSCipherSuites: p.cipherSuites("TLS_CIPHER_SUITES"),
+		TLSClientCAFile: strings.TrimSpace(raw["TLS_CLIENT_CA_FILE"]),
+		TLSClientAuth:   p.oneOf("TLS_CLIENT_AUTH", tlsconfig.ClientAuthRequire, tlsconfig.ClientAuthVerifyIfGiven),
+
+		RateLimitRPS:   p.int("RATE_LIMIT_RPS"),
+		RateLimitBurst: p.int("RATE_LIMIT_BURST"),
+
+		PrefetchLocations: p.locations("PREFETCH_LOCATIONS"),
+		PrefetchTopN:      p.int("PREFETCH_TOP_N"),
+		PrefetchLead:      p.duration("PREFETCH_LEAD"),
+
+		SubscriptionsFile:        p.required("SUBSCRIPTIONS_FILE", "path of the subscription store"),
+		SubscriptionPollInterval: p.duration("SUBSCRIPTION_POLL_INTERVAL"),
+		StreamPollInterval:       p.duration("STREAM_POLL_INTERVAL"),
+
+		HistoryFile:      p.required("HISTORY_FILE", "path of the forecast history database"),
+		HistoryRetention: p.duration("HISTORY_RETENTION"),
+
+		VerificationEnabled:  p.bool("VERIFICATION_ENABLED"),
+		VerificationInterval: p.duration("VERIFICATION_INTERVAL"),
+		VerificationLeadDays: p.leadDays("VERIFICATION_LEAD_DAYS"),
+
+		raw: raw,
+	}
+	if cfg.NWSRetryAttempts < 1 {
+		p.errorf("NWS_RETRY_ATTEMPTS", "must be at least 1, got %d", cfg.NWSRetryAttempts)
+	}
+	if cfg.NWSRetryMaxBackoff < cfg.NWSRetryBackoff {
+		p.errorf("NWS_RETRY_MAX_BACKOFF", "must not be shorter than NWS_RETRY_BACKOFF (%s < %s)",
+			cfg.NWSRetryMaxBackoff, cfg.NWSRetryBackoff)
+	}
+	if cfg.CompressionLevel < 0 || cfg.CompressionLevel > 9 {
+		p.errorf("COMPRESSION_LEVEL", "must be between 0 (off) and 9, got %d", cfg.CompressionLevel)
+	}
+	if cfg.CORSCredentials && slices.Contains(cfg.CORSOrigins, "*") {
+		p.errorf("CORS_ALLOW_CREDENTIALS", "cannot be combined with CORS_ALLOWED_ORIGINS=*; list the origins instead")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		p.errorf("TLS_CERT_FILE", "and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		p.errorf("TLS_CLIENT_CA_FILE", "requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if len(cfg.TLSCipherSuites) > 0 && cfg.TLSMinVersion == "1.3" {
+		p.errorf("TLS_CIPHER_SUITES", "has no effect with TLS_MIN_VERSION=1.3 (TLS 1.3 suites are not configurable)")
+	}
+	if cfg.RateLimitRPS < 0 {
+		p.errorf("RATE_LIMIT_RPS", "must not be negative, got %d", cfg.RateLimitRPS)
+	}
+	if cfg.RateLimitBurst < 1 {
+		p.errorf("RATE_LIMIT_BURST", "must be at least 1, got %d", cfg.RateLimitBurst)
+	}
+	if cfg.PrefetchTopN < 0 {
+		p.errorf("PREFETCH_TOP_N", "must not be negative, got %d", cfg.PrefetchTopN)
+	}
+	if cfg.PrefetchLead <= 0 {
+		p.errorf("PREFETCH_LEAD", "must be positive, got %s", cfg.PrefetchLead)
+	}
+	if cfg.HistoryRetention <= 0 {
+		p.errorf("HISTORY_RETENTION", "must be positive, got %s", cfg.HistoryRetention)
+	}
+	if cfg.VerificationEnabled && len(cfg.VerificationLeadDays) == 0 {
+		p.errorf("VERIFICATION_LEAD_DAYS", "must list at least one lead time while verification is enabled")
+	}
+	if cfg.VerificationEnabled && len(cfg.PrefetchLocations) == 0 && cfg.PrefetchTopN == 0 {
+		p.errorf("VERIFICATION_ENABLED", "verifies prefetched locations, but neither PREFETCH_LOCATIONS nor PREFETCH_TOP_N is set")
+	}
+	if cfg.ColdMax >= cfg.HotMin {
+		p.errorf("TEMP_BAND_COLD_MAX", "must be lower than TEMP_BAND_HOT_MIN (%d >= %d)", cfg.ColdMax, cfg.HotMin)
+	}
+	sortErrs(p.errs)
+	return cfg, errors.Join(p.errs...)
+}
+
+// parser converts raw string settings, collecting every failure instead of
+// stopping at the first.
+type parser struct {
+	raw  map[string]string
+	errs []error
+}
+
+func (p *parser) errorf(key, format string, args ...any) {
+	p.errs = append(p.errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+}
+
+func (p *parser) required(key, hint string) string {
+	v := strings.TrimSpace(p.raw[key])
+	if v == "" {
+		p.errorf(key, "is required (%s)", hint)
+	}
+	return v
+}
+
+func (p *parser) port(key string) string {
+	v := strings.TrimSpace(p.raw[key])
+	if n, err := strconv.Atoi(v); err != nil || n < 1 || n > 65535 {
+		p.errorf(key, "invalid port %q", v)
+	}
+	return v
+}
+
+func (p *parser) hostPort(key string) string {
+	v := strings.TrimSpace(p.raw[key])
+	_, port, err := net.SplitHostPort(v)
+	if n, perr := strconv.Atoi(port); err != nil || perr != nil || n < 1 || n > 65535 {
+		p.errorf(key, "invalid address %q (want host:port)", v)
+	}
+	return v
+}
+
+func (p *parser) duration(key string) time.Duration {
+	v := p.raw[key]
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		p.errorf(key, "invalid duration %q (want a positive value such as 5s or 10m)", v)
+	}
+	return d
+}
+
+// delay is like duration but also accepts zero.
+func (p *parser) delay(key string) time.Duration {
+	v := p.raw[key]
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		p.errorf(key, "invalid duration %q (want 0 or a positive value such as 5s)", v)
+	}
+	return d
+}
+
+func (p *parser) int(key string) int {
+	v := p.raw[key]
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		p.errorf(key, "invalid integer %q", v)
+	}
+	return i
+}
+
+func (p *parser) bool(key string) bool {
+	v := p.raw[key]
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		p.errorf(key, "invalid boolean %q (want true or false)", v)
+	}
+	return b
+}
+
+// list splits a comma-separated setting, dropping empty items.
+func (p *parser) list(key string) []string {
+	var out []string
+	for _, item := range strings.Split(p.raw[key], ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// origins parses a list of CORS origins: "*", scheme://host[:port], or a pattern
+// whose first host label is "*" to match any subdomain.
+func (p *parser) origins(key string) []string {
+	out := p.list(key)
+	for _, o := range out {
+		if o == "*" {
+			continue
+		}
+		u, err := url.Parse(strings.Replace(o, "://*.", "://wildcard.", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" ||
+			u.RawQuery != "" || strings.Contains(strings.TrimPrefix(u.Host, "wildcard."), "*") {
+			p.errorf(key, "invalid origin %q (want https://host[:port], https://*.domain or *)", o)
+		}
+	}
+	return out
+}
+
+// cipherSuites parses a list of TLS 1.2 cipher suite names.
+func (p *parser) cipherSuites(key string) []string {
+	out := p.list(key)
+	for _, name := range out {
+		if _, ok := tlsconfig.CipherSuite(name); !ok {
+			p.errorf(key, "unknown or insecure cipher suite %q", name)
+		}
+	}
+	return out
+}
+
+// locations parses semicolon-separated "lat,lon" pairs.
+func (p *parser) locations(key string) []prefetch.Location {
+	locs, err := prefetch.ParseLocations(p.raw[key])
+	if err != nil {
+		p.errorf(key, "%v", err)
+	}
+	return locs
+}
+
+// leadDays parses a list of forecast lead times in days, within the seven
+// days NWS forecasts cover.
+func (p *parser) leadDays(key string) []int {
+	var out []int
+	for _, item := range p.list(key) {
+		d, err := strconv.Atoi(item)
+		if err != nil || d < 0 || d > 6 {
+			p.errorf(key, "invalid lead time %q (want 0 to 6 days)", item)
+			continue
+		}
+		if !slices.Contains(out, d) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func (p *parser) url(key string) string {
+	v := strings.TrimSpace(p.raw[key])
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.errorf(key, "invalid URL %q", v)
+	}
+	return v
+}
+
+func (p *parser) oneOf(key string, allowed ...string) string {
+	v := strings.ToLower(strings.TrimSpace(p.raw[key]))
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	p.errorf(key, "invalid value %q (want one of %s)", p.raw[key], strings.Join(allowed, ", "))
+	return v
+}
+
+func (p *parser) level(key string) slog.Level {
+	v := p.raw[key]
+	switch strings.ToUpper(strings.TrimSpace(v)) {
 	case "DEBUG":
 		return slog.LevelDebug
+	case "INFO":
+		return slog.LevelInfo
 	case "WARN", "WARNING":
 		return slog.LevelWarn
 	case "ERROR":
 		return slog.LevelError
 	default:
+		p.errorf(key, "invalid log level %q (want DEBUG, INFO, WARN or ERROR)", v)
 		return slog.LevelInfo
 	}
 }
+
+// sortErrs orders errors by message so reports are stable across runs.
+func sortErrs(errs []error) {
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+}
@@ -0,0 +1,315 @@
+package config_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/config"
+	"weather-service/internal/nws"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "test (ops@example.com)")
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Port != "8080" || cfg.CacheTTL != config.CacheTTLDefault || cfg.LogLevel != slog.LevelInfo {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadFileUnderEnv(t *testing.T) {
+	path := writeFile(t, "weatherd.yaml", "cache_ttl: 2m\ntemp_band_hot_min: 90\nlog_level: debug\nnws_user_agent: file-agent\n")
+	t.Setenv("CACHE_TTL", "30s")
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CacheTTL != 30*time.Second {
+		t.Fatalf("CacheTTL=%v want env override 30s", cfg.CacheTTL)
+	}
+	if cfg.HotMin != 90 || cfg.LogLevel != slog.LevelDebug || cfg.NWSUserAgent != "file-agent" {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+}
+
+func TestLoadJSONFile(t *testing.T) {
+	path := writeFile(t, "weatherd.json", `{"port": 9090, "nws_user_agent": "json-agent"}`)
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Port != "9090" {
+		t.Fatalf("Port=%q want 9090", cfg.Port)
+	}
+}
+
+func TestLoadReportsEveryError(t *testing.T) {
+	t.Setenv("CACHE_TTL", "10mins")
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("TEMP_BAND_COLD_MAX", "90")
+	t.Setenv("PORT", "http")
+	t.Setenv("COMPRESSION_LEVEL", "11")
+
+	_, err := config.Load("")
+	if err == nil {
+		t.Fatalf("expected validation errors")
+	}
+	msg := err.Error()
+	for _, want := range []string{"CACHE_TTL", "LOG_LEVEL", "TEMP_BAND_COLD_MAX", "PORT", "COMPRESSION_LEVEL", "NWS_USER_AGENT"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("error does not mention %s:\n%s", want, msg)
+		}
+	}
+}
+
+func TestLoadCORS(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "test (ops@example.com)")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, https://*.example.org:8443")
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.CORSOrigins) != 2 || cfg.CORSOrigins[1] != "https://*.example.org:8443" || len(cfg.CORSMethods) == 0 {
+		t.Fatalf("unexpected CORS settings: %+v", cfg)
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*,app.example.com,https://a.*.example.com")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "yes")
+	_, err = config.Load("")
+	for _, want := range []string{`"app.example.com"`, `"https://a.*.example.com"`, `CORS_ALLOW_CREDENTIALS: invalid boolean`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("error does not mention %s: %v", want, err)
+		}
+	}
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	if _, err = config.Load(""); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Fatalf("credentials with any origin: %v", err)
+	}
+}
+
+func TestLoadTLS(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "test (ops@example.com)")
+	t.Setenv("TLS_CLIENT_CA_FILE", "/etc/weatherd/clients.pem")
+	t.Setenv("TLS_CIPHER_SUITES", "TLS_RSA_WITH_RC4_128_SHA")
+	t.Setenv("TLS_MIN_VERSION", "1.1")
+	_, err := config.Load("")
+	for _, want := range []string{"TLS_CLIENT_CA_FILE", "TLS_RSA_WITH_RC4_128_SHA", "TLS_MIN_VERSION"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("error does not mention %s: %v", want, err)
+		}
+	}
+
+	t.Setenv("TLS_CERT_FILE", "/etc/weatherd/tls.crt")
+	t.Setenv("TLS_KEY_FILE", "/etc/weatherd/tls.key")
+	t.Setenv("TLS_CIPHER_SUITES", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256")
+	t.Setenv("TLS_MIN_VERSION", "1.2")
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.TLSClientAuth != "require" || len(cfg.TLSCipherSuites) != 1 {
+		t.Fatalf("unexpected TLS settings: %+v", cfg)
+	}
+}
+
+func TestLoadNWSRetry(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "test (ops@example.com)")
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	def := nws.DefaultRetryPolicy()
+	if cfg.NWSRetryAttempts != def.MaxAttempts || cfg.NWSRetryBackoff != def.BaseBackoff ||
+		cfg.NWSRetryBudget != def.Budget || cfg.NWSRetryMaxRetryAfter != def.MaxRetryAfter {
+		t.Fatalf("retry defaults differ from nws.DefaultRetryPolicy: %+v", cfg)
+	}
+
+	t.Setenv("NWS_RETRY_ATTEMPTS", "0")
+	t.Setenv("NWS_RETRY_BACKOFF", "5s")
+	t.Setenv("NWS_RETRY_MAX_BACKOFF", "1s")
+	_, err = config.Load("")
+	for _, want := range []string{"NWS_RETRY_ATTEMPTS", "NWS_RETRY_MAX_BACKOFF"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("error does not mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestLoadRateLimit(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "test (ops@example.com)")
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RateLimitRPS != 0 || cfg.RateLimitBurst != config.RateLimitBurstDefault {
+		t.Fatalf("unexpected rate limit settings: %d/%d", cfg.RateLimitRPS, cfg.RateLimitBurst)
+	}
+
+	t.Setenv("RATE_LIMIT_RPS", "-1")
+	t.Setenv("RATE_LIMIT_BURST", "0")
+	_, err = config.Load("")
+	for _, want := range []string{"RATE_LIMIT_RPS", "RATE_LIMIT_BURST"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("error does not mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestLoadPrefetch(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "test (ops@example.com)")
+	t.Setenv("PREFETCH_LOCATIONS", "39.7392,-104.9903; 47.6062,-122.3321")
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.PrefetchLocations) != 2 || cfg.PrefetchLocations[1].Lon != -122.3321 || cfg.PrefetchTopN != 10 {
+		t.Fatalf("unexpected prefetch settings: %+v", cfg)
+	}
+
+	t.Setenv("PREFETCH_LOCATIONS", "39.7392 -104.9903")
+	t.Setenv("PREFETCH_LEAD", "0s")
+	_, err = config.Load("")
+	for _, want := range []string{"PREFETCH_LOCATIONS", "PREFETCH_LEAD"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("error does not mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestLoadHistory(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "test (ops@example.com)")
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.HistoryFile != "history.db" || cfg.HistoryRetention != config.HistoryRetentionDefault {
+		t.Fatalf("unexpected history settings: %q %s", cfg.HistoryFile, cfg.HistoryRetention)
+	}
+
+	t.Setenv("HISTORY_FILE", " ")
+	t.Setenv("HISTORY_RETENTION", "-1h")
+	_, err = config.Load("")
+	for _, want := range []string{"HISTORY_FILE", "HISTORY_RETENTION"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("error does not mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestLoadShutdownDrain(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "test (ops@example.com)")
+	cfg, err := config.Load("")
+	if err != nil || cfg.ShutdownDrain != config.ShutdownDrainDefault {
+		t.Fatalf("default drain %s, err %v", cfg.ShutdownDrain, err)
+	}
+	t.Setenv("SHUTDOWN_DRAIN", "0s")
+	if cfg, err = config.Load(""); err != nil || cfg.ShutdownDrain != 0 {
+		t.Fatalf("zero drain %s, err %v", cfg.ShutdownDrain, err)
+	}
+	t.Setenv("SHUTDOWN_DRAIN", "-1s")
+	if _, err = config.Load(""); err == nil || !strings.Contains(err.Error(), "SHUTDOWN_DRAIN") {
+		t.Fatalf("negative drain accepted: %v", err)
+	}
+}
+
+func TestLoadVerification(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "test (ops@example.com)")
+	t.Setenv("PREFETCH_TOP_N", "0")
+	cfg, err := config.Load("")
+	if err != nil || cfg.VerificationEnabled {
+		t.Fatalf("verification should be off by default, even without prefetch targets: enabled=%v err=%v",
+			cfg.VerificationEnabled, err)
+	}
+
+	t.Setenv("PREFETCH_TOP_N", "10")
+	t.Setenv("VERIFICATION_ENABLED", "true")
+	t.Setenv("VERIFICATION_LEAD_DAYS", "1, 3,1")
+	if cfg, err = config.Load(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.VerificationLeadDays, []int{1, 3}) || cfg.VerificationInterval != time.Hour {
+		t.Fatalf("unexpected verification settings: %v %s", cfg.VerificationLeadDays, cfg.VerificationInterval)
+	}
+
+	t.Setenv("VERIFICATION_LEAD_DAYS", "1,9")
+	if _, err = config.Load(""); err == nil || !strings.Contains(err.Error(), "VERIFICATION_LEAD_DAYS") {
+		t.Fatalf("expected lead time error, got %v", err)
+	}
+	t.Setenv("VERIFICATION_LEAD_DAYS", ",")
+	if _, err = config.Load(""); err == nil || !strings.Contains(err.Error(), "at least one lead time") {
+		t.Fatalf("expected missing lead time error, got %v", err)
+	}
+	t.Setenv("VERIFICATION_LEAD_DAYS", "1")
+	t.Setenv("PREFETCH_TOP_N", "0")
+	if _, err = config.Load(""); err == nil || !strings.Contains(err.Error(), "VERIFICATION_ENABLED: verifies prefetched locations") {
+		t.Fatalf("expected missing targets error, got %v", err)
+	}
+	t.Setenv("PREFETCH_LOCATIONS", "39.7392,-104.9903")
+	if _, err = config.Load(""); err != nil {
+		t.Fatalf("fixed locations: %v", err)
+	}
+	t.Setenv("PREFETCH_LOCATIONS", "")
+	t.Setenv("VERIFICATION_ENABLED", "false")
+	if cfg, err = config.Load(""); err != nil || cfg.VerificationEnabled {
+		t.Fatalf("disabled verification: enabled=%v err=%v", cfg.VerificationEnabled, err)
+	}
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	path := writeFile(t, "weatherd.yaml", "nws_user_agent: a\ncache_tll: 5m\n")
+	_, err := config.Load(path)
+	if err == nil || !strings.Contains(err.Error(), "cache_tll") {
+		t.Fatalf("expected unknown setting error, got %v", err)
+	}
+}
+
+func TestRedactedAndRestartRequired(t *testing.T) {
+	t.Setenv("NWS_USER_AGENT", "secret (me@example.com)")
+	cur, err := config.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cur.Redacted()["NWS_USER_AGENT"]; strings.Contains(got, "example.com") {
+		t.Fatalf("user agent not redacted: %q", got)
+	}
+
+	t.Setenv("PORT", "9999")
+	t.Setenv("CACHE_TTL", "1m")
+	next, err := config.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changed := cur.RestartRequired(next)
+	if len(changed) != 1 || changed[0] != "PORT" {
+		t.Fatalf("RestartRequired=%v want [PORT]", changed)
+	}
+
+	// Once reloaded, only the settings applied at runtime change.
+	running, err := cur.Reloaded(next)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if running.Port != cur.Port || running.Redacted()["PORT"] != cur.Redacted()["PORT"] || running.CacheTTL != time.Minute {
+		t.Fatalf("reloaded PORT=%s CACHE_TTL=%s", running.Port, running.CacheTTL)
+	}
+	if changed = cur.RestartRequired(running); len(changed) != 0 {
+		t.Fatalf("reloaded config still differs in %v", changed)
+	}
+}
@@ -0,0 +1,175 @@
+package forecast
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"weather-service/internal/nws"
+)
+
+// ChangeThresholdDefault is how many degrees a period's temperature must move
+// for Diff to report it.
+const ChangeThresholdDefault = 3
+
+// Change statuses of a PeriodChange.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeUpdated = "changed"
+)
+
+// Change is what one forecast update changed.
+type Change struct {
+	From    time.Time      `json:"from" xml:"from"` // updateTime of the replaced forecast
+	To      time.Time      `json:"to" xml:"to"`     // updateTime of the new forecast
+	Periods []PeriodChange `json:"periods" xml:"period"`
+
+	threshold int
+}
+
+// PeriodChange is a period an update changed, matched by start time. Before is
+// nil for added periods and After for removed ones.
+type PeriodChange struct {
+	Status                string         `json:"status" xml:"status"` // added|removed|changed
+	Start                 time.Time      `json:"start" xml:"start"`
+	Before                *PeriodSummary `json:"before,omitempty" xml:"before,omitempty"`
+	After                 *PeriodSummary `json:"after,omitempty" xml:"after,omitempty"`
+	TemperatureDelta      int            `json:"temperatureDelta,omitempty" xml:"temperatureDelta,omitempty"`
+	ClassificationChanged bool           `json:"classificationChanged,omitempty" xml:"classificationChanged,omitempty"`
+	ShortForecastChanged  bool           `json:"shortForecastChanged,omitempty" xml:"shortForecastChanged,omitempty"`
+}
+
+// ChangeSummary counts the periods the latest forecast update changed.
+type ChangeSummary struct {
+	Since          string `json:"since" xml:"since"`                   // updateTime of the replaced forecast (RFC 3339)
+	Temperature    int    `json:"temperature" xml:"temperature"`       // moved by more than the threshold
+	Classification int    `json:"classification" xml:"classification"` // classification flipped
+	ShortForecast  int    `json:"shortForecast" xml:"shortForecast"`   // short forecast text changed
+	Added          int    `json:"added" xml:"added"`
+	Removed        int    `json:"removed" xml:"removed"`
+}
+
+// Diff compares forecast cur with prev, the forecast it replaced. A period is
+// reported when its temperature moved by more than threshold degrees, its
+// classification under b flipped or its short forecast text changed, and when
+// it was added or removed. Periods that ended before cur was issued are not
+// reported as removed.
+func Diff(prev, cur nws.Forecast, b Bands, threshold int) Change {
+	c := Change{From: prev.Properties.Updated, To: cur.Properties.Updated, Periods: []PeriodChange{}, threshold: threshold}
+	before := make(map[time.Time]nws.Period, len(prev.Properties.Periods))
+	for _, p := range prev.Properties.Periods {
+		before[p.StartTime.UTC()] = p
+	}
+	for _, p := range cur.Properties.Periods {
+		after := summary(p, b)
+		old, ok := before[p.StartTime.UTC()]
+		if !ok {
+			c.Periods = append(c.Periods, PeriodChange{Status: ChangeAdded, Start: p.StartTime, After: &after})
+			continue
+		}
+		delete(before, p.StartTime.UTC())
+		was := summary(old, b)
+		pc := PeriodChange{
+			Status:                ChangeUpdated,
+			Start:                 p.StartTime,
+			Before:                &was,
+			After:                 &after,
+			TemperatureDelta:      p.Temperature - old.Temperature,
+			ClassificationChanged: after.Temperature.Type != was.Temperature.Type,
+			ShortForecastChanged:  p.ShortForecast != old.ShortForecast,
+		}
+		if abs(pc.TemperatureDelta) > threshold || pc.ClassificationChanged || pc.ShortForecastChanged {
+			c.Periods = append(c.Periods, pc)
+		}
+	}
+	for _, p := range prev.Properties.Periods {
+		if _, gone := before[p.StartTime.UTC()]; gone && p.EndTime.After(cur.Properties.Updated) {
+			was := summary(p, b)
+			c.Periods = append(c.Periods, PeriodChange{Status: ChangeRemoved, Start: p.StartTime, Before: &was})
+		}
+	}
+	return c
+}
+
+// Summary counts c's changed periods by kind.
+func (c Change) Summary() ChangeSummary {
+	s := ChangeSummary{Since: c.From.Format(time.RFC3339)}
+	for _, p := range c.Periods {
+		switch p.Status {
+		case ChangeAdded:
+			s.Added++
+		case ChangeRemoved:
+			s.Removed++
+		default:
+			if abs(p.TemperatureDelta) > c.threshold {
+				s.Temperature++
+			}
+			if p.ClassificationChanged {
+				s.Classification++
+			}
+			if p.ShortForecastChanged {
+				s.ShortForecast++
+			}
+		}
+	}
+	return s
+}
+
+// maxSummaries bounds how many grid cells the service remembers the change
+// summary of; an arbitrary one is forgotten to make room.
+const maxSummaries = 4096
+
+// summaries memoizes Meta.ChangedSinceLastUpdate per grid cell, so the archive
+// is read and the forecasts diffed once per update rather than on every
+// request.
+type summaries struct {
+	mu sync.Mutex
+	m  map[string]memoized // by forecast URL
+}
+
+// memoized is what the update of a grid cell issued at updated changed, diffed
+// with bands; sum is nil when the forecast it replaced is unknown.
+type memoized struct {
+	updated time.Time
+	bands   Bands
+	sum     *ChangeSummary
+}
+
+// get returns the summary of the update of forecastURL issued at updated, if
+// it was computed with bands.
+func (s *summaries) get(forecastURL string, updated time.Time, bands Bands) (*ChangeSummary, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.m[forecastURL]
+	if !ok || !e.updated.Equal(updated) || e.bands != bands {
+		return nil, false
+	}
+	return e.sum, true
+}
+
+// put remembers sum as the summary of the update of forecastURL issued at
+// updated, replacing that of the update before.
+func (s *summaries) put(forecastURL string, updated time.Time, bands Bands, sum *ChangeSummary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.m[forecastURL]; !ok && len(s.m) >= maxSummaries {
+		for k := range s.m {
+			delete(s.m, k)
+			break
+		}
+	}
+	s.m[forecastURL] = memoized{updated: updated, bands: bands, sum: sum}
+}
+
+// PreviousFunc returns the forecast document of the grid cell at forecastURL
+// that the update issued at updated replaced, like history.Store.Previous. Any
+// error, including there being none, leaves results without a change summary.
+type PreviousFunc func(ctx context.Context, forecastURL string, updated time.Time) (nws.Forecast, error)
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,164 @@
+package forecast_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"weather-service/internal/cache"
+	"weather-service/internal/forecast"
+	"weather-service/internal/history"
+	"weather-service/internal/nws"
+)
+
+func forecastDoc(t *testing.T, updated time.Time, periods string) nws.Forecast {
+	t.Helper()
+	var fc nws.Forecast
+	fc.Properties.Updated = updated
+	if err := json.Unmarshal([]byte(periods), &fc.Properties.Periods); err != nil {
+		t.Fatalf("periods: %v", err)
+	}
+	return fc
+}
+
+func TestDiff(t *testing.T) {
+	prev := forecastDoc(t, time.Date(2025, 8, 13, 10, 0, 0, 0, time.UTC), `[
+		{"name":"Morning","startTime":"2025-08-13T06:00:00Z","endTime":"2025-08-13T12:00:00Z","temperature":70,"shortForecast":"Sunny"},
+		{"name":"Afternoon","startTime":"2025-08-13T12:00:00Z","endTime":"2025-08-13T18:00:00Z","temperature":84,"shortForecast":"Sunny"},
+		{"name":"Tonight","startTime":"2025-08-13T18:00:00Z","endTime":"2025-08-14T06:00:00Z","temperature":60,"shortForecast":"Clear"},
+		{"name":"Thursday","startTime":"2025-08-14T06:00:00Z","endTime":"2025-08-14T18:00:00Z","temperature":80,"shortForecast":"Sunny"},
+		{"name":"Thursday Night","startTime":"2025-08-14T18:00:00Z","endTime":"2025-08-15T06:00:00Z","temperature":55,"shortForecast":"Clear"}]`)
+	cur := forecastDoc(t, time.Date(2025, 8, 13, 16, 0, 0, 0, time.UTC), `[
+		{"name":"Afternoon","startTime":"2025-08-13T12:00:00Z","endTime":"2025-08-13T18:00:00Z","temperature":86,"shortForecast":"Sunny"},
+		{"name":"Tonight","startTime":"2025-08-13T18:00:00Z","endTime":"2025-08-14T06:00:00Z","temperature":61,"shortForecast":"Clear"},
+		{"name":"Thursday","startTime":"2025-08-14T06:00:00Z","endTime":"2025-08-14T18:00:00Z","temperature":75,"shortForecast":"Chance Showers"},
+		{"name":"Friday","startTime":"2025-08-15T06:00:00Z","endTime":"2025-08-15T18:00:00Z","temperature":78,"shortForecast":"Sunny"}]`)
+
+	c := forecast.Diff(prev, cur, forecast.Bands{ColdMax: 45, HotMin: 85}, 3)
+	if !c.From.Equal(prev.Properties.Updated) || !c.To.Equal(cur.Properties.Updated) {
+		t.Fatalf("From, To = %v, %v", c.From, c.To)
+	}
+	want := []struct {
+		status, name string
+		delta        int
+		class, short bool
+	}{
+		{forecast.ChangeUpdated, "Afternoon", 2, true, false},
+		{forecast.ChangeUpdated, "Thursday", -5, false, true},
+		{forecast.ChangeAdded, "Friday", 0, false, false},
+		{forecast.ChangeRemoved, "Thursday Night", 0, false, false},
+	}
+	if len(c.Periods) != len(want) {
+		t.Fatalf("got %d changed periods, want %d: %+v", len(c.Periods), len(want), c.Periods)
+	}
+	for i, w := range want {
+		p := c.Periods[i]
+		s := p.After
+		if s == nil {
+			s = p.Before
+		}
+		if p.Status != w.status || s.Name != w.name || p.TemperatureDelta != w.delta ||
+			p.ClassificationChanged != w.class || p.ShortForecastChanged != w.short {
+			t.Fatalf("period %d = %s %s %+d class=%t short=%t, want %+v",
+				i, p.Status, s.Name, p.TemperatureDelta, p.ClassificationChanged, p.ShortForecastChanged, w)
+		}
+	}
+	if p := c.Periods[0]; p.Before.Temperature.Type != "moderate" || p.After.Temperature.Type != "hot" {
+		t.Fatalf("Afternoon classification %s -> %s", p.Before.Temperature.Type, p.After.Temperature.Type)
+	}
+
+	got := c.Summary()
+	exp := forecast.ChangeSummary{Since: "2025-08-13T10:00:00Z", Temperature: 1, Classification: 1, ShortForecast: 1, Added: 1, Removed: 1}
+	if got != exp {
+		t.Fatalf("Summary() = %+v, want %+v", got, exp)
+	}
+
+	if c := forecast.Diff(cur, cur, forecast.Bands{ColdMax: 45, HotMin: 85}, 3); len(c.Periods) != 0 {
+		t.Fatalf("identical forecasts differ: %+v", c.Periods)
+	}
+}
+
+func TestMetaChangedSinceLastUpdate(t *testing.T) {
+	periods := `[{"name":"Today","isDaytime":true,"startTime":"2025-08-13T06:00:00Z","endTime":"2025-08-13T18:00:00Z","temperature":%d}]`
+	fake := newStubNWS(t, 1, 2, "UTC", fmt.Sprintf(periods, 70))
+	archive, err := history.Open(filepath.Join(t.TempDir(), "history.db"), time.Hour)
+	if err != nil {
+		t.Fatalf("open history: %v", err)
+	}
+	defer archive.Close()
+	now := time.Date(2025, 8, 13, 9, 0, 0, 0, time.UTC)
+	svc := newArchivingService(fake, now, archive)
+	ctx := context.Background()
+
+	res, err := svc.GetTodaysForcast(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Meta == nil || res.Meta.ChangedSinceLastUpdate != nil {
+		t.Fatalf("first forecast Meta = %+v, want no changes", res.Meta)
+	}
+
+	fake.SetForecast(1, 2, forecastDoc(t, time.Date(2025, 8, 13, 21, 0, 0, 0, time.UTC), fmt.Sprintf(periods, 88)))
+	if _, err = svc.Refresh(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err = svc.GetTodaysForcast(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := forecast.ChangeSummary{Since: "2025-08-13T20:00:00Z", Temperature: 1, Classification: 1}
+	if got := res.Meta.ChangedSinceLastUpdate; got == nil || *got != want {
+		t.Fatalf("ChangedSinceLastUpdate = %+v, want %+v", got, want)
+	}
+
+	// Refetching the same update keeps the summary of what it changed.
+	if _, err = svc.Refresh(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res, err = svc.GetTodaysForcast(ctx, 1, 2); err != nil || res.Meta.ChangedSinceLastUpdate == nil {
+		t.Fatalf("after refetch: %+v, %v", res.Meta, err)
+	}
+
+	// The summary comes from the archive, so it survives a restart.
+	res, err = newArchivingService(fake, now, archive).GetTodaysForcast(ctx, 1, 2)
+	if err != nil || res.Meta.ChangedSinceLastUpdate == nil || *res.Meta.ChangedSinceLastUpdate != want {
+		t.Fatalf("after restart: %+v, %v", res.Meta, err)
+	}
+}
+
+func TestMetaChangedSinceLastUpdateIsMemoized(t *testing.T) {
+	periods := `[{"name":"Today","isDaytime":true,"startTime":"2025-08-13T06:00:00Z","endTime":"2025-08-13T18:00:00Z","temperature":%d}]`
+	fake := newStubNWS(t, 1, 2, "UTC", fmt.Sprintf(periods, 88))
+	calls := 0
+	previous := func(_ context.Context, _ string, updated time.Time) (nws.Forecast, error) {
+		calls++
+		return forecastDoc(t, updated.Add(-6*time.Hour), fmt.Sprintf(periods, 70)), nil
+	}
+	client := nws.NewClient(fake.URL, "test-agent", fake.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	bands := forecast.NewBandsVar(forecast.Bands{ColdMax: 45, HotMin: 85})
+	svc := forecast.NewService(forecast.NewRouter(forecast.NWS(client), nil, nil), cache.NewCache(time.Minute), bands, previous)
+	forecast.SetNow(svc, func() time.Time { return time.Date(2025, 8, 13, 9, 0, 0, 0, time.UTC) })
+	ctx := context.Background()
+
+	for range 3 {
+		res, err := svc.GetTodaysForcast(ctx, 1, 2)
+		if err != nil || res.Meta.ChangedSinceLastUpdate == nil || res.Meta.ChangedSinceLastUpdate.Classification != 1 {
+			t.Fatalf("Meta = %+v, err = %v", res.Meta, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("previous forecast looked up %d times for one update, want 1", calls)
+	}
+
+	// New bands can change the classification count, so the summary is redone.
+	bands.Store(forecast.Bands{ColdMax: 45, HotMin: 95})
+	res, err := svc.GetTodaysForcast(ctx, 1, 2)
+	if err != nil || calls != 2 || res.Meta.ChangedSinceLastUpdate.Classification != 0 {
+		t.Fatalf("after a band change: Meta = %+v, %d lookups, err = %v", res.Meta, calls, err)
+	}
+}
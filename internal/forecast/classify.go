@@ -1,5 +1,10 @@
 package forecast
 
+import (
+	"sync/atomic"
+	"time"
+)
+
 // Bands describe temperature thresholds in Fahrenheit for classification.
 // If temp <= ColdMax => "cold"; if temp >= HotMin => "hot"; otherwise "moderate".
 type Bands struct {
@@ -17,3 +22,41 @@ func Classify(temp int, b Bands) string {
 	}
 	return "moderate"
 }
+
+// BandsVar holds Bands that can be swapped at runtime (e.g. on config reload),
+// in the spirit of slog.LevelVar. It is safe for concurrent use.
+type BandsVar struct {
+	v atomic.Pointer[bandsSince]
+}
+
+type bandsSince struct {
+	bands   Bands
+	changed time.Time
+}
+
+// NewBandsVar returns a BandsVar initialised to b.
+func NewBandsVar(b Bands) *BandsVar {
+	v := &BandsVar{}
+	v.Store(b)
+	return v
+}
+
+// Load returns the current Bands.
+func (v *BandsVar) Load() Bands {
+	return v.v.Load().bands
+}
+
+// Changed returns when the current Bands were stored: when the BandsVar was
+// created or last given different Bands.
+func (v *BandsVar) Changed() time.Time {
+	return v.v.Load().changed
+}
+
+// Store replaces the current Bands. Storing the current Bands again keeps
+// their Changed time.
+func (v *BandsVar) Store(b Bands) {
+	if cur := v.v.Load(); cur != nil && cur.bands == b {
+		return
+	}
+	v.v.Store(&bandsSince{bands: b, changed: time.Now()})
+}
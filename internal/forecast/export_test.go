@@ -0,0 +1,19 @@
+package forecast
+
+import "time"
+
+// SetNow overrides the clock of a Service built with NewService.
+func SetNow(s Service, now func() time.Time) {
+	s.(*service).now = now
+}
+
+// SetBackgroundWait overrides how long background calls of a Service built with
+// NewService wait for live ones.
+func SetBackgroundWait(s Service, d time.Duration) {
+	s.(*service).upstream.maxWait = d
+}
+
+// SetBandsChanged backdates when v's current Bands were stored.
+func SetBandsChanged(v *BandsVar, t time.Time) {
+	v.v.Store(&bandsSince{bands: v.Load(), changed: t})
+}
@@ -0,0 +1,80 @@
+package forecast
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// backgroundMaxWait bounds how long a background call waits for live calls to
+// finish, so steady traffic delays prefetching but cannot starve it.
+const backgroundMaxWait = 2 * time.Second
+
+type backgroundKey struct{}
+
+// withBackground marks ctx as background work, whose upstream calls yield to
+// live ones.
+func withBackground(ctx context.Context) context.Context {
+	return context.WithValue(ctx, backgroundKey{}, true)
+}
+
+func isBackground(ctx context.Context) bool {
+	b, _ := ctx.Value(backgroundKey{}).(bool)
+	return b
+}
+
+// priority orders upstream calls. Live calls are never held back; background
+// calls wait until no live call is in flight, or for maxWait at most, so
+// prefetching mostly uses upstream capacity that requests leave idle.
+type priority struct {
+	maxWait time.Duration
+
+	mu   sync.Mutex
+	live int
+	idle chan struct{} // closed while live == 0
+}
+
+func newPriority(maxWait time.Duration) *priority {
+	idle := make(chan struct{})
+	close(idle)
+	return &priority{maxWait: maxWait, idle: idle}
+}
+
+// acquire admits an upstream call made with ctx and returns the function that
+// ends it. Background calls fail with ctx's error if it is done while waiting.
+func (p *priority) acquire(ctx context.Context) (func(), error) {
+	if isBackground(ctx) {
+		timeout := time.NewTimer(p.maxWait)
+		defer timeout.Stop()
+		for {
+			p.mu.Lock()
+			if p.live == 0 {
+				p.mu.Unlock()
+				return func() {}, nil
+			}
+			idle := p.idle
+			p.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-timeout.C:
+				return func() {}, nil
+			case <-idle:
+			}
+		}
+	}
+
+	p.mu.Lock()
+	if p.live == 0 {
+		p.idle = make(chan struct{})
+	}
+	p.live++
+	p.mu.Unlock()
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.live--; p.live == 0 {
+			close(p.idle)
+		}
+	}, nil
+}
@@ -0,0 +1,153 @@
+package forecast
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"weather-service/internal/nws"
+)
+
+// Provider is an upstream forecast source. Forecasts from every provider use the
+// NWS document shape: day and night periods with times in the location's zone.
+type Provider interface {
+	// Name identifies the provider in Result.Source.
+	Name() string
+	// Point resolves coordinates to the forecast serving them.
+	Point(ctx context.Context, lat, lon float64) (Point, error)
+	// Forecast fetches the forecast document at a Point's ForecastURL.
+	Forecast(ctx context.Context, forecastURL string) (nws.Forecast, error)
+	// Hourly fetches the hourly forecast document at a Point's HourlyURL, in the
+	// same shape. It is only called for points with a HourlyURL.
+	Hourly(ctx context.Context, hourlyURL string) (nws.Forecast, error)
+}
+
+// Point is a location resolved by a Provider. Coordinates sharing a ForecastURL
+// share one forecast document (an NWS grid cell, or an Open-Meteo grid point).
+type Point struct {
+	ForecastURL string
+	HourlyURL   string // empty when the provider has no hourly forecast
+	TimeZone    string
+	StationsURL string // NWS observation stations near the point; empty for other providers
+}
+
+// nwsProvider adapts an nws.Client to Provider.
+type nwsProvider struct {
+	*nws.Client
+}
+
+// NWS returns a Provider backed by the api.weather.gov client.
+func NWS(client *nws.Client) Provider {
+	return nwsProvider{client}
+}
+
+func (nwsProvider) Name() string { return source }
+
+func (p nwsProvider) Point(ctx context.Context, lat, lon float64) (Point, error) {
+	pts, err := p.Points(ctx, lat, lon)
+	if err != nil {
+		return Point{}, err
+	}
+	if pts.Properties.Forecast == "" {
+		return Point{}, errors.New("no forecast URL for point")
+	}
+	return Point{
+		ForecastURL: pts.Properties.Forecast,
+		HourlyURL:   pts.Properties.ForecastHourly,
+		TimeZone:    pts.Properties.TimeZone,
+		StationsURL: pts.Properties.Stations,
+	}, nil
+}
+
+// Hourly fetches the hourly forecast, which NWS serves in the forecast's shape.
+func (p nwsProvider) Hourly(ctx context.Context, hourlyURL string) (nws.Forecast, error) {
+	return p.Client.Forecast(ctx, hourlyURL)
+}
+
+// Circuit breaker settings for the primary provider.
+const (
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// Router chooses the providers to try for a location, in order: the primary
+// inside its coverage and the fallback after it, or only the fallback outside
+// the primary's coverage and while the primary's circuit is open. The circuit
+// opens after consecutive upstream failures of the primary and lets requests
+// through again after a cooldown.
+type Router struct {
+	primary, fallback Provider
+	covers            func(lat, lon float64) bool
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	now       func() time.Time
+}
+
+// NewRouter routes between primary and fallback. covers reports whether the
+// primary serves a location; nil means everywhere. With a nil fallback every
+// location goes to the primary.
+func NewRouter(primary, fallback Provider, covers func(lat, lon float64) bool) *Router {
+	return &Router{primary: primary, fallback: fallback, covers: covers, now: time.Now}
+}
+
+// Route returns the providers to try for lat, lon.
+func (r *Router) Route(lat, lon float64) []Provider {
+	if r.fallback == nil {
+		return []Provider{r.primary}
+	}
+	if (r.covers == nil || r.covers(lat, lon)) && r.closed() {
+		return []Provider{r.primary, r.fallback}
+	}
+	return []Provider{r.fallback}
+}
+
+// Providers returns every provider the router may use.
+func (r *Router) Providers() []Provider {
+	if r.fallback == nil {
+		return []Provider{r.primary}
+	}
+	return []Provider{r.primary, r.fallback}
+}
+
+// OpenUntil reports when the primary's open circuit lets calls through again,
+// and false while the circuit is closed.
+func (r *Router) OpenUntil() (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.now().Before(r.openUntil) {
+		return time.Time{}, false
+	}
+	return r.openUntil, true
+}
+
+func (r *Router) closed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.now().Before(r.openUntil)
+}
+
+// report records the outcome of a call to p for the circuit breaker. Client
+// errors, such as a point outside coverage, and canceled calls do not count.
+func (r *Router) report(ctx context.Context, p Provider, err error) {
+	if p != r.primary || r.fallback == nil {
+		return
+	}
+	var se *nws.StatusError
+	if err != nil && (ctx.Err() != nil || errors.As(err, &se) && se.Code < http.StatusInternalServerError) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		r.failures = 0
+		return
+	}
+	r.failures++
+	if r.failures >= breakerThreshold {
+		r.openUntil = r.now().Add(breakerCooldown)
+	}
+}
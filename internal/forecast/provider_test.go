@@ -0,0 +1,149 @@
+package forecast_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/cache"
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+	"weather-service/internal/nws/nwstest"
+)
+
+// stubProvider serves one fixed forecast for every location.
+type stubProvider struct {
+	name  string
+	calls int
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) Point(context.Context, float64, float64) (forecast.Point, error) {
+	return forecast.Point{ForecastURL: "stub://" + p.name, TimeZone: "UTC"}, nil
+}
+
+func (p *stubProvider) Forecast(context.Context, string) (nws.Forecast, error) {
+	p.calls++
+	var fc nws.Forecast
+	fc.Properties.Periods = []nws.Period{{
+		Name: "Today", IsDaytime: true, Temperature: 60, TemperatureUnit: "F", ShortForecast: "Fallback",
+		StartTime: time.Date(2025, 8, 13, 6, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 8, 13, 18, 0, 0, 0, time.UTC),
+	}}
+	return fc, nil
+}
+
+func (p *stubProvider) Hourly(context.Context, string) (nws.Forecast, error) {
+	return nws.Forecast{}, forecast.ErrNoHourly
+}
+
+func newRoutedService(t *testing.T) (forecast.Internal, *forecast.Router, *nwstest.Server, *stubProvider) {
+	t.Helper()
+	fake := nwstest.NewServer(t)
+	fake.SetNow(func() time.Time { return time.Date(2025, 8, 13, 15, 0, 0, 0, time.UTC) })
+	client := nws.NewClient(fake.URL, "test-agent", fake.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client.SetRetryPolicy(nws.RetryPolicy{}) // each injected failure is one breaker failure
+	fallback := &stubProvider{name: "fallback"}
+	router := forecast.NewRouter(forecast.NWS(client), fallback, nws.Covers)
+	// A short TTL keeps every call going upstream.
+	svc := forecast.NewService(router, cache.NewCache(time.Nanosecond), forecast.NewBandsVar(forecast.Bands{ColdMax: 45, HotMin: 85}), nil)
+	forecast.SetNow(svc, func() time.Time { return time.Date(2025, 8, 13, 15, 0, 0, 0, time.UTC) })
+	return svc, router, fake, fallback
+}
+
+func TestRouterByCoverage(t *testing.T) {
+	svc, _, fake, _ := newRoutedService(t)
+	ctx := context.Background()
+
+	res, err := svc.GetTodaysForcast(ctx, 39.7392, -104.9903)
+	if err != nil || res.Source != "api.weather.gov" {
+		t.Fatalf("Denver: source=%q err=%v", res.Source, err)
+	}
+	res, err = svc.GetTodaysForcast(ctx, 48.8566, 2.3522)
+	if err != nil || res.Source != "fallback" || res.Today.ShortForecast != "Fallback" {
+		t.Fatalf("Paris: res=%+v err=%v", res, err)
+	}
+	if n := fake.Requests("/points/48.8566"); n != 0 {
+		t.Fatalf("NWS asked about Paris %d times", n)
+	}
+	if _, err = svc.GetHourlyForecast(ctx, 48.8566, 2.3522, 0); !errors.Is(err, forecast.ErrNoHourly) {
+		t.Fatalf("Paris hourly: err=%v want ErrNoHourly", err)
+	}
+
+	// Inside the coverage box but unknown to NWS (southern Ontario).
+	fake.NotCovered(43.6532, -79.3832)
+	res, err = svc.GetTodaysForcast(ctx, 43.6532, -79.3832)
+	if err != nil || res.Source != "fallback" {
+		t.Fatalf("Toronto: source=%q err=%v", res.Source, err)
+	}
+}
+
+func TestRouterOpensCircuit(t *testing.T) {
+	svc, router, fake, fallback := newRoutedService(t)
+	ctx := context.Background()
+	fake.Inject("/points", nwstest.Status(500, 0))
+
+	for i := 0; i < 5; i++ {
+		if _, open := router.OpenUntil(); open {
+			t.Fatalf("circuit open after %d failures", i)
+		}
+		res, err := svc.GetTodaysForcast(ctx, 39.7392, -104.9903)
+		if err != nil || res.Source != "fallback" {
+			t.Fatalf("call %d: source=%q err=%v", i, res.Source, err)
+		}
+	}
+	before := fake.Requests("/points")
+	if _, err := svc.GetTodaysForcast(ctx, 39.7392, -104.9903); err != nil {
+		t.Fatalf("open circuit: %v", err)
+	}
+	if after := fake.Requests("/points"); after != before {
+		t.Fatalf("NWS called with open circuit (%d -> %d)", before, after)
+	}
+	if until, open := router.OpenUntil(); !open || until.IsZero() {
+		t.Fatalf("OpenUntil=%v,%v want open", until, open)
+	}
+	if fallback.calls != 6 {
+		t.Fatalf("fallback calls=%d want 6", fallback.calls)
+	}
+}
+
+func TestRouterWithoutFallbackReportsError(t *testing.T) {
+	fake := nwstest.NewServer(t)
+	fake.Inject("/points", nwstest.Status(500, 0))
+	client := nws.NewClient(fake.URL, "test-agent", fake.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client.SetRetryPolicy(nws.RetryPolicy{})
+	svc := forecast.NewService(forecast.NewRouter(forecast.NWS(client), nil, nil), cache.NewCache(time.Minute),
+		forecast.NewBandsVar(forecast.Bands{ColdMax: 45, HotMin: 85}), nil)
+
+	_, err := svc.GetTodaysForcast(context.Background(), 1, 2)
+	var se *nws.StatusError
+	if !errors.As(err, &se) || se.Code != 500 || strings.Contains(err.Error(), "api.weather.gov:") {
+		t.Fatalf("err=%v want bare NWS status error", err)
+	}
+}
+
+func TestStationsURLUsesCachedPoint(t *testing.T) {
+	fake := nwstest.NewServer(t)
+	client := nws.NewClient(fake.URL, "test-agent", fake.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	svc := forecast.NewService(forecast.NewRouter(forecast.NWS(client), &stubProvider{name: "fallback"}, nws.Covers),
+		cache.NewCache(time.Minute), forecast.NewBandsVar(forecast.Bands{ColdMax: 45, HotMin: 85}), nil)
+	ctx := context.Background()
+
+	if _, err := svc.GridCell(ctx, 39.7392, -104.9903); err != nil {
+		t.Fatal(err)
+	}
+	u, err := svc.StationsURL(ctx, 39.7392, -104.9903)
+	if err != nil || !strings.HasSuffix(u, "/stations") {
+		t.Fatalf("stations URL=%q err=%v", u, err)
+	}
+	if n := fake.Requests("/points"); n != 1 {
+		t.Fatalf("points fetched %d times, want 1", n)
+	}
+	if _, err = svc.StationsURL(ctx, 48.8566, 2.3522); err == nil {
+		t.Fatal("fallback point reported observation stations")
+	}
+}
@@ -18,104 +18,655 @@ const (
 type Service interface {
 	// GetTodaysForcast returns a summarized forecast result for the given coordinates.
 	GetTodaysForcast(ctx context.Context, lat, lon float64) (Result, error)
+	// GetDailyForecast returns the daytime and overnight periods for a local calendar date.
+	GetDailyForecast(ctx context.Context, lat, lon float64, date time.Time) (DailyResult, error)
+	// GetPeriods returns every forecast period that has not yet ended, with its times and detailed text.
+	GetPeriods(ctx context.Context, lat, lon float64) (PeriodsResult, error)
+	// GetHourlyForecast returns up to hours hourly periods that have not yet ended; zero means all.
+	GetHourlyForecast(ctx context.Context, lat, lon float64, hours int) (PeriodsResult, error)
+	// Purge evicts the cached point and forecast for the coordinates, returning how many entries were removed.
+	Purge(lat, lon float64) int
+	// Refresh purges the coordinates and fetches today's forecast again from upstream.
+	Refresh(ctx context.Context, lat, lon float64) (Result, error)
 }
 
+// Internal is the Service NewService returns, with the methods only the
+// process's own background work and cell-keyed features use. The API handlers
+// take a Service and never see them.
+type Internal interface {
+	Service
+	// GridCell returns a stable identifier of the NWS grid cell serving the coordinates;
+	// coordinates in the same cell share one forecast document.
+	GridCell(ctx context.Context, lat, lon float64) (string, error)
+	// StationsURL returns the URL listing the NWS observation stations near the
+	// coordinates, from the same cached point.
+	StationsURL(ctx context.Context, lat, lon float64) (string, error)
+	// Prefetch fetches the cached upstream documents of the coordinates that are
+	// missing or expire within lead, at lower priority than live requests, and
+	// returns when the first of them expires.
+	Prefetch(ctx context.Context, lat, lon float64, lead time.Duration) (time.Time, error)
+}
+
+// ErrDateOutOfRange is returned when a requested date has no periods in the forecast horizon.
+var ErrDateOutOfRange = errors.New("date is outside the forecast horizon")
+
+// ErrNoHourly is returned for locations whose providers have no hourly forecast.
+var ErrNoHourly = errors.New("no hourly forecast for this location")
+
 type service struct {
-	client *nws.Client
-	cache  *cache.Memory
-	bands  Bands
+	router   *Router
+	cache    *cache.Memory
+	bands    *BandsVar
+	upstream *priority
+	previous PreviousFunc
+	changes  summaries
+	now      func() time.Time
 }
 
-// NewService constructs a forecast Service using the given NWS client, cache, and bands.
-func NewService(client *nws.Client, cache *cache.Memory, bands Bands) Service {
-	return &service{client: client, cache: cache, bands: bands}
+// NewService constructs a forecast Service using the given provider router, cache, and bands.
+// Bands stored into the BandsVar later apply to subsequent requests. previous looks up the
+// forecast an update replaced, for Meta.ChangedSinceLastUpdate; nil leaves it out.
+func NewService(router *Router, cache *cache.Memory, bands *BandsVar, previous PreviousFunc) Internal {
+	return &service{router: router, cache: cache, bands: bands, upstream: newPriority(backgroundMaxWait), previous: previous,
+		changes: summaries{m: make(map[string]memoized)}, now: time.Now}
 }
 
 // Result is the API response payload returned by the forecast service for Today.
 type Result struct {
 	Coords struct {
-		Lat float64 `json:"lat"`
-		Lon float64 `json:"lon"`
-	} `json:"coords"`
-	Date  string `json:"date"`
-	Today struct {
-		Name          string `json:"name"`
-		ShortForecast string `json:"shortForecast"`
-		Temperature   struct {
-			Value int    `json:"value"`
-			Unit  string `json:"unit"`
-			Type  string `json:"type"` // hot|moderate|cold
-		} `json:"temperature"`
-	} `json:"today"`
-	Source string      `json:"source"`
-	Meta   interface{} `json:"meta,omitempty"`
-}
-
-// GetTodaysForcast resolves the NWS grid point for the given lat/lon, fetches (with caching)
-// the associated forecast, selects today's period relative to the current time, and
-// returns a summarized Result. It classifies the temperature using the configured
-// Bands (hot/moderate/cold) and includes the upstream document's update time in
-// Result.Meta["updated"].
+		Lat float64 `json:"lat" xml:"lat"`
+		Lon float64 `json:"lon" xml:"lon"`
+	} `json:"coords" xml:"coords"`
+	Date      string        `json:"date" xml:"date"`
+	TimeZone  string        `json:"timeZone" xml:"timeZone"`
+	LocalTime string        `json:"localTime" xml:"localTime"`
+	Today     PeriodSummary `json:"today" xml:"today"`
+	IsDaytime bool          `json:"isDaytime" xml:"isDaytime"` // false once Today is the evening's "Tonight"
+	Source    string        `json:"source" xml:"source"`
+	Meta      *Meta         `json:"meta,omitempty" xml:"meta,omitempty"`
+}
+
+// DailyResult is the API response payload for a single local calendar date.
+// Day or Night (and the matching High or Low) are omitted when NWS no longer, or
+// does not yet, list that half of the date.
+type DailyResult struct {
+	Coords struct {
+		Lat float64 `json:"lat" xml:"lat"`
+		Lon float64 `json:"lon" xml:"lon"`
+	} `json:"coords" xml:"coords"`
+	Date     string         `json:"date" xml:"date"`
+	TimeZone string         `json:"timeZone" xml:"timeZone"`
+	Day      *PeriodSummary `json:"day,omitempty" xml:"day,omitempty"`
+	Night    *PeriodSummary `json:"night,omitempty" xml:"night,omitempty"`
+	High     *Temperature   `json:"high,omitempty" xml:"high,omitempty"`
+	Low      *Temperature   `json:"low,omitempty" xml:"low,omitempty"`
+	Source   string         `json:"source" xml:"source"`
+	Meta     *Meta          `json:"meta,omitempty" xml:"meta,omitempty"`
+}
+
+// PeriodsResult is the API response payload for the whole forecast horizon.
+type PeriodsResult struct {
+	Coords struct {
+		Lat float64 `json:"lat" xml:"lat"`
+		Lon float64 `json:"lon" xml:"lon"`
+	} `json:"coords" xml:"coords"`
+	TimeZone string   `json:"timeZone" xml:"timeZone"`
+	Periods  []Period `json:"periods" xml:"period"`
+	Source   string   `json:"source" xml:"source"`
+	Meta     *Meta    `json:"meta,omitempty" xml:"meta,omitempty"`
+}
+
+// Period is a summarized forecast period with its local start and end times.
+type Period struct {
+	PeriodSummary
+	Start            time.Time `json:"start" xml:"start"`
+	End              time.Time `json:"end" xml:"end"`
+	IsDaytime        bool      `json:"isDaytime" xml:"isDaytime"`
+	DetailedForecast string    `json:"detailedForecast,omitempty" xml:"detailedForecast,omitempty"`
+}
+
+// Meta carries metadata about the upstream document a result was built from.
+type Meta struct {
+	Updated string `json:"updated" xml:"updated"` // upstream updateTime (RFC 3339)
+	// ChangedSinceLastUpdate summarizes what this update changed from the one
+	// it replaced; nil until a second update has been fetched.
+	ChangedSinceLastUpdate *ChangeSummary `json:"changedSinceLastUpdate,omitempty" xml:"changedSinceLastUpdate,omitempty"`
+	// Expires is when the cached upstream document will be fetched again; zero
+	// when it is not cached.
+	Expires time.Time `json:"-" xml:"-"`
+	// Modified is the newest input of the result: the upstream update time,
+	// the last change of the temperature bands and, for results chosen by the
+	// clock, the last period boundary or local midnight passed.
+	Modified time.Time `json:"-" xml:"-"`
+}
+
+// meta builds the Meta of a result from fc, the forecast p serves for lat/lon.
+func (s *service) meta(ctx context.Context, p Provider, lat, lon float64, fc nws.Forecast) *Meta {
+	m := &Meta{Updated: fc.Properties.Updated.Format(time.RFC3339)}
+	if v, _, ok := s.cache.Peek(pointsKey(p, lat, lon)); ok {
+		if pt, ok2 := v.(Point); ok2 {
+			if _, exp, ok3 := s.cache.Peek(forecastKey(pt.ForecastURL)); ok3 {
+				m.Expires = exp
+			}
+			m.ChangedSinceLastUpdate = s.changedSince(ctx, pt.ForecastURL, fc)
+		}
+	}
+	return m
+}
+
+// changedSince returns what fc, the latest forecast of the grid cell at
+// forecastURL, changed from the one it replaced, or nil when that is unknown.
+// It is computed once per update and band change.
+func (s *service) changedSince(ctx context.Context, forecastURL string, fc nws.Forecast) *ChangeSummary {
+	if s.previous == nil {
+		return nil
+	}
+	updated, bands := fc.Properties.Updated, s.bands.Load()
+	if sum, ok := s.changes.get(forecastURL, updated, bands); ok {
+		return sum
+	}
+	var sum *ChangeSummary
+	if prev, err := s.previous(ctx, forecastURL, updated); err == nil {
+		cs := Diff(prev, fc, bands, ChangeThresholdDefault).Summary()
+		sum = &cs
+	}
+	s.changes.put(forecastURL, updated, bands, sum)
+	return sum
+}
+
+// modified returns Meta.Modified for a result built from fc. A non-zero now,
+// in the location's zone, marks a result chosen by the clock: it can change at
+// local midnight and whenever a period starts or ends, so the last of those
+// before now counts as an input.
+func (s *service) modified(fc nws.Forecast, now time.Time) time.Time {
+	m := fc.Properties.Updated
+	if c := s.bands.Changed(); c.After(m) {
+		m = c
+	}
+	if now.IsZero() {
+		return m
+	}
+	y, mo, d := now.Date()
+	if midnight := time.Date(y, mo, d, 0, 0, 0, 0, now.Location()); midnight.After(m) {
+		m = midnight
+	}
+	for _, p := range fc.Properties.Periods {
+		for _, t := range []time.Time{p.StartTime, p.EndTime} {
+			if t.After(m) && !t.After(now) {
+				m = t
+			}
+		}
+	}
+	return m
+}
+
+// PeriodSummary is the summarized view of a single NWS forecast period.
+type PeriodSummary struct {
+	Name          string      `json:"name" xml:"name"`
+	ShortForecast string      `json:"shortForecast" xml:"shortForecast"`
+	Temperature   Temperature `json:"temperature" xml:"temperature"`
+}
+
+// Temperature is a temperature value with its classification.
+type Temperature struct {
+	Value int    `json:"value" xml:"value"`
+	Unit  string `json:"unit" xml:"unit"`
+	Type  string `json:"type" xml:"type"` // hot|moderate|cold
+}
+
+// GetTodaysForcast resolves the grid point for the given lat/lon, fetches (with caching)
+// the associated forecast, selects today's period relative to the current time in the
+// location's own time zone, and returns a summarized Result. It classifies the temperature
+// using the configured Bands (hot/moderate/cold) and includes the upstream document's
+// update time and cache expiry in Result.Meta. Result.Source names the provider that answered.
 //
 // Caching:
-//   - points: maps lat/lon -> forecast URL
+//   - points: maps provider and lat/lon -> forecast URL and IANA time zone
 //   - forecast: caches the full forecast document
 //
-// Errors are returned when the point has no forecast URL, when no usable forecast
-// periods are available for today, or when upstream calls fail.
+// Errors are returned when the point has no forecast URL, when the point's time zone
+// cannot be loaded, when no usable forecast periods are available for today, or when
+// every provider routed to fails.
 func (s *service) GetTodaysForcast(ctx context.Context, lat, lon float64) (Result, error) {
-	pointsKey := fmt.Sprintf("points:%.4f,%.4f", lat, lon)
-	var forecastURL string
-	if v, ok := s.cache.Get(pointsKey); ok {
-		forecastURL, _ = v.(string)
-	} else {
-		pts, err := s.client.Points(ctx, lat, lon)
-		if err != nil {
-			return Result{}, err
+	p, loc, fc, err := s.load(ctx, lat, lon)
+	if err != nil {
+		return Result{}, err
+	}
+
+	now := s.now().In(loc)
+	period, ok := nws.SelectToday(fc.Properties.Periods, now)
+	if !ok {
+		return Result{}, errors.New("no forecast periods available")
+	}
+
+	var res Result
+	res.Source = p.Name()
+	res.Coords.Lat, res.Coords.Lon = lat, lon
+	res.Date = period.StartTime.In(loc).Format("2006-01-02")
+	res.TimeZone = loc.String()
+	res.LocalTime = now.Format(time.RFC3339)
+	res.Today = s.summarize(period)
+	res.IsDaytime = period.IsDaytime
+
+	// Include some useful meta
+	res.Meta = s.meta(ctx, p, lat, lon, fc)
+	res.Meta.Modified = s.modified(fc, now)
+
+	return res, nil
+}
+
+// GetDailyForecast resolves the location like GetTodaysForcast and returns the daytime
+// and overnight periods for the calendar date of date, interpreted in the location's
+// time zone. The daytime temperature is reported as the high and the overnight
+// temperature as the low, each with its own classification.
+//
+// ErrDateOutOfRange is returned when the forecast has no periods on that date.
+func (s *service) GetDailyForecast(ctx context.Context, lat, lon float64, date time.Time) (DailyResult, error) {
+	p, loc, fc, err := s.load(ctx, lat, lon)
+	if err != nil {
+		return DailyResult{}, err
+	}
+
+	y, m, d := date.Date()
+	local := time.Date(y, m, d, 12, 0, 0, 0, loc)
+	dn, ok := nws.SelectDate(fc.Properties.Periods, local)
+	if !ok {
+		return DailyResult{}, fmt.Errorf("%w: %s", ErrDateOutOfRange, local.Format("2006-01-02"))
+	}
+
+	var res DailyResult
+	res.Source = p.Name()
+	res.Coords.Lat, res.Coords.Lon = lat, lon
+	res.Date = local.Format("2006-01-02")
+	res.TimeZone = loc.String()
+	if dn.Day != nil {
+		day := s.summarize(*dn.Day)
+		res.Day, res.High = &day, &day.Temperature
+	}
+	if dn.Night != nil {
+		night := s.summarize(*dn.Night)
+		res.Night, res.Low = &night, &night.Temperature
+	}
+	res.Meta = s.meta(ctx, p, lat, lon, fc)
+	res.Meta.Modified = s.modified(fc, time.Time{})
+
+	return res, nil
+}
+
+// GetPeriods resolves the location like GetTodaysForcast and returns the periods
+// that have not yet ended, in upstream order, with times in the location's zone.
+func (s *service) GetPeriods(ctx context.Context, lat, lon float64) (PeriodsResult, error) {
+	p, loc, fc, err := s.load(ctx, lat, lon)
+	if err != nil {
+		return PeriodsResult{}, err
+	}
+
+	var res PeriodsResult
+	now := s.now()
+	for _, np := range fc.Properties.Periods {
+		if !np.EndTime.After(now) {
+			continue
+		}
+		res.Periods = append(res.Periods, Period{
+			PeriodSummary:    s.summarize(np),
+			Start:            np.StartTime.In(loc),
+			End:              np.EndTime.In(loc),
+			IsDaytime:        np.IsDaytime,
+			DetailedForecast: np.DetailedForecast,
+		})
+	}
+	if len(res.Periods) == 0 {
+		return PeriodsResult{}, errors.New("no forecast periods available")
+	}
+	res.Source = p.Name()
+	res.Coords.Lat, res.Coords.Lon = lat, lon
+	res.TimeZone = loc.String()
+	res.Meta = s.meta(ctx, p, lat, lon, fc)
+	res.Meta.Modified = s.modified(fc, now.In(loc))
+
+	return res, nil
+}
+
+// GetHourlyForecast resolves the location like GetTodaysForcast, then fetches
+// (with caching) the provider's hourly forecast and returns its periods that have
+// not yet ended. Hourly periods are named by NWS with empty strings, so Name is
+// left empty too. Providers without an hourly forecast are skipped, and
+// ErrNoHourly is returned only when none of the routed providers has one.
+func (s *service) GetHourlyForecast(ctx context.Context, lat, lon float64, hours int) (PeriodsResult, error) {
+	var errs []error
+	for _, p := range s.router.Route(lat, lon) {
+		res, err := s.hourlyFrom(ctx, p, lat, lon, hours)
+		if err == nil {
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			return PeriodsResult{}, err
 		}
-		forecastURL = pts.Properties.Forecast
-		if forecastURL == "" {
-			return Result{}, errors.New("no forecast URL for point")
+		if !errors.Is(err, ErrNoHourly) {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
 		}
-		s.cache.Set(pointsKey, forecastURL)
 	}
+	switch len(errs) {
+	case 0:
+		return PeriodsResult{}, ErrNoHourly
+	case 1:
+		return PeriodsResult{}, errors.Unwrap(errs[0])
+	}
+	return PeriodsResult{}, errors.Join(errs...)
+}
 
-	fcKey := "forecast:" + forecastURL
-	var fc nws.Forecast
-	if v, ok := s.cache.Get(fcKey); ok {
-		if cached, ok2 := v.(nws.Forecast); ok2 {
-			fc = cached
+func (s *service) hourlyFrom(ctx context.Context, p Provider, lat, lon float64, hours int) (PeriodsResult, error) {
+	pt, err := s.point(ctx, p, lat, lon)
+	if err != nil {
+		return PeriodsResult{}, err
+	}
+	if pt.HourlyURL == "" {
+		return PeriodsResult{}, ErrNoHourly
+	}
+	fc, err := s.hourly(ctx, p, pt.HourlyURL)
+	if err != nil {
+		return PeriodsResult{}, err
+	}
+	var ref time.Time
+	if periods := fc.Properties.Periods; len(periods) > 0 {
+		ref = periods[0].StartTime
+	}
+	loc, err := nws.LoadLocation(pt.TimeZone, ref)
+	if err != nil {
+		return PeriodsResult{}, fmt.Errorf("load time zone %q: %w", pt.TimeZone, err)
+	}
+
+	var res PeriodsResult
+	now := s.now()
+	for _, np := range fc.Properties.Periods {
+		if !np.EndTime.After(now) {
+			continue
+		}
+		if hours > 0 && len(res.Periods) == hours {
+			break
 		}
+		res.Periods = append(res.Periods, Period{
+			PeriodSummary: s.summarize(np),
+			Start:         np.StartTime.In(loc),
+			End:           np.EndTime.In(loc),
+			IsDaytime:     np.IsDaytime,
+		})
+	}
+	if len(res.Periods) == 0 {
+		return PeriodsResult{}, errors.New("no hourly periods available")
+	}
+	res.Source = p.Name()
+	res.Coords.Lat, res.Coords.Lon = lat, lon
+	res.TimeZone = loc.String()
+	res.Meta = &Meta{Updated: fc.Properties.Updated.Format(time.RFC3339)}
+	if _, exp, ok := s.cache.Peek(forecastKey(pt.HourlyURL)); ok {
+		res.Meta.Expires = exp
 	}
-	if len(fc.Properties.Periods) == 0 {
+	return res, nil
+}
+
+// GridCell resolves (with caching) the point with the first provider that answers
+// and identifies its grid cell by the forecast URL, which NWS builds from the
+// office and grid coordinates.
+func (s *service) GridCell(ctx context.Context, lat, lon float64) (string, error) {
+	var errs []error
+	for _, p := range s.router.Route(lat, lon) {
+		pt, err := s.point(ctx, p, lat, lon)
+		if err == nil {
+			return pt.ForecastURL, nil
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return "", errors.Join(errs...)
+}
+
+// StationsURL resolves (with caching) the point with the first provider that
+// answers and has observation stations for it.
+func (s *service) StationsURL(ctx context.Context, lat, lon float64) (string, error) {
+	var errs []error
+	for _, p := range s.router.Route(lat, lon) {
+		pt, err := s.point(ctx, p, lat, lon)
+		if err == nil && pt.StationsURL != "" {
+			return pt.StationsURL, nil
+		}
+		if err == nil {
+			err = errors.New("no observation stations")
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return "", errors.Join(errs...)
+}
+
+// Purge evicts the cached points of every provider and, for each cached point,
+// the forecast and hourly documents it refers to. Other locations in the same grid cell share
+// that forecast document and will refetch it too.
+func (s *service) Purge(lat, lon float64) int {
+	n := 0
+	for _, p := range s.router.Providers() {
+		key := pointsKey(p, lat, lon)
+		if v, _, ok := s.cache.Peek(key); ok {
+			if pt, ok2 := v.(Point); ok2 {
+				if s.cache.Delete(forecastKey(pt.ForecastURL)) {
+					n++
+				}
+				if pt.HourlyURL != "" && s.cache.Delete(forecastKey(pt.HourlyURL)) {
+					n++
+				}
+			}
+		}
+		if s.cache.Delete(key) {
+			n++
+		}
+	}
+	return n
+}
+
+// Refresh purges the coordinates and rebuilds today's result from upstream data.
+func (s *service) Refresh(ctx context.Context, lat, lon float64) (Result, error) {
+	s.Purge(lat, lon)
+	return s.GetTodaysForcast(ctx, lat, lon)
+}
+
+// Prefetch refreshes the location's point and forecast with the first provider
+// routed to that answers. Entries are replaced in place rather than purged, so
+// live requests keep hitting the cache while the refresh is in flight. Upstream
+// calls wait while live ones are in flight, for up to backgroundMaxWait.
+func (s *service) Prefetch(ctx context.Context, lat, lon float64, lead time.Duration) (time.Time, error) {
+	ctx = withBackground(ctx)
+	var errs []error
+	for _, p := range s.router.Route(lat, lon) {
+		exp, err := s.prefetchFrom(ctx, p, lat, lon, s.now().Add(lead))
+		if err == nil {
+			return exp, nil
+		}
+		if ctx.Err() != nil {
+			return time.Time{}, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return time.Time{}, errors.Join(errs...)
+}
+
+// prefetchFrom fetches the point and forecast p serves for lat/lon unless they
+// are cached beyond stale, and returns the earlier of their expiries.
+func (s *service) prefetchFrom(ctx context.Context, p Provider, lat, lon float64, stale time.Time) (time.Time, error) {
+	key := pointsKey(p, lat, lon)
+	v, pointExp, ok := s.cache.Peek(key)
+	pt, ok2 := v.(Point)
+	if !ok || !ok2 || pointExp.Before(stale) {
 		var err error
-		fc, err = s.client.Forecast(ctx, forecastURL)
-		if err != nil {
-			return Result{}, err
+		if pt, err = s.fetchPoint(ctx, p, lat, lon); err != nil {
+			return time.Time{}, err
 		}
-		s.cache.Set(fcKey, fc)
+		_, pointExp, _ = s.cache.Peek(key)
 	}
 
-	now := time.Now()
-	period, ok := nws.SelectToday(fc.Properties.Periods, now)
-	if !ok {
-		return Result{}, errors.New("no forecast periods available")
+	fcKey := forecastKey(pt.ForecastURL)
+	v, fcExp, ok := s.cache.Peek(fcKey)
+	if fc, ok2 := v.(nws.Forecast); !ok || !ok2 || len(fc.Properties.Periods) == 0 || fcExp.Before(stale) {
+		if _, err := s.fetchForecast(ctx, p, pt.ForecastURL); err != nil {
+			return time.Time{}, err
+		}
+		_, fcExp, _ = s.cache.Peek(fcKey)
 	}
+	if pointExp.Before(fcExp) {
+		return pointExp, nil
+	}
+	return fcExp, nil
+}
 
-	var res Result
-	res.Source = source
-	res.Coords.Lat, res.Coords.Lon = lat, lon
-	res.Date = period.StartTime.Format("2006-01-02")
-	res.Today.Name = period.Name
-	res.Today.ShortForecast = period.ShortForecast
-	res.Today.Temperature.Value = period.Temperature
-	res.Today.Temperature.Unit = period.TemperatureUnit
-	res.Today.Temperature.Type = Classify(period.Temperature, s.bands)
+// summarize converts an NWS period into a PeriodSummary classified with the configured Bands.
+func (s *service) summarize(p nws.Period) PeriodSummary {
+	return summary(p, s.bands.Load())
+}
 
-	// Include some useful meta
-	res.Meta = map[string]any{
-		"updated": fc.Properties.Updated.Format(time.RFC3339),
+// summary summarizes p, classifying its temperature with b.
+func summary(p nws.Period, b Bands) PeriodSummary {
+	return PeriodSummary{
+		Name:          p.Name,
+		ShortForecast: p.ShortForecast,
+		Temperature: Temperature{
+			Value: p.Temperature,
+			Unit:  p.TemperatureUnit,
+			Type:  Classify(p.Temperature, b),
+		},
 	}
+}
 
-	return res, nil
+// load resolves the location and fetches its forecast with the providers routed
+// to, in order, falling back to the next one when a provider fails. It returns
+// the provider that answered with the location's zone and forecast.
+func (s *service) load(ctx context.Context, lat, lon float64) (Provider, *time.Location, nws.Forecast, error) {
+	var errs []error
+	for _, p := range s.router.Route(lat, lon) {
+		loc, fc, err := s.loadFrom(ctx, p, lat, lon)
+		if err == nil {
+			return p, loc, fc, nil
+		}
+		if ctx.Err() != nil {
+			return nil, nil, nws.Forecast{}, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	if len(errs) == 1 {
+		return nil, nil, nws.Forecast{}, errors.Unwrap(errs[0])
+	}
+	return nil, nil, nws.Forecast{}, errors.Join(errs...)
+}
+
+func (s *service) loadFrom(ctx context.Context, p Provider, lat, lon float64) (*time.Location, nws.Forecast, error) {
+	pt, err := s.point(ctx, p, lat, lon)
+	if err != nil {
+		return nil, nws.Forecast{}, err
+	}
+	fc, err := s.forecast(ctx, p, pt.ForecastURL)
+	if err != nil {
+		return nil, nws.Forecast{}, err
+	}
+	var ref time.Time
+	if periods := fc.Properties.Periods; len(periods) > 0 {
+		ref = periods[0].StartTime
+	}
+	loc, err := nws.LoadLocation(pt.TimeZone, ref)
+	if err != nil {
+		return nil, nws.Forecast{}, fmt.Errorf("load time zone %q: %w", pt.TimeZone, err)
+	}
+	return loc, fc, nil
+}
+
+// point resolves (with caching) the forecast URL and time zone for lat/lon with p.
+func (s *service) point(ctx context.Context, p Provider, lat, lon float64) (Point, error) {
+	key := pointsKey(p, lat, lon)
+	if v, ok := s.cache.Get(key); ok {
+		if cached, ok2 := v.(Point); ok2 {
+			return cached, nil
+		}
+	}
+	return s.fetchPoint(ctx, p, lat, lon)
+}
+
+// fetchPoint resolves the point for lat/lon with p and caches it.
+func (s *service) fetchPoint(ctx context.Context, p Provider, lat, lon float64) (Point, error) {
+	release, err := s.upstream.acquire(ctx)
+	if err != nil {
+		return Point{}, err
+	}
+	pt, err := p.Point(ctx, lat, lon)
+	release()
+	s.router.report(ctx, p, err)
+	if err != nil {
+		return Point{}, err
+	}
+	s.cache.Set(pointsKey(p, lat, lon), pt)
+	return pt, nil
+}
+
+// forecast fetches (with caching) the forecast document at forecastURL from p.
+func (s *service) forecast(ctx context.Context, p Provider, forecastURL string) (nws.Forecast, error) {
+	fcKey := forecastKey(forecastURL)
+	if v, ok := s.cache.Get(fcKey); ok {
+		if cached, ok2 := v.(nws.Forecast); ok2 && len(cached.Properties.Periods) > 0 {
+			return cached, nil
+		}
+	}
+	return s.fetchForecast(ctx, p, forecastURL)
+}
+
+// fetchForecast fetches the forecast document at forecastURL from p and caches it.
+func (s *service) fetchForecast(ctx context.Context, p Provider, forecastURL string) (nws.Forecast, error) {
+	release, err := s.upstream.acquire(ctx)
+	if err != nil {
+		return nws.Forecast{}, err
+	}
+	fc, err := p.Forecast(ctx, forecastURL)
+	release()
+	s.router.report(ctx, p, err)
+	if err != nil {
+		return nws.Forecast{}, err
+	}
+	s.cache.Set(forecastKey(forecastURL), fc)
+	return fc, nil
+}
+
+// hourly fetches (with caching) the hourly forecast document at hourlyURL from p.
+// Unlike forecast documents, hourly ones are neither archived nor diffed.
+func (s *service) hourly(ctx context.Context, p Provider, hourlyURL string) (nws.Forecast, error) {
+	key := forecastKey(hourlyURL)
+	if v, ok := s.cache.Get(key); ok {
+		if cached, ok2 := v.(nws.Forecast); ok2 && len(cached.Properties.Periods) > 0 {
+			return cached, nil
+		}
+	}
+	release, err := s.upstream.acquire(ctx)
+	if err != nil {
+		return nws.Forecast{}, err
+	}
+	fc, err := p.Hourly(ctx, hourlyURL)
+	release()
+	s.router.report(ctx, p, err)
+	if err != nil {
+		return nws.Forecast{}, err
+	}
+	s.cache.Set(key, fc)
+	return fc, nil
+}
+
+// pointsKey is the cache key of the point p resolved for lat/lon. NWS points keep
+// the unprefixed form.
+func pointsKey(p Provider, lat, lon float64) string {
+	if p.Name() == source {
+		return fmt.Sprintf("points:%.4f,%.4f", lat, lon)
+	}
+	return fmt.Sprintf("points:%s:%.4f,%.4f", p.Name(), lat, lon)
+}
+
+// forecastKey is the cache key of the forecast document at forecastURL.
+func forecastKey(forecastURL string) string {
+	return "forecast:" + forecastURL
 }
@@ -1,8 +1,20 @@
 package forecast_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"weather-service/internal/cache"
 	"weather-service/internal/forecast"
+	"weather-service/internal/history"
+	"weather-service/internal/nws"
+	"weather-service/internal/nws/nwstest"
 )
 
 func TestClassify(t *testing.T) {
@@ -24,3 +36,329 @@ func TestClassify(t *testing.T) {
 		}
 	}
 }
+
+// newStubNWS starts a fake NWS answering the given zone and periods at lat, lon.
+func newStubNWS(t *testing.T, lat, lon float64, tz, periods string) *nwstest.Server {
+	t.Helper()
+	fake := nwstest.NewServer(t)
+	fake.SetDefaultTimeZone(tz)
+	var fc nws.Forecast
+	fc.Properties.Updated = time.Date(2025, 8, 13, 20, 0, 0, 0, time.UTC)
+	if err := json.Unmarshal([]byte(periods), &fc.Properties.Periods); err != nil {
+		t.Fatalf("periods: %v", err)
+	}
+	fake.SetForecast(lat, lon, fc)
+	return fake
+}
+
+func newTestService(t *testing.T, fake *nwstest.Server, now time.Time) forecast.Internal {
+	t.Helper()
+	archive, err := history.Open(filepath.Join(t.TempDir(), "history.db"), time.Hour)
+	if err != nil {
+		t.Fatalf("open history: %v", err)
+	}
+	t.Cleanup(func() { _ = archive.Close() })
+	return newArchivingService(fake, now, archive)
+}
+
+// newArchivingService is newTestService archiving forecasts in archive.
+func newArchivingService(fake *nwstest.Server, now time.Time, archive *history.Store) forecast.Internal {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := nws.NewClient(fake.URL, "test-agent", fake.Client(), logger)
+	router := forecast.NewRouter(history.Archive(forecast.NWS(client), archive, logger), nil, nil)
+	svc := forecast.NewService(router, cache.NewCache(time.Minute), forecast.NewBandsVar(forecast.Bands{ColdMax: 45, HotMin: 85}), archive.Previous)
+	forecast.SetNow(svc, func() time.Time { return now })
+	return svc
+}
+
+func TestGetTodaysForcastUsesLocationTimeZone(t *testing.T) {
+	// 20:00Z on the 13th is already the morning of the 14th in Guam.
+	periods := `[
+		{"name":"Tonight","isDaytime":false,"startTime":"2025-08-13T18:00:00+10:00","endTime":"2025-08-14T06:00:00+10:00","temperature":78,"temperatureUnit":"F","shortForecast":"Showers"},
+		{"name":"Thursday","isDaytime":true,"startTime":"2025-08-14T06:00:00+10:00","endTime":"2025-08-14T18:00:00+10:00","temperature":88,"temperatureUnit":"F","shortForecast":"Sunny"}
+	]`
+	srv := newStubNWS(t, 13.4443, 144.7937, "Pacific/Guam", periods)
+	svc := newTestService(t, srv, time.Date(2025, 8, 13, 20, 0, 0, 0, time.UTC))
+
+	res, err := svc.GetTodaysForcast(context.Background(), 13.4443, 144.7937)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Today.Name != "Thursday" || res.Date != "2025-08-14" {
+		t.Fatalf("got %q on %s, want Thursday on 2025-08-14", res.Today.Name, res.Date)
+	}
+	if res.TimeZone != "Pacific/Guam" {
+		t.Fatalf("timeZone=%q want Pacific/Guam", res.TimeZone)
+	}
+	if res.LocalTime != "2025-08-14T06:00:00+10:00" {
+		t.Fatalf("localTime=%q want 2025-08-14T06:00:00+10:00", res.LocalTime)
+	}
+	if res.Today.Temperature.Type != "hot" {
+		t.Fatalf("type=%q want hot", res.Today.Temperature.Type)
+	}
+}
+
+func TestGetTodaysForcastBadTimeZone(t *testing.T) {
+	srv := newStubNWS(t, 1, 2, "Mars/Olympus_Mons", `[]`)
+	svc := newTestService(t, srv, time.Now())
+
+	if _, err := svc.GetTodaysForcast(context.Background(), 1, 2); err == nil {
+		t.Fatalf("expected error for unknown time zone")
+	}
+}
+
+func TestGetDailyForecast(t *testing.T) {
+	periods := `[
+		{"name":"This Afternoon","isDaytime":true,"startTime":"2025-08-13T14:00:00-06:00","endTime":"2025-08-13T18:00:00-06:00","temperature":91,"temperatureUnit":"F","shortForecast":"Sunny"},
+		{"name":"Tonight","isDaytime":false,"startTime":"2025-08-13T18:00:00-06:00","endTime":"2025-08-14T06:00:00-06:00","temperature":58,"temperatureUnit":"F","shortForecast":"Clear"},
+		{"name":"Thursday","isDaytime":true,"startTime":"2025-08-14T06:00:00-06:00","endTime":"2025-08-14T18:00:00-06:00","temperature":40,"temperatureUnit":"F","shortForecast":"Snow"}
+	]`
+	srv := newStubNWS(t, 39.7, -104.9, "America/Denver", periods)
+	svc := newTestService(t, srv, time.Date(2025, 8, 13, 21, 0, 0, 0, time.UTC))
+
+	res, err := svc.GetDailyForecast(context.Background(), 39.7, -104.9, time.Date(2025, 8, 13, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Day == nil || res.Day.Name != "This Afternoon" || res.Night == nil || res.Night.Name != "Tonight" {
+		t.Fatalf("unexpected periods: %+v", res)
+	}
+	if res.High == nil || res.High.Value != 91 || res.High.Type != "hot" {
+		t.Fatalf("unexpected high: %+v", res.High)
+	}
+	if res.Low == nil || res.Low.Value != 58 || res.Low.Type != "moderate" {
+		t.Fatalf("unexpected low: %+v", res.Low)
+	}
+
+	res, err = svc.GetDailyForecast(context.Background(), 39.7, -104.9, time.Date(2025, 8, 14, 0, 0, 0, 0, time.UTC))
+	if err != nil || res.Low != nil || res.High == nil || res.High.Type != "cold" {
+		t.Fatalf("14th: res=%+v err=%v", res, err)
+	}
+
+	_, err = svc.GetDailyForecast(context.Background(), 39.7, -104.9, time.Date(2025, 8, 30, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, forecast.ErrDateOutOfRange) {
+		t.Fatalf("err=%v want ErrDateOutOfRange", err)
+	}
+}
+
+func TestGetPeriodsSkipsEndedPeriods(t *testing.T) {
+	periods := `[
+		{"name":"This Afternoon","isDaytime":true,"startTime":"2025-08-13T14:00:00-06:00","endTime":"2025-08-13T18:00:00-06:00","temperature":91,"temperatureUnit":"F","shortForecast":"Sunny"},
+		{"name":"Tonight","isDaytime":false,"startTime":"2025-08-13T18:00:00-06:00","endTime":"2025-08-14T06:00:00-06:00","temperature":58,"temperatureUnit":"F","shortForecast":"Clear","detailedForecast":"Clear, with a low around 58."}
+	]`
+	srv := newStubNWS(t, 39.7, -104.9, "America/Denver", periods)
+	svc := newTestService(t, srv, time.Date(2025, 8, 14, 0, 0, 0, 0, time.UTC)) // 18:00 MDT
+
+	res, err := svc.GetPeriods(context.Background(), 39.7, -104.9)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Periods) != 1 || res.TimeZone != "America/Denver" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	p := res.Periods[0]
+	if p.Name != "Tonight" || p.IsDaytime || p.Temperature.Type != "moderate" || p.DetailedForecast != "Clear, with a low around 58." {
+		t.Fatalf("unexpected period: %+v", p)
+	}
+	if got := p.Start.Format(time.RFC3339); got != "2025-08-13T18:00:00-06:00" {
+		t.Fatalf("start=%s", got)
+	}
+	if left := time.Until(res.Meta.Expires); left <= 0 || left > time.Minute {
+		t.Fatalf("forecast expires in %s, want within the cache TTL", left)
+	}
+}
+
+func TestMetaModified(t *testing.T) {
+	periods := `[
+		{"name":"This Afternoon","isDaytime":true,"startTime":"2025-08-13T14:00:00-06:00","endTime":"2025-08-13T18:00:00-06:00","temperature":91,"temperatureUnit":"F","shortForecast":"Sunny"},
+		{"name":"Tonight","isDaytime":false,"startTime":"2025-08-13T18:00:00-06:00","endTime":"2025-08-14T06:00:00-06:00","temperature":58,"temperatureUnit":"F","shortForecast":"Clear"}
+	]`
+	fake := newStubNWS(t, 39.7, -104.9, "America/Denver", periods) // updated 14:00 MDT
+	bands := forecast.NewBandsVar(forecast.Bands{ColdMax: 45, HotMin: 85})
+	forecast.SetBandsChanged(bands, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC))
+	client := nws.NewClient(fake.URL, "test-agent", fake.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	svc := forecast.NewService(forecast.NewRouter(forecast.NWS(client), nil, nil), cache.NewCache(time.Minute), bands, nil)
+	forecast.SetNow(svc, func() time.Time { return time.Date(2025, 8, 14, 1, 0, 0, 0, time.UTC) }) // 19:00 MDT
+	ctx := context.Background()
+
+	// Today switched to "Tonight" when it started at 18:00, after the update.
+	today, err := svc.GetTodaysForcast(ctx, 39.7, -104.9)
+	if err != nil {
+		t.Fatalf("today: %v", err)
+	}
+	if want := time.Date(2025, 8, 14, 0, 0, 0, 0, time.UTC); !today.Meta.Modified.Equal(want) {
+		t.Fatalf("today modified=%v want %v (Tonight's start)", today.Meta.Modified, want)
+	}
+	// A date's periods do not depend on the clock.
+	daily, err := svc.GetDailyForecast(ctx, 39.7, -104.9, time.Date(2025, 8, 13, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("daily: %v", err)
+	}
+	if want := time.Date(2025, 8, 13, 20, 0, 0, 0, time.UTC); !daily.Meta.Modified.Equal(want) {
+		t.Fatalf("daily modified=%v want the update time %v", daily.Meta.Modified, want)
+	}
+
+	bands.Store(forecast.Bands{ColdMax: 45, HotMin: 85}) // unchanged
+	if daily, _ = svc.GetDailyForecast(ctx, 39.7, -104.9, time.Date(2025, 8, 13, 0, 0, 0, 0, time.UTC)); daily.Meta.Modified.Year() != 2025 {
+		t.Fatalf("storing the same bands moved modified to %v", daily.Meta.Modified)
+	}
+	before := time.Now()
+	bands.Store(forecast.Bands{ColdMax: 50, HotMin: 90})
+	if daily, _ = svc.GetDailyForecast(ctx, 39.7, -104.9, time.Date(2025, 8, 13, 0, 0, 0, 0, time.UTC)); daily.Meta.Modified.Before(before) {
+		t.Fatalf("modified=%v want the band change", daily.Meta.Modified)
+	}
+}
+
+func TestGetHourlyForecast(t *testing.T) {
+	now := time.Date(2025, 8, 13, 15, 30, 0, 0, time.UTC) // 09:30 MDT
+	fake := nwstest.NewServer(t)
+	fake.SetDefaultTimeZone("America/Denver")
+	fake.SetNow(func() time.Time { return now })
+	svc := newTestService(t, fake, now)
+	ctx := context.Background()
+
+	res, err := svc.GetHourlyForecast(ctx, 39.7, -104.9, 6)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Periods) != 6 || res.TimeZone != "America/Denver" || res.Source != "api.weather.gov" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if got := res.Periods[0].Start.Format(time.RFC3339); got != "2025-08-13T09:00:00-06:00" {
+		t.Fatalf("first hour starts %s, want the current hour", got)
+	}
+	if all, _ := svc.GetHourlyForecast(ctx, 39.7, -104.9, 0); len(all.Periods) <= 6 {
+		t.Fatalf("hours=0 returned %d periods", len(all.Periods))
+	}
+	if n := fake.Requests("/gridpoints/"); n != 1 {
+		t.Fatalf("hourly document fetched %d times, want once (cached)", n)
+	}
+	if n := svc.Purge(39.7, -104.9); n != 2 {
+		t.Fatalf("purged %d entries, want the point and the hourly document", n)
+	}
+}
+
+func TestPurgeAndRefresh(t *testing.T) {
+	srv := newStubNWS(t, 1, 2, "UTC",
+		`[{"name":"Today","isDaytime":true,"startTime":"2025-08-13T06:00:00Z","endTime":"2025-08-13T18:00:00Z","temperature":70}]`)
+	svc := newTestService(t, srv, time.Date(2025, 8, 13, 9, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	if _, err := svc.GetTodaysForcast(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := svc.Purge(1, 2); n != 2 {
+		t.Fatalf("Purge removed %d entries, want point and forecast", n)
+	}
+	if n := svc.Purge(1, 2); n != 0 {
+		t.Fatalf("second Purge removed %d entries", n)
+	}
+	if _, err := svc.Refresh(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Refresh(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := srv.Requests("/gridpoints"); n != 3 {
+		t.Fatalf("forecast fetched %d times, want 3", n)
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	srv := newStubNWS(t, 1, 2, "UTC",
+		`[{"name":"Today","isDaytime":true,"startTime":"2025-08-13T06:00:00Z","endTime":"2025-08-13T18:00:00Z","temperature":70}]`)
+	svc := newTestService(t, srv, time.Date(2025, 8, 13, 9, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	exp, err := svc.Prefetch(ctx, 1, 2, 10*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if left := time.Until(exp); left <= 50*time.Second || left > time.Minute {
+		t.Fatalf("expires in %s, want about the cache TTL", left)
+	}
+	if _, err = svc.GetTodaysForcast(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = svc.Prefetch(ctx, 1, 2, 10*time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p, f := srv.Requests("/points"), srv.Requests("/gridpoints"); p != 1 || f != 1 {
+		t.Fatalf("points=%d forecasts=%d, want the prefetched copies served", p, f)
+	}
+
+	// Entries expiring within the lead are fetched again without being purged.
+	// Staleness is judged by the service's clock, which the cache shares here.
+	forecast.SetNow(svc, time.Now)
+	if _, err = svc.Prefetch(ctx, 1, 2, 2*time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p, f := srv.Requests("/points"), srv.Requests("/gridpoints"); p != 2 || f != 2 {
+		t.Fatalf("points=%d forecasts=%d after refresh", p, f)
+	}
+	forecast.SetNow(svc, func() time.Time { return time.Now().Add(2 * time.Minute) })
+	if _, err = svc.Prefetch(ctx, 1, 2, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p, f := srv.Requests("/points"), srv.Requests("/gridpoints"); p != 3 || f != 3 {
+		t.Fatalf("points=%d forecasts=%d with the clock past the expiry", p, f)
+	}
+}
+
+func TestPrefetchYieldsToLiveRequests(t *testing.T) {
+	srv := nwstest.NewServer(t)
+	svc := newTestService(t, srv, time.Now())
+	srv.Inject("/gridpoints", nwstest.Slow(time.Second, 1))
+
+	live := make(chan error, 1)
+	go func() {
+		_, err := svc.GetTodaysForcast(context.Background(), 39.7392, -104.9903)
+		live <- err
+	}()
+	for srv.Requests("/gridpoints") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := svc.Prefetch(ctx, 47.6062, -122.3321, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err=%v, want the prefetch held back", err)
+	}
+	if n := srv.Requests("/points"); n != 1 {
+		t.Fatalf("points=%d, want only the live request upstream", n)
+	}
+	if err := <-live; err != nil {
+		t.Fatalf("live request: %v", err)
+	}
+	if _, err := svc.Prefetch(context.Background(), 47.6062, -122.3321, time.Minute); err != nil {
+		t.Fatalf("prefetch once idle: %v", err)
+	}
+}
+
+func TestPrefetchWaitIsBounded(t *testing.T) {
+	srv := nwstest.NewServer(t)
+	svc := newTestService(t, srv, time.Now())
+	forecast.SetBackgroundWait(svc, 20*time.Millisecond)
+	srv.Inject("/gridpoints", nwstest.Slow(time.Second, 1))
+
+	live := make(chan error, 1)
+	go func() {
+		_, err := svc.GetTodaysForcast(context.Background(), 39.7392, -104.9903)
+		live <- err
+	}()
+	for srv.Requests("/gridpoints") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := svc.Prefetch(context.Background(), 47.6062, -122.3321, time.Minute); err != nil {
+		t.Fatalf("prefetch during a live request: %v", err)
+	}
+	select {
+	case err := <-live:
+		t.Fatalf("prefetch waited for the live request to finish (err=%v)", err)
+	default:
+	}
+	if err := <-live; err != nil {
+		t.Fatalf("live request: %v", err)
+	}
+}
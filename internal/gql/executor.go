@@ -0,0 +1,70 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+)
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// AlertsFunc returns the active alerts for a location; nil or empty when there
+// are none.
+type AlertsFunc func(ctx context.Context, lat, lon float64) ([]nws.Alert, error)
+
+// ObservationFunc returns the latest observation near a location, or nil when
+// no station reports for it.
+type ObservationFunc func(ctx context.Context, lat, lon float64) (*nws.Observation, error)
+
+// Executor runs GraphQL requests against a forecast.Service.
+type Executor struct {
+	schema graphql.Schema
+	svc    forecast.Service
+	alerts AlertsFunc
+	obs    ObservationFunc
+}
+
+// NewExecutor builds the schema and returns an Executor backed by svc. Alerts
+// and observations come from alerts and obs; when either is nil the matching
+// field resolves to an empty list or null.
+func NewExecutor(svc forecast.Service, alerts AlertsFunc, obs ObservationFunc) (*Executor, error) {
+	schema, err := newSchema()
+	if err != nil {
+		return nil, err
+	}
+	return &Executor{schema: schema, svc: svc, alerts: alerts, obs: obs}, nil
+}
+
+// Do parses, validates and limit-checks req, then executes it with fresh
+// dataloaders so that every forecast, alert list and observation is fetched
+// at most once per request.
+// Errors are reported in the result, never returned.
+func (e *Executor) Do(ctx context.Context, req Request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if v := graphql.ValidateDocument(&e.schema, doc, nil); !v.IsValid {
+		return &graphql.Result{Errors: v.Errors}
+	}
+	if err = checkLimits(doc, req.OperationName, req.Variables); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, newLoaders(ctx, e.svc, e.alerts, e.obs)),
+	})
+}
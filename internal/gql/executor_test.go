@@ -0,0 +1,241 @@
+package gql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/gql"
+	"weather-service/internal/nws"
+)
+
+// countingSvc serves today as 2025-06-01 and daily results for three dates,
+// counting calls per location and date.
+type countingSvc struct {
+	mu    sync.Mutex
+	today map[string]int
+	daily map[string]int
+}
+
+func newCountingSvc() *countingSvc {
+	return &countingSvc{today: map[string]int{}, daily: map[string]int{}}
+}
+
+func (s *countingSvc) GetTodaysForcast(_ context.Context, lat, lon float64) (forecast.Result, error) {
+	s.mu.Lock()
+	s.today[fmt.Sprintf("%g,%g", lat, lon)]++
+	s.mu.Unlock()
+	var res forecast.Result
+	res.Coords.Lat, res.Coords.Lon = lat, lon
+	res.TimeZone = "Pacific/Honolulu"
+	res.LocalTime = "2025-06-01T09:00:00-10:00"
+	res.Today = forecast.PeriodSummary{Name: "Today", ShortForecast: "Sunny", Temperature: forecast.Temperature{Value: 90, Unit: "F", Type: "hot"}}
+	return res, nil
+}
+
+func (s *countingSvc) GetDailyForecast(_ context.Context, lat, lon float64, date time.Time) (forecast.DailyResult, error) {
+	d := date.Format("2006-01-02")
+	s.mu.Lock()
+	s.daily[fmt.Sprintf("%g,%g/%s", lat, lon, d)]++
+	s.mu.Unlock()
+	if d > "2025-06-03" {
+		return forecast.DailyResult{}, fmt.Errorf("%w: %s", forecast.ErrDateOutOfRange, d)
+	}
+	high := forecast.Temperature{Value: 80, Unit: "F", Type: "moderate"}
+	return forecast.DailyResult{Date: d, Day: &forecast.PeriodSummary{Name: "Day", Temperature: high}, High: &high}, nil
+}
+
+func (s *countingSvc) GetPeriods(context.Context, float64, float64) (forecast.PeriodsResult, error) {
+	return forecast.PeriodsResult{}, errors.New("not implemented")
+}
+
+func (s *countingSvc) GetHourlyForecast(context.Context, float64, float64, int) (forecast.PeriodsResult, error) {
+	return forecast.PeriodsResult{}, errors.New("not implemented")
+}
+
+func (s *countingSvc) Purge(float64, float64) int { return 0 }
+
+func (s *countingSvc) Refresh(ctx context.Context, lat, lon float64) (forecast.Result, error) {
+	return s.GetTodaysForcast(ctx, lat, lon)
+}
+
+func do(t *testing.T, svc forecast.Service, query string, vars map[string]any) (map[string]any, []string) {
+	t.Helper()
+	exec, err := gql.NewExecutor(svc, nil, nil)
+	if err != nil {
+		t.Fatalf("NewExecutor: %v", err)
+	}
+	return run(exec, query, vars)
+}
+
+func run(exec *gql.Executor, query string, vars map[string]any) (map[string]any, []string) {
+	res := exec.Do(context.Background(), gql.Request{Query: query, Variables: vars})
+	var msgs []string
+	for _, e := range res.Errors {
+		msgs = append(msgs, e.Message)
+	}
+	b, _ := json.Marshal(res.Data)
+	var data map[string]any
+	_ = json.Unmarshal(b, &data)
+	return data, msgs
+}
+
+func TestNestedQueryLoadsEachForecastOnce(t *testing.T) {
+	svc := newCountingSvc()
+	data, errs := do(t, svc, `{
+		a: location(lat: 21.3, lon: -157.8) { timeZone today { name temperature { classification } } days { date } }
+		b: location(lat: 21.3, lon: -157.8) { localTime day(date: "2025-06-02") { high { value } } }
+		locations(coords: [{lat: 21.3, lon: -157.8}, {lat: 19.7, lon: -155.1}]) { today { shortForecast } }
+	}`, nil)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+
+	if svc.today["21.3,-157.8"] != 1 || svc.today["19.7,-155.1"] != 1 {
+		t.Fatalf("today calls=%v want one per location", svc.today)
+	}
+	for k, n := range svc.daily {
+		if n != 1 {
+			t.Fatalf("daily %s called %d times", k, n)
+		}
+	}
+
+	a, _ := data["a"].(map[string]any)
+	if got := a["today"].(map[string]any)["temperature"].(map[string]any)["classification"]; got != "HOT" {
+		t.Fatalf("classification=%v want HOT", got)
+	}
+	if days, _ := a["days"].([]any); len(days) != 3 {
+		t.Fatalf("days=%v want 3 (end of horizon)", a["days"])
+	}
+	b, _ := data["b"].(map[string]any)
+	if got := b["day"].(map[string]any)["high"].(map[string]any)["value"]; got != float64(80) {
+		t.Fatalf("high=%v want 80", got)
+	}
+}
+
+func TestDayOutsideHorizonIsNull(t *testing.T) {
+	data, errs := do(t, newCountingSvc(), `{ location(lat: 1, lon: 1) { day(date: "2025-07-01") { date } } }`, nil)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if loc, _ := data["location"].(map[string]any); loc["day"] != nil {
+		t.Fatalf("day=%v want null", loc["day"])
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+		want  string
+	}{
+		{
+			name:  "depth",
+			query: `{ location(lat: 1, lon: 1) { ...L } } fragment L on Location { days { day { temperature { value } } } }`,
+		},
+		{
+			name:  "too deep",
+			query: `{ location(lat: 1, lon: 1) { days { day { temperature { value } } } } __schema { types { fields { type { ofType { ofType { name } } } } } } }`,
+			want:  "query depth 7 exceeds",
+		},
+		{
+			name:  "complexity from variables",
+			query: `query($c: [Coordinates!]!) { locations(coords: $c) { days(count: 14) { day { name shortForecast temperature { value unit classification } } } } }`,
+			vars:  map[string]any{"c": make([]any, 20)},
+			want:  "query complexity",
+		},
+		{
+			name:  "invalid location",
+			query: `{ location(lat: 95, lon: 1) { lat } }`,
+			want:  "invalid lat",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := do(t, newCountingSvc(), tt.query, tt.vars)
+			got := strings.Join(errs, "; ")
+			if tt.want == "" && got != "" {
+				t.Fatalf("unexpected errors: %s", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("errors=%q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlertsAndObservation(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	count := func(kind string) {
+		mu.Lock()
+		calls[kind]++
+		mu.Unlock()
+	}
+	alerts := func(_ context.Context, lat, _ float64) ([]nws.Alert, error) {
+		count("alerts")
+		if lat != 21.3 {
+			return nil, nil
+		}
+		return []nws.Alert{{ID: "urn:1", Event: "High Surf Warning", Severity: "Moderate",
+			Effective: time.Date(2025, 6, 1, 6, 0, 0, 0, time.UTC)}}, nil
+	}
+	obs := func(_ context.Context, lat, _ float64) (*nws.Observation, error) {
+		count("observation")
+		switch lat {
+		case 21.3:
+			c := 30.0
+			return &nws.Observation{Station: "PHNL", TextDescription: "Clear",
+				Timestamp:   time.Date(2025, 6, 1, 18, 53, 0, 0, time.UTC),
+				Temperature: nws.Measurement{UnitCode: "wmoUnit:degC", Value: &c}}, nil
+		case 0:
+			return nil, errors.New("upstream down")
+		}
+		return nil, nil
+	}
+	exec, err := gql.NewExecutor(newCountingSvc(), alerts, obs)
+	if err != nil {
+		t.Fatalf("NewExecutor: %v", err)
+	}
+
+	data, errs := run(exec, `{
+		a: location(lat: 21.3, lon: -157.8) { alerts { id event effective ends } observation { station timestamp temperature } }
+		b: location(lat: 21.3, lon: -157.8) { alerts { severity } }
+		c: location(lat: 48.9, lon: 2.4) { alerts { id } observation { station } }
+	}`, nil)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if calls["alerts"] != 2 || calls["observation"] != 2 {
+		t.Fatalf("calls=%v want one per location", calls)
+	}
+	a, _ := data["a"].(map[string]any)
+	list, _ := a["alerts"].([]any)
+	if len(list) != 1 {
+		t.Fatalf("alerts=%v want 1", a["alerts"])
+	}
+	al := list[0].(map[string]any)
+	if al["event"] != "High Surf Warning" || al["effective"] != "2025-06-01T06:00:00Z" || al["ends"] != nil {
+		t.Fatalf("alert=%v", al)
+	}
+	o, _ := a["observation"].(map[string]any)
+	if o["station"] != "PHNL" || o["timestamp"] != "2025-06-01T18:53:00Z" || o["temperature"] != float64(86) {
+		t.Fatalf("observation=%v", o)
+	}
+	c, _ := data["c"].(map[string]any)
+	if l, _ := c["alerts"].([]any); len(l) != 0 || c["observation"] != nil {
+		t.Fatalf("outside coverage: %v", c)
+	}
+
+	_, errs = run(exec, `{ location(lat: 0, lon: 0) { observation { station } } }`, nil)
+	if len(errs) != 1 || !strings.Contains(errs[0], "upstream down") {
+		t.Fatalf("errors=%v want upstream failure", errs)
+	}
+}
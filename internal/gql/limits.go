@@ -0,0 +1,137 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Query limits. Depth counts nested fields (location { days { day { temperature
+// { value } } } } is 5). Complexity counts every selected field once, multiplied by
+// the number of locations or days its parent list yields.
+const (
+	MaxDepth      = 6
+	MaxComplexity = 1000
+	MaxDays       = 14
+)
+
+// limits walks the selected operation of a validated document.
+type limits struct {
+	fragments map[string]*ast.FragmentDefinition
+	vars      map[string]any
+	defaults  map[string]ast.Value
+}
+
+// checkLimits returns an error when the operation exceeds MaxDepth or MaxComplexity.
+// The document must have passed validation, which rules out fragment cycles.
+func checkLimits(doc *ast.Document, opName string, vars map[string]any) error {
+	l := limits{fragments: map[string]*ast.FragmentDefinition{}, vars: vars, defaults: map[string]ast.Value{}}
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch d := def.(type) {
+		case *ast.FragmentDefinition:
+			l.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if op == nil && (opName == "" || (d.Name != nil && d.Name.Value == opName)) {
+				op = d
+			}
+		}
+	}
+	if op == nil {
+		return nil // execution reports the missing operation
+	}
+	for _, vd := range op.VariableDefinitions {
+		if vd.DefaultValue != nil {
+			l.defaults[vd.Variable.Name.Value] = vd.DefaultValue
+		}
+	}
+
+	depth, cost := l.selectionSet(op.SelectionSet)
+	if depth > MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, MaxDepth)
+	}
+	if cost > MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", cost, MaxComplexity)
+	}
+	return nil
+}
+
+// selectionSet returns the maximum field depth and total cost of ss.
+func (l limits) selectionSet(ss *ast.SelectionSet) (int, int) {
+	if ss == nil {
+		return 0, 0
+	}
+	maxDepth, cost := 0, 0
+	for _, sel := range ss.Selections {
+		var d, c int
+		switch s := sel.(type) {
+		case *ast.Field:
+			d, c = l.selectionSet(s.SelectionSet)
+			d, c = d+1, 1+l.multiplier(s)*c
+		case *ast.InlineFragment:
+			d, c = l.selectionSet(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if f, ok := l.fragments[s.Name.Value]; ok {
+				d, c = l.selectionSet(f.SelectionSet)
+			}
+		}
+		maxDepth = max(maxDepth, d)
+		cost += c
+	}
+	return maxDepth, cost
+}
+
+// multiplier is how many times the children of field f are resolved.
+func (l limits) multiplier(f *ast.Field) int {
+	switch f.Name.Value {
+	case "locations":
+		if list, ok := l.value(argument(f, "coords")).([]any); ok {
+			return max(len(list), 1)
+		}
+	case "days":
+		n := defaultDays
+		switch v := l.value(argument(f, "count")).(type) {
+		case int:
+			n = v
+		case float64: // JSON variables
+			n = int(v)
+		}
+		return min(max(n, 1), MaxDays)
+	}
+	return 1
+}
+
+// value converts an argument to a Go value, resolving variables. Only the shapes
+// multiplier needs (ints and lists) are converted.
+func (l limits) value(v ast.Value) any {
+	switch x := v.(type) {
+	case *ast.Variable:
+		if val, ok := l.vars[x.Name.Value]; ok {
+			return val
+		}
+		if def, ok := l.defaults[x.Name.Value]; ok {
+			return l.value(def)
+		}
+	case *ast.IntValue:
+		if n, err := strconv.Atoi(x.Value); err == nil {
+			return n
+		}
+	case *ast.ListValue:
+		out := make([]any, len(x.Values))
+		for i, item := range x.Values {
+			out[i] = l.value(item)
+		}
+		return out
+	}
+	return nil
+}
+
+func argument(f *ast.Field, name string) ast.Value {
+	for _, a := range f.Arguments {
+		if a.Name.Value == name {
+			return a.Value
+		}
+	}
+	return nil
+}
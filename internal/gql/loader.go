@@ -0,0 +1,137 @@
+package gql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+)
+
+// loaderWorkers bounds how many service calls one batch runs at a time.
+const loaderWorkers = 4
+
+// loader is a per-request dataloader. Load records a key and returns a thunk; the
+// first thunk to run fetches every key recorded so far in a single batch, so the
+// resolvers of one query level share one round of service calls. Each distinct
+// key is fetched at most once per request.
+type loader[K comparable, V any] struct {
+	fetch func(K) (V, error)
+
+	mu      sync.Mutex
+	pending []K
+	calls   map[K]*call[V]
+}
+
+type call[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+func newLoader[K comparable, V any](fetch func(K) (V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, calls: make(map[K]*call[V])}
+}
+
+// Load schedules key for the next batch and returns a thunk yielding its value.
+func (l *loader[K, V]) Load(key K) func() (V, error) {
+	l.mu.Lock()
+	c, ok := l.calls[key]
+	if !ok {
+		c = &call[V]{done: make(chan struct{})}
+		l.calls[key] = c
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.dispatch()
+		<-c.done
+		return c.val, c.err
+	}
+}
+
+// dispatch fetches all pending keys concurrently and waits for them.
+func (l *loader[K, V]) dispatch() {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	batch := make([]*call[V], len(keys))
+	for i, k := range keys {
+		batch[i] = l.calls[k]
+	}
+	l.mu.Unlock()
+
+	sem := make(chan struct{}, loaderWorkers)
+	var wg sync.WaitGroup
+	for i, k := range keys {
+		c := batch[i]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			c.val, c.err = l.fetch(k)
+			close(c.done)
+		}()
+	}
+	wg.Wait()
+}
+
+// coord identifies a location; it is also the source value of the Location type.
+type coord struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type dayKey struct {
+	coord
+
+	date string
+}
+
+// loaders holds the dataloaders of one request.
+type loaders struct {
+	today       *loader[coord, forecast.Result]
+	daily       *loader[dayKey, forecast.DailyResult]
+	alerts      *loader[coord, []nws.Alert]
+	observation *loader[coord, *nws.Observation]
+}
+
+func newLoaders(ctx context.Context, svc forecast.Service, alerts AlertsFunc, obs ObservationFunc) *loaders {
+	return &loaders{
+		today: newLoader(func(c coord) (forecast.Result, error) {
+			return svc.GetTodaysForcast(ctx, c.Lat, c.Lon)
+		}),
+		daily: newLoader(func(k dayKey) (forecast.DailyResult, error) {
+			date, err := time.Parse("2006-01-02", k.date)
+			if err != nil {
+				return forecast.DailyResult{}, errInvalidDate
+			}
+			return svc.GetDailyForecast(ctx, k.Lat, k.Lon, date)
+		}),
+		alerts: newLoader(func(c coord) ([]nws.Alert, error) {
+			if alerts == nil {
+				return nil, nil
+			}
+			return alerts(ctx, c.Lat, c.Lon)
+		}),
+		observation: newLoader(func(c coord) (*nws.Observation, error) {
+			if obs == nil {
+				return nil, nil
+			}
+			return obs(ctx, c.Lat, c.Lon)
+		}),
+	}
+}
+
+type ctxKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(ctxKey{}).(*loaders)
+	return l
+}
@@ -0,0 +1,316 @@
+// Package gql exposes the forecast service as a GraphQL schema. Resolvers load
+// forecasts through per-request dataloaders, and queries are checked against
+// depth and complexity limits before they run.
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+)
+
+// defaultDays is the number of days returned by Location.days without a count.
+const defaultDays = 7
+
+var errInvalidDate = errors.New("invalid date (want YYYY-MM-DD)")
+
+// newSchema builds the schema. Resolvers find their service through the
+// dataloaders stored in the request context by Executor.Do.
+func newSchema() (graphql.Schema, error) {
+	classification := graphql.NewEnum(graphql.EnumConfig{
+		Name:        "Classification",
+		Description: "Temperature band of a period.",
+		Values: graphql.EnumValueConfigMap{
+			"HOT":      {Value: "hot"},
+			"MODERATE": {Value: "moderate"},
+			"COLD":     {Value: "cold"},
+		},
+	})
+	temperature := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Temperature",
+		Fields: graphql.Fields{
+			"value":          {Type: graphql.NewNonNull(graphql.Int)},
+			"unit":           {Type: graphql.NewNonNull(graphql.String)},
+			"classification": {Type: graphql.NewNonNull(classification), Resolve: field(func(t *forecast.Temperature) any { return t.Type })},
+		},
+	})
+	period := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Period",
+		Description: "A named NWS forecast period such as \"Today\" or \"Tonight\".",
+		Fields: graphql.Fields{
+			"name":          {Type: graphql.NewNonNull(graphql.String)},
+			"shortForecast": {Type: graphql.NewNonNull(graphql.String)},
+			"temperature": {Type: graphql.NewNonNull(temperature), Resolve: field(func(p *forecast.PeriodSummary) any {
+				return &p.Temperature
+			})},
+		},
+	})
+	day := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Day",
+		Description: "Daytime and overnight periods of one local calendar date; either may be null.",
+		Fields: graphql.Fields{
+			"date":  {Type: graphql.NewNonNull(graphql.String)},
+			"day":   {Type: period},
+			"night": {Type: period},
+			"high":  {Type: temperature},
+			"low":   {Type: temperature},
+		},
+	})
+	alert := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Alert",
+		Description: "An active NWS alert for the location.",
+		Fields: graphql.Fields{
+			"id":          {Type: graphql.NewNonNull(graphql.String)},
+			"event":       {Type: graphql.NewNonNull(graphql.String)},
+			"headline":    {Type: graphql.String},
+			"severity":    {Type: graphql.String},
+			"urgency":     {Type: graphql.String},
+			"areaDesc":    {Type: graphql.String},
+			"description": {Type: graphql.String},
+			"instruction": {Type: graphql.String},
+			"effective":   {Type: graphql.DateTime},
+			"expires":     {Type: graphql.DateTime},
+			"ends":        {Type: graphql.DateTime},
+		},
+	})
+	observation := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Observation",
+		Description: "The latest report of the nearest observation station.",
+		Fields: graphql.Fields{
+			"station":         {Type: graphql.NewNonNull(graphql.String)},
+			"timestamp":       {Type: graphql.NewNonNull(graphql.DateTime)},
+			"textDescription": {Type: graphql.String},
+			"temperature": {
+				Type:        graphql.Float,
+				Description: "Air temperature in Fahrenheit; null when the station did not report it.",
+				Resolve: field(func(o *nws.Observation) any {
+					if f, ok := o.Fahrenheit(); ok {
+						return f
+					}
+					return nil
+				}),
+			},
+		},
+	})
+	location := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Location",
+		Fields: graphql.Fields{
+			"lat":       {Type: graphql.NewNonNull(graphql.Float)},
+			"lon":       {Type: graphql.NewNonNull(graphql.Float)},
+			"timeZone":  {Type: graphql.NewNonNull(graphql.String), Resolve: today(func(r forecast.Result) any { return r.TimeZone })},
+			"localTime": {Type: graphql.NewNonNull(graphql.String), Resolve: today(func(r forecast.Result) any { return r.LocalTime })},
+			"updated": {
+				Type:        graphql.String,
+				Description: "Upstream updateTime of the forecast (RFC 3339).",
+				Resolve: today(func(r forecast.Result) any {
+					if r.Meta == nil {
+						return nil
+					}
+					return r.Meta.Updated
+				}),
+			},
+			"today": {Type: graphql.NewNonNull(period), Resolve: today(func(r forecast.Result) any { return &r.Today })},
+			"day": {
+				Type:        day,
+				Description: "Periods for a local date (YYYY-MM-DD); null outside the forecast horizon.",
+				Args:        graphql.FieldConfigArgument{"date": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve:     resolveDay,
+			},
+			"days": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(day))),
+				Description: "Consecutive local dates starting today, up to count or the end of the forecast.",
+				Args:        graphql.FieldConfigArgument{"count": {Type: graphql.Int, DefaultValue: defaultDays}},
+				Resolve:     resolveDays,
+			},
+			"alerts": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(alert))),
+				Description: "Active NWS alerts; empty outside NWS coverage.",
+				Resolve:     resolveAlerts,
+			},
+			"observation": {
+				Type:        observation,
+				Description: "Latest observation of the nearest station; null outside NWS coverage.",
+				Resolve:     resolveObservation,
+			},
+		},
+	})
+	coords := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "Coordinates",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"lat": {Type: graphql.NewNonNull(graphql.Float)},
+			"lon": {Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"location": {
+				Type: graphql.NewNonNull(location),
+				Args: graphql.FieldConfigArgument{
+					"lat": {Type: graphql.NewNonNull(graphql.Float)},
+					"lon": {Type: graphql.NewNonNull(graphql.Float)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					lat, _ := p.Args["lat"].(float64)
+					lon, _ := p.Args["lon"].(float64)
+					return newCoord(lat, lon)
+				},
+			},
+			"locations": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(location))),
+				Args: graphql.FieldConfigArgument{
+					"coords": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(coords)))},
+				},
+				Resolve: resolveLocations,
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+func resolveLocations(p graphql.ResolveParams) (any, error) {
+	in, _ := p.Args["coords"].([]any)
+	out := make([]any, 0, len(in))
+	for _, v := range in {
+		m, _ := v.(map[string]any)
+		lat, _ := m["lat"].(float64)
+		lon, _ := m["lon"].(float64)
+		c, err := newCoord(lat, lon)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func resolveDay(p graphql.ResolveParams) (any, error) {
+	c, _ := p.Source.(*coord)
+	date, _ := p.Args["date"].(string)
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, errInvalidDate
+	}
+	load := loadersFrom(p.Context).daily.Load(dayKey{coord: *c, date: date})
+	return func() (any, error) {
+		res, err := load()
+		if errors.Is(err, forecast.ErrDateOutOfRange) {
+			return nil, nil //nolint:nilnil // null day
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &res, nil
+	}, nil
+}
+
+// resolveDays waits for today's result to learn the local date, then loads the
+// following dates as one batch.
+func resolveDays(p graphql.ResolveParams) (any, error) {
+	c, _ := p.Source.(*coord)
+	count, _ := p.Args["count"].(int)
+	if count < 1 || count > MaxDays {
+		return nil, fmt.Errorf("count must be between 1 and %d", MaxDays)
+	}
+	ld := loadersFrom(p.Context)
+	loadToday := ld.today.Load(*c)
+	return func() (any, error) {
+		res, err := loadToday()
+		if err != nil {
+			return nil, err
+		}
+		start, err := time.Parse(time.RFC3339, res.LocalTime)
+		if err != nil {
+			return nil, fmt.Errorf("parse local time %q: %w", res.LocalTime, err)
+		}
+		loads := make([]func() (forecast.DailyResult, error), count)
+		for i := range loads {
+			loads[i] = ld.daily.Load(dayKey{coord: *c, date: start.AddDate(0, 0, i).Format("2006-01-02")})
+		}
+		out := make([]any, 0, count)
+		for i, load := range loads {
+			d, err := load()
+			if errors.Is(err, forecast.ErrDateOutOfRange) {
+				if i == 0 {
+					continue // today's periods may already have rolled off
+				}
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, &d)
+		}
+		return out, nil
+	}, nil
+}
+
+func resolveAlerts(p graphql.ResolveParams) (any, error) {
+	c, _ := p.Source.(*coord)
+	load := loadersFrom(p.Context).alerts.Load(*c)
+	return func() (any, error) {
+		alerts, err := load()
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, len(alerts))
+		for i := range alerts {
+			out[i] = &alerts[i]
+		}
+		return out, nil
+	}, nil
+}
+
+func resolveObservation(p graphql.ResolveParams) (any, error) {
+	c, _ := p.Source.(*coord)
+	load := loadersFrom(p.Context).observation.Load(*c)
+	return func() (any, error) {
+		o, err := load()
+		if err != nil || o == nil {
+			return nil, err
+		}
+		return o, nil
+	}, nil
+}
+
+// today resolves a Location field from today's result through the dataloader.
+func today(get func(forecast.Result) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		c, _ := p.Source.(*coord)
+		load := loadersFrom(p.Context).today.Load(*c)
+		return func() (any, error) {
+			res, err := load()
+			if err != nil {
+				return nil, err
+			}
+			return get(res), nil
+		}, nil
+	}
+}
+
+// field resolves a value computed from a source of type *T.
+func field[T any](get func(*T) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		src, ok := p.Source.(*T)
+		if !ok {
+			return nil, nil //nolint:nilnil // null source resolves to null
+		}
+		return get(src), nil
+	}
+}
+
+// newCoord validates coordinates with the same bounds as the REST API.
+func newCoord(lat, lon float64) (*coord, error) {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return nil, errors.New("invalid lat")
+	}
+	if math.IsNaN(lon) || lon < -180 || lon > 180 {
+		return nil, errors.New("invalid lon")
+	}
+	return &coord{Lat: lat, Lon: lon}, nil
+}
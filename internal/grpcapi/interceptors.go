@@ -0,0 +1,165 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	logpkg "weather-service/internal/log"
+	"weather-service/internal/ratelimit"
+)
+
+// requestIDKey is the metadata key carrying the request id, matching the HTTP
+// X-Request-ID header.
+const requestIDKey = "x-request-id"
+
+// withRequestID stores the caller's request id (or a new one) in ctx for logging
+// and echoes it back in the response header.
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDKey); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return logpkg.WithRequestID(ctx, id)
+}
+
+func unaryRequestID(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	return next(withRequestID(ctx), req)
+}
+
+func streamRequestID(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+	return next(srv, &serverStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+}
+
+// withCaller records the subject of the peer's verified client certificate as
+// the caller identity (see logpkg.WithCaller), like the HTTP middleware does.
+func withCaller(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+		return logpkg.WithCaller(ctx, info.State.VerifiedChains[0][0].Subject.String())
+	}
+	return ctx
+}
+
+func unaryCaller(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	return next(withCaller(ctx), req)
+}
+
+func streamCaller(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+	return next(srv, &serverStream{ServerStream: ss, ctx: withCaller(ss.Context())})
+}
+
+// limit rejects the call with RESOURCE_EXHAUSTED when its caller (see
+// ratelimit.Key) is over the limit. Health checks are never limited, so probes
+// keep working however busy a caller is.
+func limit(ctx context.Context, l *ratelimit.Limiter, method string) error {
+	if strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	if ok, wait := l.Allow(ratelimit.Key(ctx, addr)); !ok {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded; retry in %s", wait.Round(time.Millisecond))
+	}
+	return nil
+}
+
+func unaryLimiter(l *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		if err := limit(ctx, l, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func streamLimiter(l *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		if err := limit(ss.Context(), l, info.FullMethod); err != nil {
+			return err
+		}
+		return next(srv, ss)
+	}
+}
+
+// unaryRecoverer turns a panic in a handler into an INTERNAL error.
+func unaryRecoverer(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (resp any, err error) { //nolint:nonamedreturns // set by recover
+		defer recoverTo(ctx, log, &err)
+		return next(ctx, req)
+	}
+}
+
+func streamRecoverer(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, next grpc.StreamHandler) (err error) { //nolint:nonamedreturns // set by recover
+		defer recoverTo(ss.Context(), log, &err)
+		return next(srv, ss)
+	}
+}
+
+func recoverTo(ctx context.Context, log *slog.Logger, err *error) {
+	if rec := recover(); rec != nil {
+		log.ErrorContext(ctx, "panic recovered", "err", rec)
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+// unaryLogger logs each call with its status code and duration.
+func unaryLogger(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+		logCall(ctx, log, info.FullMethod, err, start)
+		return resp, err
+	}
+}
+
+func streamLogger(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		start := time.Now()
+		err := next(srv, ss)
+		logCall(ss.Context(), log, info.FullMethod, err, start)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, log *slog.Logger, method string, err error, start time.Time) {
+	// request_id is attached by the log handler from the call context.
+	log.InfoContext(ctx, "grpc_request",
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
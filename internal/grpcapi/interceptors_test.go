@@ -0,0 +1,148 @@
+package grpcapi_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	weatherv1 "weather-service/api/proto/weather/v1"
+	"weather-service/internal/grpcapi"
+	"weather-service/internal/ratelimit"
+	"weather-service/internal/tlsconfig"
+)
+
+// issue returns a certificate for cn signed by parent, or a self-signed CA when
+// parent is nil.
+func issue(t *testing.T, cn string, parent *tls.Certificate, usage ...x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  usage,
+	}
+	signer, signerKey := tmpl, any(key)
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// writePEM stores blocks as a PEM file in dir and returns its path.
+func writePEM(t *testing.T, dir, name string, blocks ...*pem.Block) string {
+	t.Helper()
+	var b []byte
+	for _, block := range blocks {
+		b = append(b, pem.EncodeToMemory(block)...)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestMutualTLSRateLimit serves gRPC with the listener's TLS configuration and
+// checks that clients need a certificate and are limited by its subject.
+func TestMutualTLSRateLimit(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "Test CA", nil)
+	serverCert := issue(t, "localhost", &ca, x509.ExtKeyUsageServerAuth)
+	keyDER, err := x509.MarshalECPrivateKey(serverCert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cert, err := tlsconfig.LoadCertificate(
+		writePEM(t, dir, "tls.crt", &pem.Block{Type: "CERTIFICATE", Bytes: serverCert.Certificate[0]}),
+		writePEM(t, dir, "tls.key", &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := tlsconfig.New(tlsconfig.Options{
+		ClientCAFile: writePEM(t, dir, "ca.pem", &pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate[0]}),
+		MinVersion:   "1.2",
+	}, cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpcapi.NewServer(logger, &fakeSvc{}, ratelimit.New(1, 1), grpc.Creds(credentials.NewTLS(cfg)))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	connect := func(certs ...tls.Certificate) *grpc.ClientConn {
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(
+			&tls.Config{RootCAs: roots, Certificates: certs, ServerName: "localhost", MinVersion: tls.VersionTLS12})))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	}
+	today := func(conn *grpc.ClientConn) codes.Code {
+		_, err := weatherv1.NewWeatherServiceClient(conn).GetToday(context.Background(),
+			&weatherv1.GetTodayRequest{Location: &weatherv1.Location{Lat: 21.3, Lon: -157.8}})
+		return status.Code(err)
+	}
+
+	if got := today(connect()); got != codes.Unavailable {
+		t.Fatalf("without a client certificate: %v, want Unavailable", got)
+	}
+	ui := connect(issue(t, "forecast-ui", &ca, x509.ExtKeyUsageClientAuth))
+	if got := today(ui); got != codes.OK {
+		t.Fatalf("first call: %v", got)
+	}
+	if got := today(ui); got != codes.ResourceExhausted {
+		t.Fatalf("second call within a second: %v, want ResourceExhausted", got)
+	}
+	if _, err = healthpb.NewHealthClient(ui).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("health check while limited: %v", err)
+	}
+	// Another caller from the same address has its own allowance.
+	if got := today(connect(issue(t, "batch-jobs", &ca, x509.ExtKeyUsageClientAuth))); got != codes.OK {
+		t.Fatalf("other caller: %v", got)
+	}
+}
@@ -0,0 +1,103 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// callKey identifies a metrics series: a full method name and a status code.
+type callKey struct {
+	method, code string
+}
+
+type callStats struct {
+	count   uint64
+	seconds float64
+}
+
+// callMetrics counts finished calls and their total duration per method and
+// status code. It is safe for concurrent use.
+type callMetrics struct {
+	mu    sync.Mutex
+	calls map[callKey]*callStats
+}
+
+func newCallMetrics() *callMetrics {
+	return &callMetrics{calls: make(map[callKey]*callStats)}
+}
+
+func (m *callMetrics) observe(method string, err error, start time.Time) {
+	k := callKey{method: method, code: status.Code(err).String()}
+	d := time.Since(start).Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.calls[k]
+	if st == nil {
+		st = &callStats{}
+		m.calls[k] = st
+	}
+	st.count++
+	st.seconds += d
+}
+
+func (m *callMetrics) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := next(ctx, req)
+	m.observe(info.FullMethod, err, start)
+	return resp, err
+}
+
+func (m *callMetrics) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+	start := time.Now()
+	err := next(srv, ss)
+	m.observe(info.FullMethod, err, start)
+	return err
+}
+
+// WriteMetrics writes the call counters in the Prometheus text exposition
+// format, labelled by method and code.
+func (s *Server) WriteMetrics(w io.Writer) error {
+	m := s.metrics
+	m.mu.Lock()
+	keys := make([]callKey, 0, len(m.calls))
+	for k := range m.calls {
+		keys = append(keys, k)
+	}
+	stats := make(map[callKey]callStats, len(keys))
+	for _, k := range keys {
+		stats[k] = *m.calls[k]
+	}
+	m.mu.Unlock()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].code < keys[j].code
+	})
+
+	var b strings.Builder
+	for _, f := range []struct {
+		name, help string
+		value      func(callStats) float64
+	}{
+		{"weather_grpc_requests_total", "gRPC calls handled, by method and status code.",
+			func(st callStats) float64 { return float64(st.count) }},
+		{"weather_grpc_request_seconds_total", "Time spent handling gRPC calls, by method and status code.",
+			func(st callStats) float64 { return st.seconds }},
+	} {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", f.name, f.help, f.name)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s{method=%q,code=%q} %g\n", f.name, k.method, k.code, f.value(stats[k]))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,361 @@
+// Package grpcapi serves the forecast service over gRPC (weather.v1.WeatherService),
+// together with gRPC health checking and server reflection.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	weatherv1 "weather-service/api/proto/weather/v1"
+	"weather-service/internal/forecast"
+	"weather-service/internal/ratelimit"
+)
+
+const (
+	// MaxBatch is the largest number of locations accepted by BatchGetToday.
+	MaxBatch = 100
+	// maxHours bounds GetHourly; NWS hourly forecasts cover 156 hours.
+	maxHours = 168
+	// maxDays bounds GetMultiDay; NWS forecasts cover seven days.
+	maxDays      = 14
+	batchWorkers = 4
+)
+
+// Server is a gRPC server exposing WeatherService backed by a forecast.Service.
+type Server struct {
+	grpc    *grpc.Server
+	health  *health.Server
+	metrics *callMetrics
+}
+
+// NewServer builds a gRPC server whose WeatherService calls into svc. Every call
+// passes through the request-id, caller identity, metrics, recovery, logging and
+// rate limiting interceptors; limiter may be nil to allow every call. opts, such
+// as grpc.Creds, are applied after the interceptors. The standard health service
+// reports SERVING until SetServing(false) or Stop.
+func NewServer(log *slog.Logger, svc forecast.Service, limiter *ratelimit.Limiter, opts ...grpc.ServerOption) *Server {
+	m := newCallMetrics()
+	gs := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryRequestID, unaryCaller, m.unary, unaryRecoverer(log), unaryLogger(log),
+			unaryLimiter(limiter)),
+		grpc.ChainStreamInterceptor(streamRequestID, streamCaller, m.stream, streamRecoverer(log), streamLogger(log),
+			streamLimiter(limiter)),
+	}, opts...)...)
+	hs := health.NewServer()
+	weatherv1.RegisterWeatherServiceServer(gs, &weatherService{svc: svc})
+	healthpb.RegisterHealthServer(gs, hs)
+	reflection.Register(gs)
+
+	s := &Server{grpc: gs, health: hs, metrics: m}
+	s.SetServing(true)
+	return s
+}
+
+// Serve accepts connections on lis until Stop is called.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// SetServing sets the health status reported for the server as a whole and for
+// WeatherService. main clears it as soon as shutdown starts.
+func (s *Server) SetServing(ok bool) {
+	st := healthpb.HealthCheckResponse_SERVING
+	if !ok {
+		st = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.health.SetServingStatus("", st)
+	s.health.SetServingStatus(weatherv1.WeatherService_ServiceDesc.ServiceName, st)
+}
+
+// Stop marks the server not serving and waits for in-flight calls to finish
+// until ctx is done, after which remaining calls are cancelled.
+func (s *Server) Stop(ctx context.Context) {
+	s.health.Shutdown()
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
+
+// weatherService implements weatherv1.WeatherServiceServer.
+type weatherService struct {
+	weatherv1.UnimplementedWeatherServiceServer
+
+	svc forecast.Service
+}
+
+func (w *weatherService) GetToday(ctx context.Context, req *weatherv1.GetTodayRequest) (*weatherv1.TodayForecast, error) {
+	lat, lon, err := location(req.GetLocation())
+	if err != nil {
+		return nil, err
+	}
+	res, err := w.svc.GetTodaysForcast(ctx, lat, lon)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return todayProto(res), nil
+}
+
+func (w *weatherService) GetHourly(ctx context.Context, req *weatherv1.GetHourlyRequest) (*weatherv1.HourlyForecast, error) {
+	lat, lon, err := location(req.GetLocation())
+	if err != nil {
+		return nil, err
+	}
+	hours := int(req.GetHours())
+	if hours < 0 || hours > maxHours {
+		return nil, status.Errorf(codes.InvalidArgument, "hours must be between 0 and %d", maxHours)
+	}
+	res, err := w.svc.GetHourlyForecast(ctx, lat, lon, hours)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return hourlyProto(res), nil
+}
+
+func (w *weatherService) GetDaily(ctx context.Context, req *weatherv1.GetDailyRequest) (*weatherv1.DailyForecast, error) {
+	lat, lon, err := location(req.GetLocation())
+	if err != nil {
+		return nil, err
+	}
+	date, err := time.Parse("2006-01-02", req.GetDate())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid date (want YYYY-MM-DD)")
+	}
+	res, err := w.svc.GetDailyForecast(ctx, lat, lon, date)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return dailyProto(res), nil
+}
+
+// GetMultiDay starts from today's local date at the location (taken from the
+// today result) and collects consecutive dates until the requested count or the
+// end of the forecast horizon. All lookups after the first are served from cache.
+func (w *weatherService) GetMultiDay(ctx context.Context, req *weatherv1.GetMultiDayRequest) (*weatherv1.MultiDayForecast, error) {
+	lat, lon, err := location(req.GetLocation())
+	if err != nil {
+		return nil, err
+	}
+	days := int(req.GetDays())
+	if days < 0 || days > maxDays {
+		return nil, status.Errorf(codes.InvalidArgument, "days must be between 0 and %d", maxDays)
+	}
+	if days == 0 {
+		days = maxDays
+	}
+
+	today, err := w.svc.GetTodaysForcast(ctx, lat, lon)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	start, err := time.Parse(time.RFC3339, today.LocalTime)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "parse local time %q: %v", today.LocalTime, err)
+	}
+
+	out := &weatherv1.MultiDayForecast{}
+	for i := 0; i < days; i++ {
+		res, err := w.svc.GetDailyForecast(ctx, lat, lon, start.AddDate(0, 0, i))
+		if errors.Is(err, forecast.ErrDateOutOfRange) {
+			if i == 0 {
+				continue // today's periods may already have rolled off
+			}
+			break
+		}
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+		out.Days = append(out.Days, dailyProto(res))
+	}
+	return out, nil
+}
+
+// BatchGetToday fetches locations concurrently and sends each result as soon as it
+// is ready. A failing location yields an error entry rather than ending the stream.
+func (w *weatherService) BatchGetToday(req *weatherv1.BatchGetTodayRequest,
+	stream grpc.ServerStreamingServer[weatherv1.BatchGetTodayResponse],
+) error {
+	locs := req.GetLocations()
+	if len(locs) > MaxBatch {
+		return status.Errorf(codes.InvalidArgument, "at most %d locations per batch", MaxBatch)
+	}
+	ctx := stream.Context()
+
+	jobs := make(chan int)
+	results := make(chan *weatherv1.BatchGetTodayResponse)
+	var wg sync.WaitGroup
+	for range min(batchWorkers, len(locs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results <- w.batchItem(ctx, i, locs[i])
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range locs {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var sendErr error
+	for r := range results {
+		if sendErr == nil {
+			sendErr = stream.Send(r)
+		}
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	return toStatus(ctx, ctx.Err())
+}
+
+func (w *weatherService) batchItem(ctx context.Context, i int, loc *weatherv1.Location) *weatherv1.BatchGetTodayResponse {
+	item := &weatherv1.BatchGetTodayResponse{Index: int32(i)} //nolint:gosec // bounded by MaxBatch
+	lat, lon, err := location(loc)
+	if err == nil {
+		var res forecast.Result
+		if res, err = w.svc.GetTodaysForcast(ctx, lat, lon); err == nil {
+			item.Result = &weatherv1.BatchGetTodayResponse_Forecast{Forecast: todayProto(res)}
+			return item
+		}
+	}
+	item.Result = &weatherv1.BatchGetTodayResponse_Error{Error: status.Convert(err).Message()}
+	return item
+}
+
+// location validates a request location with the same bounds as the HTTP API.
+func location(l *weatherv1.Location) (float64, float64, error) {
+	if l == nil {
+		return 0, 0, status.Error(codes.InvalidArgument, "location is required")
+	}
+	lat, lon := l.GetLat(), l.GetLon()
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return 0, 0, status.Error(codes.InvalidArgument, "invalid lat")
+	}
+	if math.IsNaN(lon) || lon < -180 || lon > 180 {
+		return 0, 0, status.Error(codes.InvalidArgument, "invalid lon")
+	}
+	return lat, lon, nil
+}
+
+// toStatus maps service errors to gRPC codes the way the HTTP handlers map them to
+// statuses: out-of-horizon dates are NOT_FOUND, locations without an hourly
+// forecast FAILED_PRECONDITION and upstream failures UNAVAILABLE.
+func toStatus(ctx context.Context, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, forecast.ErrDateOutOfRange):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, forecast.ErrNoHourly):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	default:
+		return status.Error(codes.Unavailable, fmt.Sprintf("upstream: %v", err))
+	}
+}
+
+func todayProto(r forecast.Result) *weatherv1.TodayForecast {
+	return &weatherv1.TodayForecast{
+		Location:  &weatherv1.Location{Lat: r.Coords.Lat, Lon: r.Coords.Lon},
+		Date:      r.Date,
+		TimeZone:  r.TimeZone,
+		LocalTime: r.LocalTime,
+		Today:     periodProto(&r.Today),
+		Source:    r.Source,
+		Meta:      metaProto(r.Meta),
+	}
+}
+
+func hourlyProto(r forecast.PeriodsResult) *weatherv1.HourlyForecast {
+	out := &weatherv1.HourlyForecast{
+		Location: &weatherv1.Location{Lat: r.Coords.Lat, Lon: r.Coords.Lon},
+		TimeZone: r.TimeZone,
+		Source:   r.Source,
+		Meta:     metaProto(r.Meta),
+	}
+	for _, p := range r.Periods {
+		out.Hours = append(out.Hours, &weatherv1.HourlyPeriod{
+			StartTime:     p.Start.Format(time.RFC3339),
+			EndTime:       p.End.Format(time.RFC3339),
+			IsDaytime:     p.IsDaytime,
+			ShortForecast: p.ShortForecast,
+			Temperature:   temperatureProto(&p.Temperature),
+		})
+	}
+	return out
+}
+
+func dailyProto(r forecast.DailyResult) *weatherv1.DailyForecast {
+	return &weatherv1.DailyForecast{
+		Location: &weatherv1.Location{Lat: r.Coords.Lat, Lon: r.Coords.Lon},
+		Date:     r.Date,
+		TimeZone: r.TimeZone,
+		Day:      periodProto(r.Day),
+		Night:    periodProto(r.Night),
+		High:     temperatureProto(r.High),
+		Low:      temperatureProto(r.Low),
+		Source:   r.Source,
+		Meta:     metaProto(r.Meta),
+	}
+}
+
+func periodProto(p *forecast.PeriodSummary) *weatherv1.Period {
+	if p == nil {
+		return nil
+	}
+	return &weatherv1.Period{
+		Name:          p.Name,
+		ShortForecast: p.ShortForecast,
+		Temperature:   temperatureProto(&p.Temperature),
+	}
+}
+
+func temperatureProto(t *forecast.Temperature) *weatherv1.Temperature {
+	if t == nil {
+		return nil
+	}
+	return &weatherv1.Temperature{
+		Value: int32(t.Value), //nolint:gosec // temperatures fit in int32
+		Unit:  t.Unit,
+		Type:  t.Type,
+	}
+}
+
+func metaProto(m *forecast.Meta) *weatherv1.Meta {
+	if m == nil {
+		return nil
+	}
+	return &weatherv1.Meta{Updated: m.Updated}
+}
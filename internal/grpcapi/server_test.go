@@ -0,0 +1,286 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	weatherv1 "weather-service/api/proto/weather/v1"
+	"weather-service/internal/forecast"
+	"weather-service/internal/grpcapi"
+)
+
+// fakeSvc answers today for any location except lat 0, which fails, and has daily
+// periods for the dates listed in days.
+type fakeSvc struct {
+	days map[string]bool
+}
+
+func (f *fakeSvc) GetTodaysForcast(_ context.Context, lat, lon float64) (forecast.Result, error) {
+	if lat == 0 {
+		return forecast.Result{}, errors.New("upstream down")
+	}
+	var res forecast.Result
+	res.Coords.Lat, res.Coords.Lon = lat, lon
+	res.Date = "2025-06-01"
+	res.LocalTime = "2025-06-01T09:00:00-10:00"
+	res.Today = forecast.PeriodSummary{Name: "Today", Temperature: forecast.Temperature{Value: 80, Unit: "F", Type: "moderate"}}
+	res.Meta = &forecast.Meta{Updated: "u1"}
+	return res, nil
+}
+
+func (f *fakeSvc) GetDailyForecast(_ context.Context, _, _ float64, date time.Time) (forecast.DailyResult, error) {
+	d := date.Format("2006-01-02")
+	if !f.days[d] {
+		return forecast.DailyResult{}, fmt.Errorf("%w: %s", forecast.ErrDateOutOfRange, d)
+	}
+	low := forecast.Temperature{Value: 60, Unit: "F", Type: "moderate"}
+	return forecast.DailyResult{Date: d, Night: &forecast.PeriodSummary{Name: "Tonight", Temperature: low}, Low: &low}, nil
+}
+
+func (f *fakeSvc) GetPeriods(context.Context, float64, float64) (forecast.PeriodsResult, error) {
+	return forecast.PeriodsResult{}, errors.New("not implemented")
+}
+
+// GetHourlyForecast returns hours periods from 09:00 local, or 48 for zero;
+// lat 0 fails and lat 1 has no hourly forecast.
+func (f *fakeSvc) GetHourlyForecast(_ context.Context, lat, lon float64, hours int) (forecast.PeriodsResult, error) {
+	switch lat {
+	case 0:
+		return forecast.PeriodsResult{}, errors.New("upstream down")
+	case 1:
+		return forecast.PeriodsResult{}, forecast.ErrNoHourly
+	}
+	if hours == 0 {
+		hours = 48
+	}
+	var res forecast.PeriodsResult
+	res.Coords.Lat, res.Coords.Lon = lat, lon
+	res.TimeZone = "Pacific/Honolulu"
+	res.Source = "api.weather.gov"
+	res.Meta = &forecast.Meta{Updated: "u1"}
+	start := time.Date(2025, 6, 1, 9, 0, 0, 0, time.FixedZone("HST", -10*3600))
+	for i := range hours {
+		res.Periods = append(res.Periods, forecast.Period{
+			PeriodSummary: forecast.PeriodSummary{
+				ShortForecast: "Sunny",
+				Temperature:   forecast.Temperature{Value: 80 + i%3, Unit: "F", Type: "moderate"},
+			},
+			Start:     start.Add(time.Duration(i) * time.Hour),
+			End:       start.Add(time.Duration(i+1) * time.Hour),
+			IsDaytime: i < 9,
+		})
+	}
+	return res, nil
+}
+
+func (f *fakeSvc) Purge(float64, float64) int { return 0 }
+
+func (f *fakeSvc) Refresh(ctx context.Context, lat, lon float64) (forecast.Result, error) {
+	return f.GetTodaysForcast(ctx, lat, lon)
+}
+
+func dial(t *testing.T, svc forecast.Service) (*grpcapi.Server, *grpc.ClientConn) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcapi.NewServer(slog.New(slog.NewTextHandler(io.Discard, nil)), svc, nil)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return srv, conn
+}
+
+func TestGetToday(t *testing.T) {
+	_, conn := dial(t, &fakeSvc{})
+	client := weatherv1.NewWeatherServiceClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-1")
+	var header metadata.MD
+	res, err := client.GetToday(ctx, &weatherv1.GetTodayRequest{Location: &weatherv1.Location{Lat: 21.3, Lon: -157.8}},
+		grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("GetToday: %v", err)
+	}
+	if res.GetLocation().GetLat() != 21.3 || res.GetToday().GetTemperature().GetType() != "moderate" ||
+		res.GetMeta().GetUpdated() != "u1" {
+		t.Fatalf("unexpected result: %v", res)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "req-1" {
+		t.Fatalf("x-request-id header=%v want [req-1]", got)
+	}
+
+	_, err = client.GetToday(context.Background(), &weatherv1.GetTodayRequest{Location: &weatherv1.Location{Lat: 91}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("bad lat: code=%v want InvalidArgument", status.Code(err))
+	}
+	_, err = client.GetToday(context.Background(), &weatherv1.GetTodayRequest{Location: &weatherv1.Location{}})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("upstream error: code=%v want Unavailable", status.Code(err))
+	}
+}
+
+func TestGetHourly(t *testing.T) {
+	_, conn := dial(t, &fakeSvc{})
+	client := weatherv1.NewWeatherServiceClient(conn)
+	ctx := context.Background()
+	loc := &weatherv1.Location{Lat: 21.3, Lon: -157.8}
+
+	res, err := client.GetHourly(ctx, &weatherv1.GetHourlyRequest{Location: loc, Hours: 3})
+	if err != nil {
+		t.Fatalf("GetHourly: %v", err)
+	}
+	if len(res.GetHours()) != 3 || res.GetTimeZone() != "Pacific/Honolulu" || res.GetMeta().GetUpdated() != "u1" {
+		t.Fatalf("unexpected result: %v", res)
+	}
+	first := res.GetHours()[0]
+	if first.GetStartTime() != "2025-06-01T09:00:00-10:00" || first.GetEndTime() != "2025-06-01T10:00:00-10:00" ||
+		!first.GetIsDaytime() || first.GetTemperature().GetValue() != 80 {
+		t.Fatalf("unexpected first hour: %v", first)
+	}
+
+	for _, c := range []struct {
+		name string
+		req  *weatherv1.GetHourlyRequest
+		want codes.Code
+	}{
+		{"too many hours", &weatherv1.GetHourlyRequest{Location: loc, Hours: 169}, codes.InvalidArgument},
+		{"negative hours", &weatherv1.GetHourlyRequest{Location: loc, Hours: -1}, codes.InvalidArgument},
+		{"no hourly forecast", &weatherv1.GetHourlyRequest{Location: &weatherv1.Location{Lat: 1}}, codes.FailedPrecondition},
+		{"upstream error", &weatherv1.GetHourlyRequest{Location: &weatherv1.Location{}}, codes.Unavailable},
+	} {
+		if _, err := client.GetHourly(ctx, c.req); status.Code(err) != c.want {
+			t.Errorf("%s: code=%v want %v", c.name, status.Code(err), c.want)
+		}
+	}
+}
+
+func TestGetDailyAndMultiDay(t *testing.T) {
+	_, conn := dial(t, &fakeSvc{days: map[string]bool{"2025-06-01": true, "2025-06-02": true}})
+	client := weatherv1.NewWeatherServiceClient(conn)
+	loc := &weatherv1.Location{Lat: 21.3, Lon: -157.8}
+
+	daily, err := client.GetDaily(context.Background(), &weatherv1.GetDailyRequest{Location: loc, Date: "2025-06-02"})
+	if err != nil {
+		t.Fatalf("GetDaily: %v", err)
+	}
+	if daily.GetDay() != nil || daily.GetLow().GetValue() != 60 {
+		t.Fatalf("unexpected daily: %v", daily)
+	}
+	_, err = client.GetDaily(context.Background(), &weatherv1.GetDailyRequest{Location: loc, Date: "2025-07-01"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("out of range: code=%v want NotFound", status.Code(err))
+	}
+	_, err = client.GetDaily(context.Background(), &weatherv1.GetDailyRequest{Location: loc, Date: "June 1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("bad date: code=%v want InvalidArgument", status.Code(err))
+	}
+
+	multi, err := client.GetMultiDay(context.Background(), &weatherv1.GetMultiDayRequest{Location: loc})
+	if err != nil {
+		t.Fatalf("GetMultiDay: %v", err)
+	}
+	if len(multi.GetDays()) != 2 || multi.GetDays()[1].GetDate() != "2025-06-02" {
+		t.Fatalf("unexpected days: %v", multi.GetDays())
+	}
+}
+
+func TestBatchGetToday(t *testing.T) {
+	_, conn := dial(t, &fakeSvc{})
+	client := weatherv1.NewWeatherServiceClient(conn)
+
+	stream, err := client.BatchGetToday(context.Background(), &weatherv1.BatchGetTodayRequest{
+		Locations: []*weatherv1.Location{{Lat: 10, Lon: 10}, {Lat: 0, Lon: 0}, {Lat: 95}, {Lat: 20, Lon: 20}},
+	})
+	if err != nil {
+		t.Fatalf("BatchGetToday: %v", err)
+	}
+	var items []*weatherv1.BatchGetTodayResponse
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].GetIndex() < items[j].GetIndex() })
+
+	if len(items) != 4 {
+		t.Fatalf("got %d items want 4", len(items))
+	}
+	if items[0].GetForecast().GetLocation().GetLat() != 10 || items[3].GetForecast().GetLocation().GetLat() != 20 {
+		t.Fatalf("unexpected forecasts: %v", items)
+	}
+	if items[1].GetError() != "upstream down" || items[2].GetError() != "invalid lat" {
+		t.Fatalf("unexpected errors: %q, %q", items[1].GetError(), items[2].GetError())
+	}
+}
+
+func TestHealth(t *testing.T) {
+	srv, conn := dial(t, &fakeSvc{})
+	client := healthpb.NewHealthClient(conn)
+
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		res, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "weather.v1.WeatherService"})
+		if err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+		return res.GetStatus()
+	}
+	if got := check(); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("status=%v want SERVING", got)
+	}
+	srv.SetServing(false)
+	if got := check(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("status=%v want NOT_SERVING", got)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	srv, conn := dial(t, &fakeSvc{})
+	client := weatherv1.NewWeatherServiceClient(conn)
+	ctx := context.Background()
+	for _, lat := range []float64{21.3, 21.3, 0} {
+		_, _ = client.GetToday(ctx, &weatherv1.GetTodayRequest{Location: &weatherv1.Location{Lat: lat}})
+	}
+
+	var b strings.Builder
+	if err := srv.WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics: %v", err)
+	}
+	for _, want := range []string{
+		"# TYPE weather_grpc_requests_total counter\n",
+		`weather_grpc_requests_total{method="/weather.v1.WeatherService/GetToday",code="OK"} 2` + "\n",
+		`weather_grpc_requests_total{method="/weather.v1.WeatherService/GetToday",code="Unavailable"} 1` + "\n",
+		`weather_grpc_request_seconds_total{method="/weather.v1.WeatherService/GetToday",code="OK"} `,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("metrics missing %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package health aggregates dependency checks into a readiness report.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckFunc probes one dependency. It returns a short human-readable detail and a
+// non-nil error when the dependency should make the service not ready.
+type CheckFunc func(ctx context.Context) (string, error)
+
+// Readiness decides whether the service should receive traffic. It is safe for
+// concurrent use.
+type Readiness struct {
+	mu           sync.Mutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+type namedCheck struct {
+	name string
+	fn   CheckFunc
+}
+
+// Report is the outcome of running every registered check.
+type Report struct {
+	Ready  bool          `json:"ready"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// NewReadiness returns a Readiness with only the built-in shutdown check registered.
+func NewReadiness() *Readiness {
+	r := &Readiness{}
+	r.Add("shutdown", func(context.Context) (string, error) {
+		if r.shuttingDown.Load() {
+			return "shutting down", errors.New("shutdown in progress")
+		}
+		return "running", nil
+	})
+	return r
+}
+
+// Add registers a named check. Checks run in registration order.
+func (r *Readiness) Add(name string, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, namedCheck{name: name, fn: fn})
+}
+
+// SetShuttingDown permanently marks the service as not ready so load balancers
+// stop routing to it before the server begins draining connections.
+func (r *Readiness) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check runs every registered check and reports overall readiness.
+func (r *Readiness) Check(ctx context.Context) Report {
+	r.mu.Lock()
+	checks := append([]namedCheck(nil), r.checks...)
+	r.mu.Unlock()
+
+	rep := Report{Ready: true, Checks: make([]CheckResult, 0, len(checks))}
+	for _, c := range checks {
+		detail, err := c.fn(ctx)
+		res := CheckResult{Name: c.name, OK: err == nil, Detail: detail}
+		if err != nil {
+			rep.Ready = false
+			if res.Detail == "" {
+				res.Detail = err.Error()
+			}
+		}
+		rep.Checks = append(rep.Checks, res)
+	}
+	return rep
+}
+
+// UpstreamCheck builds a check that fails when fewer than minRate of the upstream
+// calls observed by rate within window succeeded. Windows with fewer than
+// minSamples calls are considered healthy, since a handful of calls says little.
+func UpstreamCheck(rate func(window time.Duration) (float64, int), window time.Duration, minRate float64,
+	minSamples int,
+) CheckFunc {
+	return func(context.Context) (string, error) {
+		r, n := rate(window)
+		detail := fmt.Sprintf("%.0f%% of %d upstream calls succeeded in the last %s", r*100, n, window)
+		if n >= minSamples && r < minRate {
+			return detail, fmt.Errorf("upstream success rate %.2f below %.2f", r, minRate)
+		}
+		return detail, nil
+	}
+}
+
+// CircuitCheck builds a check that fails while the circuit breaker reported by
+// openUntil is open, that is while the primary upstream is being skipped.
+func CircuitCheck(openUntil func() (time.Time, bool)) CheckFunc {
+	return func(context.Context) (string, error) {
+		until, open := openUntil()
+		if !open {
+			return "closed", nil
+		}
+		detail := "open until " + until.UTC().Format(time.RFC3339)
+		return detail, errors.New("circuit breaker " + detail)
+	}
+}
@@ -0,0 +1,74 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"weather-service/internal/health"
+)
+
+func TestReadinessAllPassing(t *testing.T) {
+	r := health.NewReadiness()
+	r.Add("cache", func(context.Context) (string, error) { return "in-memory", nil })
+
+	rep := r.Check(context.Background())
+	if !rep.Ready || len(rep.Checks) != 2 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+}
+
+func TestReadinessFailingCheck(t *testing.T) {
+	r := health.NewReadiness()
+	r.Add("db", func(context.Context) (string, error) { return "", errors.New("unreachable") })
+
+	rep := r.Check(context.Background())
+	if rep.Ready {
+		t.Fatalf("expected not ready: %+v", rep)
+	}
+	last := rep.Checks[len(rep.Checks)-1]
+	if last.Name != "db" || last.OK || last.Detail != "unreachable" {
+		t.Fatalf("unexpected check result: %+v", last)
+	}
+}
+
+func TestReadinessShuttingDown(t *testing.T) {
+	r := health.NewReadiness()
+	r.SetShuttingDown()
+	if rep := r.Check(context.Background()); rep.Ready {
+		t.Fatalf("expected not ready after SetShuttingDown: %+v", rep)
+	}
+}
+
+func TestUpstreamCheck(t *testing.T) {
+	cases := []struct {
+		rate float64
+		n    int
+		ok   bool
+	}{
+		{1, 0, true},     // no traffic yet
+		{0, 3, true},     // too few samples to judge
+		{0.4, 10, false}, // mostly failing
+		{0.9, 10, true},
+	}
+	for _, c := range cases {
+		check := health.UpstreamCheck(func(time.Duration) (float64, int) { return c.rate, c.n }, time.Minute, 0.5, 5)
+		_, err := check(context.Background())
+		if (err == nil) != c.ok {
+			t.Fatalf("rate=%v n=%d: err=%v want ok=%v", c.rate, c.n, err, c.ok)
+		}
+	}
+}
+
+func TestCircuitCheck(t *testing.T) {
+	var until time.Time
+	check := health.CircuitCheck(func() (time.Time, bool) { return until, !until.IsZero() })
+	if detail, err := check(context.Background()); err != nil || detail != "closed" {
+		t.Fatalf("closed circuit: detail=%q err=%v", detail, err)
+	}
+	until = time.Date(2025, 8, 13, 15, 0, 30, 0, time.UTC)
+	if detail, err := check(context.Background()); err == nil || detail != "open until 2025-08-13T15:00:30Z" {
+		t.Fatalf("open circuit: detail=%q err=%v", detail, err)
+	}
+}
@@ -0,0 +1,12 @@
+package history
+
+import "time"
+
+// SetNow replaces the clock s uses for archive times and retention.
+func SetNow(s *Store, now func() time.Time) { s.now = now }
+
+// Writes returns how many page writes s has committed.
+func Writes(s *Store) int64 {
+	stats := s.db.Stats()
+	return stats.TxStats.GetWrite()
+}
@@ -0,0 +1,417 @@
+// Package history archives every distinct forecast fetched from upstream, so
+// past answers can be looked up after the cache has let them go.
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+)
+
+const (
+	// pruneInterval is how often Run drops forecasts past the retention.
+	pruneInterval = time.Hour
+	// openTimeout bounds the wait for another process's lock on the file.
+	openTimeout = time.Second
+)
+
+// scoresBucket holds forecast verification scores and pendingBucket the
+// forecasts still waiting to be scored. Grid cells are URLs, so neither can
+// collide with one.
+var (
+	scoresBucket  = []byte("verification")
+	pendingBucket = []byte("verification-pending")
+)
+
+// ErrNotFound is returned by AsOf when no archived forecast was in effect, and
+// by Previous when no earlier forecast was archived.
+var ErrNotFound = errors.New("no archived forecast")
+
+// Forecast is an archived forecast document. A forecast is in effect from its
+// issue time until the next one for its grid cell was issued.
+type Forecast struct {
+	IssuedAt     time.Time    `json:"issuedAt" xml:"issuedAt"`                             // upstream updateTime
+	SupersededAt *time.Time   `json:"supersededAt,omitempty" xml:"supersededAt,omitempty"` // issue time of the next archived forecast; nil for the latest
+	ArchivedAt   time.Time    `json:"archivedAt" xml:"archivedAt"`                         // when it was first fetched
+	Units        string       `json:"units,omitempty" xml:"units,omitempty"`
+	Periods      []nws.Period `json:"periods" xml:"period"`
+}
+
+// Document returns f as the upstream forecast document it was archived from.
+func (f Forecast) Document() nws.Forecast {
+	var fc nws.Forecast
+	fc.Properties.Updated = f.IssuedAt
+	fc.Properties.Units = f.Units
+	fc.Properties.Periods = f.Periods
+	return fc
+}
+
+// record is the stored form of a forecast.
+type record struct {
+	ArchivedAt time.Time    `json:"archivedAt"`
+	Forecast   nws.Forecast `json:"forecast"`
+}
+
+// Store is an on-disk archive of forecasts keyed by grid cell (a forecast URL,
+// see forecast.Internal.GridCell) and issue time. Each cell is a bucket whose
+// keys are big-endian issue times, so lookups by time are cursor seeks. The
+// file also keeps forecast verification scores (see PutScore) and pending
+// verification forecasts (see PutPending). It is safe for concurrent use.
+type Store struct {
+	db        *bolt.DB
+	retention time.Duration
+	now       func() time.Time
+}
+
+// Open opens or creates the archive at path. Forecasts superseded longer than
+// retention ago are dropped by Prune.
+func Open(path string, retention time.Duration) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("open forecast history %s: %w", path, err)
+	}
+	return &Store{db: db, retention: retention, now: time.Now}, nil
+}
+
+// Close closes the underlying file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Put archives fc for cell unless a forecast with the same issue time is
+// already archived, and reports whether it was new. Most fetches return a
+// version already archived, so that is checked in a read-only transaction
+// first and only a new version pays for a write and its fsync.
+func (s *Store) Put(cell string, fc nws.Forecast) (bool, error) {
+	key := timeKey(fc.Properties.Updated)
+	archived := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(cell)); b != nil {
+			archived = b.Get(key) != nil
+		}
+		return nil
+	})
+	if err != nil || archived {
+		return false, err
+	}
+	added := false
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(cell))
+		if err != nil {
+			return err
+		}
+		if b.Get(key) != nil {
+			return nil
+		}
+		v, err := json.Marshal(record{ArchivedAt: s.now().UTC(), Forecast: fc})
+		if err != nil {
+			return err
+		}
+		added = true
+		return b.Put(key, v)
+	})
+	if err != nil {
+		return false, fmt.Errorf("archive forecast: %w", err)
+	}
+	return added, nil
+}
+
+// Range returns the forecasts for cell that were in effect at some time in
+// [from, to], oldest first: the one in effect at from and those issued after
+// it up to to.
+func (s *Store) Range(cell string, from, to time.Time) ([]Forecast, error) {
+	var out []Forecast
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(cell))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		fromKey, toKey := timeKey(from), timeKey(to)
+		k, v := c.Seek(fromKey)
+		switch {
+		case k == nil:
+			k, v = c.Last()
+		case !bytes.Equal(k, fromKey):
+			// The forecast in effect at from was issued before it, if at all.
+			if pk, pv := c.Prev(); pk != nil {
+				k, v = pk, pv
+			} else {
+				k, v = c.First()
+			}
+		}
+		for ; k != nil && bytes.Compare(k, toKey) <= 0; k, v = c.Next() {
+			f, err := decode(k, v)
+			if err != nil {
+				return err
+			}
+			if n := len(out); n > 0 {
+				out[n-1].SupersededAt = &f.IssuedAt
+			}
+			out = append(out, f)
+		}
+		if k != nil && len(out) > 0 {
+			next := keyTime(k)
+			out[len(out)-1].SupersededAt = &next
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read forecast history: %w", err)
+	}
+	return out, nil
+}
+
+// AsOf returns the forecast for cell that was in effect at, as it was issued.
+// ErrNotFound is returned when none was archived yet at that time.
+func (s *Store) AsOf(cell string, at time.Time) (Forecast, error) {
+	fcs, err := s.Range(cell, at, at)
+	if err != nil {
+		return Forecast{}, err
+	}
+	if len(fcs) == 0 {
+		return Forecast{}, ErrNotFound
+	}
+	return fcs[0], nil
+}
+
+// Previous returns the forecast document for cell archived just before the
+// one issued at updated: the forecast that update replaced. It matches
+// forecast.PreviousFunc. ErrNotFound is returned when there is none.
+func (s *Store) Previous(_ context.Context, cell string, updated time.Time) (nws.Forecast, error) {
+	var prev Forecast
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(cell))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		k, v := c.Seek(timeKey(updated))
+		if k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+		if k == nil {
+			return nil
+		}
+		var err error
+		prev, err = decode(k, v)
+		found = err == nil
+		return err
+	})
+	if err != nil {
+		return nws.Forecast{}, fmt.Errorf("read forecast history: %w", err)
+	}
+	if !found {
+		return nws.Forecast{}, ErrNotFound
+	}
+	return prev.Document(), nil
+}
+
+// PutScore stores score under key, replacing the score stored before. Scores
+// are kept apart from forecasts and are never pruned.
+func (s *Store) PutScore(key string, score []byte) error {
+	if err := s.putValue(scoresBucket, key, score); err != nil {
+		return fmt.Errorf("store verification score: %w", err)
+	}
+	return nil
+}
+
+// Scores returns every stored score by key.
+func (s *Store) Scores() (map[string][]byte, error) {
+	scores, err := s.values(scoresBucket)
+	if err != nil {
+		return nil, fmt.Errorf("read verification scores: %w", err)
+	}
+	return scores, nil
+}
+
+// PutPending stores the forecasts of a date still to be verified under key,
+// replacing those stored before. Like scores, they are never pruned; the
+// verifier deletes them once the date is scored or dropped.
+func (s *Store) PutPending(key string, forecasts []byte) error {
+	if err := s.putValue(pendingBucket, key, forecasts); err != nil {
+		return fmt.Errorf("store pending verification: %w", err)
+	}
+	return nil
+}
+
+// DeletePending deletes the forecasts stored under key, if any.
+func (s *Store) DeletePending(key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(pendingBucket)
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("delete pending verification: %w", err)
+	}
+	return nil
+}
+
+// Pending returns every date's stored forecasts by key.
+func (s *Store) Pending() (map[string][]byte, error) {
+	pending, err := s.values(pendingBucket)
+	if err != nil {
+		return nil, fmt.Errorf("read pending verifications: %w", err)
+	}
+	return pending, nil
+}
+
+// putValue stores v under key in the named bucket.
+func (s *Store) putValue(bucket []byte, key string, v []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), v)
+	})
+}
+
+// values returns every value in the named bucket by key.
+func (s *Store) values(bucket []byte) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			out[string(k)] = bytes.Clone(v)
+			return nil
+		})
+	})
+	return out, err
+}
+
+// Prune drops forecasts superseded before the retention window, and the latest
+// forecast of cells not updated within it, returning how many were dropped.
+func (s *Store) Prune() (int, error) {
+	cutoff := timeKey(s.now().Add(-s.retention))
+	n := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var emptied [][]byte
+		err := tx.ForEach(func(cell []byte, b *bolt.Bucket) error {
+			if bytes.Equal(cell, scoresBucket) || bytes.Equal(cell, pendingBucket) {
+				return nil
+			}
+			var drop [][]byte
+			total := 0
+			c := b.Cursor()
+			for k, _ := c.First(); k != nil; total++ {
+				next, _ := c.Next()
+				// A forecast is stale once its successor was issued before the
+				// cutoff; the latest one once it was itself issued before it.
+				end := next
+				if end == nil {
+					end = k
+				}
+				if bytes.Compare(end, cutoff) < 0 {
+					drop = append(drop, bytes.Clone(k))
+				}
+				k = next
+			}
+			for _, k := range drop {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			n += len(drop)
+			if total == len(drop) {
+				emptied = append(emptied, bytes.Clone(cell))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, cell := range emptied {
+			if err = tx.DeleteBucket(cell); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("prune forecast history: %w", err)
+	}
+	return n, nil
+}
+
+// Run prunes the archive every hour until ctx is done. Failures are logged.
+func (s *Store) Run(ctx context.Context, logger *slog.Logger) {
+	t := time.NewTicker(pruneInterval)
+	defer t.Stop()
+	for {
+		if n, err := s.Prune(); err != nil {
+			logger.WarnContext(ctx, "forecast history prune failed", "err", err)
+		} else if n > 0 {
+			logger.InfoContext(ctx, "forecast history pruned", "forecasts", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Archive returns p with every forecast it fetches archived in s under its
+// forecast URL. Archive failures are logged and do not fail the fetch.
+func Archive(p forecast.Provider, s *Store, logger *slog.Logger) forecast.Provider {
+	return archiving{Provider: p, store: s, logger: logger}
+}
+
+type archiving struct {
+	forecast.Provider
+	store  *Store
+	logger *slog.Logger
+}
+
+func (a archiving) Forecast(ctx context.Context, forecastURL string) (nws.Forecast, error) {
+	fc, err := a.Provider.Forecast(ctx, forecastURL)
+	if err != nil || len(fc.Properties.Periods) == 0 || fc.Properties.Updated.IsZero() {
+		return fc, err
+	}
+	if _, aerr := a.store.Put(forecastURL, fc); aerr != nil {
+		a.logger.WarnContext(ctx, "forecast not archived", "url", forecastURL, "err", aerr)
+	}
+	return fc, nil
+}
+
+func decode(k, v []byte) (Forecast, error) {
+	var r record
+	if err := json.Unmarshal(v, &r); err != nil {
+		return Forecast{}, fmt.Errorf("decode archived forecast: %w", err)
+	}
+	return Forecast{
+		IssuedAt:   keyTime(k),
+		ArchivedAt: r.ArchivedAt,
+		Units:      r.Forecast.Properties.Units,
+		Periods:    r.Forecast.Properties.Periods,
+	}, nil
+}
+
+// timeKey encodes t as a key sorting in time order.
+func timeKey(t time.Time) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(t.UnixNano()))
+}
+
+func keyTime(k []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(k))).UTC()
+}
@@ -0,0 +1,266 @@
+package history_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/history"
+	"weather-service/internal/nws"
+)
+
+const cell = "https://api.weather.gov/gridpoints/BOU/62,60/forecast"
+
+var t0 = time.Date(2025, 8, 13, 12, 0, 0, 0, time.UTC)
+
+func open(t *testing.T) *history.Store {
+	t.Helper()
+	s, err := history.Open(filepath.Join(t.TempDir(), "history.db"), 48*time.Hour)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func issued(at time.Time, temp int) nws.Forecast {
+	var fc nws.Forecast
+	fc.Properties.Updated = at
+	fc.Properties.Units = "us"
+	fc.Properties.Periods = []nws.Period{{Name: "Today", StartTime: at, EndTime: at.Add(12 * time.Hour), Temperature: temp}}
+	return fc
+}
+
+// put archives forecasts issued 0h, 6h and 12h after t0.
+func put(t *testing.T, s *history.Store) {
+	t.Helper()
+	for i, temp := range []int{80, 84, 86} {
+		if added, err := s.Put(cell, issued(t0.Add(time.Duration(i)*6*time.Hour), temp)); err != nil || !added {
+			t.Fatalf("put %d: added=%v err=%v", i, added, err)
+		}
+	}
+}
+
+func temps(fcs []history.Forecast) []int {
+	out := make([]int, len(fcs))
+	for i, f := range fcs {
+		out[i] = f.Periods[0].Temperature
+	}
+	return out
+}
+
+func TestPutDedupes(t *testing.T) {
+	s := open(t)
+	put(t, s)
+	dup := issued(t0, 99)
+	writes := history.Writes(s)
+	if added, err := s.Put(cell, dup); err != nil || added {
+		t.Fatalf("duplicate issue time: added=%v err=%v", added, err)
+	}
+	if n := history.Writes(s) - writes; n != 0 {
+		t.Fatalf("duplicate written: %d page writes", n)
+	}
+	fc, err := s.AsOf(cell, t0)
+	if err != nil || fc.Periods[0].Temperature != 80 {
+		t.Fatalf("first archived copy not kept: %+v err=%v", fc, err)
+	}
+}
+
+func TestRange(t *testing.T) {
+	s := open(t)
+	put(t, s)
+	cases := []struct {
+		name     string
+		from, to time.Time
+		want     []int
+	}{
+		{"includes the forecast in effect at from", t0.Add(7 * time.Hour), t0.Add(13 * time.Hour), []int{84, 86}},
+		{"window inside one forecast", t0.Add(time.Hour), t0.Add(2 * time.Hour), []int{80}},
+		{"window after the latest", t0.Add(24 * time.Hour), t0.Add(30 * time.Hour), []int{86}},
+		{"window before the first", t0.Add(-3 * time.Hour), t0.Add(-time.Hour), []int{}},
+		{"whole history", t0.Add(-time.Hour), t0.Add(time.Hour * 24), []int{80, 84, 86}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fcs, err := s.Range(cell, tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("range: %v", err)
+			}
+			got := temps(fcs)
+			if len(got) != len(tc.want) {
+				t.Fatalf("temps=%v want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("temps=%v want %v", got, tc.want)
+				}
+			}
+		})
+	}
+
+	fcs, _ := s.Range(cell, t0, t0.Add(6*time.Hour))
+	if len(fcs) != 2 || fcs[0].SupersededAt == nil || !fcs[0].SupersededAt.Equal(t0.Add(6*time.Hour)) {
+		t.Fatalf("first forecast superseded at %v", fcs[0].SupersededAt)
+	}
+	// The 06:00 forecast was superseded at 12:00 even though that is past the window.
+	if fcs[1].SupersededAt == nil || !fcs[1].SupersededAt.Equal(t0.Add(12*time.Hour)) {
+		t.Fatalf("second forecast superseded at %v", fcs[1].SupersededAt)
+	}
+	if fcs, _ = s.Range("other", t0, t0.Add(time.Hour)); len(fcs) != 0 {
+		t.Fatalf("unknown cell returned %d forecasts", len(fcs))
+	}
+}
+
+func TestAsOf(t *testing.T) {
+	s := open(t)
+	put(t, s)
+	fc, err := s.AsOf(cell, t0.Add(8*time.Hour))
+	if err != nil || fc.Periods[0].Temperature != 84 || !fc.IssuedAt.Equal(t0.Add(6*time.Hour)) {
+		t.Fatalf("as of 20:00: %+v err=%v", fc, err)
+	}
+	if fc, err = s.AsOf(cell, t0.Add(12*time.Hour)); err != nil || fc.SupersededAt != nil {
+		t.Fatalf("latest: %+v err=%v", fc, err)
+	}
+	if _, err = s.AsOf(cell, t0.Add(-time.Minute)); !errors.Is(err, history.ErrNotFound) {
+		t.Fatalf("before the first forecast: err=%v", err)
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	s := open(t)
+	put(t, s)
+	ctx := context.Background()
+	prev, err := s.Previous(ctx, cell, t0.Add(12*time.Hour))
+	if err != nil || prev.Properties.Periods[0].Temperature != 84 || !prev.Properties.Updated.Equal(t0.Add(6*time.Hour)) {
+		t.Fatalf("replaced by the latest: %+v err=%v", prev, err)
+	}
+	if prev, err = s.Previous(ctx, cell, t0.Add(6*time.Hour)); err != nil || prev.Properties.Periods[0].Temperature != 80 {
+		t.Fatalf("replaced by the second: %+v err=%v", prev, err)
+	}
+	if _, err = s.Previous(ctx, cell, t0); !errors.Is(err, history.ErrNotFound) {
+		t.Fatalf("first forecast: err=%v", err)
+	}
+	if _, err = s.Previous(ctx, "other", t0); !errors.Is(err, history.ErrNotFound) {
+		t.Fatalf("unknown cell: err=%v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s := open(t)
+	put(t, s)
+	if _, err := s.Put("other", issued(t0, 70)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 48h retention: the 12:00 forecast was superseded more than 48h ago and
+	// the other cell was last updated then; the 18:00 one, superseded at the
+	// cutoff, is kept.
+	history.SetNow(s, func() time.Time { return t0.Add(60 * time.Hour) })
+	if err := s.PutScore("score", []byte(`{"n":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PutPending("date", []byte(`{"date":"2025-08-13"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.Prune(); err != nil || n != 2 {
+		t.Fatalf("pruned %d err=%v", n, err)
+	}
+	if fcs, _ := s.Range(cell, t0, t0.Add(24*time.Hour)); len(fcs) != 2 || fcs[0].Periods[0].Temperature != 84 {
+		t.Fatalf("remaining temps=%v", temps(fcs))
+	}
+	if _, err := s.AsOf("other", t0.Add(time.Hour)); !errors.Is(err, history.ErrNotFound) {
+		t.Fatalf("other cell kept: err=%v", err)
+	}
+	// Once the cell has not been updated within the retention, it is dropped.
+	history.SetNow(s, func() time.Time { return t0.Add(61 * time.Hour) })
+	if n, err := s.Prune(); err != nil || n != 2 {
+		t.Fatalf("pruned %d err=%v", n, err)
+	}
+	if _, err := s.AsOf(cell, t0.Add(24*time.Hour)); !errors.Is(err, history.ErrNotFound) {
+		t.Fatalf("stale cell kept: err=%v", err)
+	}
+	if scores, err := s.Scores(); err != nil || string(scores["score"]) != `{"n":1}` {
+		t.Fatalf("scores=%q err=%v", scores, err)
+	}
+	if pending, err := s.Pending(); err != nil || len(pending) != 1 {
+		t.Fatalf("pending=%q err=%v", pending, err)
+	}
+}
+
+func TestScores(t *testing.T) {
+	s := open(t)
+	if scores, err := s.Scores(); err != nil || len(scores) != 0 {
+		t.Fatalf("scores=%q err=%v", scores, err)
+	}
+	for _, v := range []string{"1", "2"} {
+		if err := s.PutScore("a", []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.PutScore("b", []byte("3")); err != nil {
+		t.Fatal(err)
+	}
+	scores, err := s.Scores()
+	if err != nil || len(scores) != 2 || string(scores["a"]) != "2" || string(scores["b"]) != "3" {
+		t.Fatalf("scores=%q err=%v", scores, err)
+	}
+}
+
+func TestPending(t *testing.T) {
+	s := open(t)
+	if err := s.DeletePending("a"); err != nil {
+		t.Fatalf("delete from empty store: %v", err)
+	}
+	for _, kv := range [][2]string{{"a", "1"}, {"a", "2"}, {"b", "3"}} {
+		if err := s.PutPending(kv[0], []byte(kv[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.DeletePending("b"); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := s.Pending()
+	if err != nil || len(pending) != 1 || string(pending["a"]) != "2" {
+		t.Fatalf("pending=%q err=%v", pending, err)
+	}
+	if scores, _ := s.Scores(); len(scores) != 0 {
+		t.Fatalf("pending forecasts read as scores: %q", scores)
+	}
+}
+
+type stubProvider struct {
+	forecast.Provider
+	fc  nws.Forecast
+	err error
+}
+
+func (p stubProvider) Forecast(context.Context, string) (nws.Forecast, error) { return p.fc, p.err }
+
+func TestArchive(t *testing.T) {
+	s := open(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	p := history.Archive(stubProvider{fc: issued(t0, 80)}, s, logger)
+	for range 2 {
+		if _, err := p.Forecast(ctx, cell); err != nil {
+			t.Fatalf("forecast: %v", err)
+		}
+	}
+	if fcs, _ := s.Range(cell, t0, t0.Add(time.Hour)); len(fcs) != 1 {
+		t.Fatalf("archived %d forecasts, want 1", len(fcs))
+	}
+
+	failing := history.Archive(stubProvider{fc: issued(t0.Add(time.Hour), 81), err: errors.New("upstream down")}, s, logger)
+	if _, err := failing.Forecast(ctx, cell); err == nil {
+		t.Fatal("error not passed through")
+	}
+	if fcs, _ := s.Range(cell, t0, t0.Add(2*time.Hour)); len(fcs) != 1 {
+		t.Fatalf("failed fetch archived: %d forecasts", len(fcs))
+	}
+}
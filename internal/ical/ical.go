@@ -0,0 +1,137 @@
+// Package ical writes RFC 5545 iCalendar documents: a VCALENDAR of timed
+// VEVENTs with a VTIMEZONE derived from Go's time zone database.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateTimeFormat = "20060102T150405"
+	utcFormat      = "20060102T150405Z"
+	maxLine        = 75 // octets per line before folding (RFC 5545 section 3.1)
+)
+
+// Calendar is a VCALENDAR.
+type Calendar struct {
+	ProdID   string         // PRODID, e.g. "-//example//forecast//EN"
+	Name     string         // X-WR-CALNAME shown by calendar clients
+	Location *time.Location // zone of event times; nil or UTC writes UTC times
+	Refresh  time.Duration  // suggested polling interval (REFRESH-INTERVAL), 0 to omit
+	Events   []Event
+}
+
+// Event is a timed VEVENT.
+type Event struct {
+	// UID must stay the same across documents for clients to update the event
+	// instead of adding a duplicate.
+	UID         string
+	Start, End  time.Time
+	Stamp       time.Time // DTSTAMP and LAST-MODIFIED
+	Summary     string
+	Description string
+	Categories  []string
+}
+
+// Encode writes c to w with CRLF line endings and folded long lines.
+func (c Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+	local := c.Location != nil && c.Location != time.UTC
+	timeProp := func(name string, t time.Time) {
+		if local {
+			line(name+";TZID="+c.Location.String(), t.In(c.Location).Format(dateTimeFormat))
+			return
+		}
+		line(name, t.UTC().Format(utcFormat))
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", Escape(c.Name))
+	}
+	if c.Refresh > 0 {
+		line("REFRESH-INTERVAL;VALUE=DURATION", duration(c.Refresh))
+		line("X-PUBLISHED-TTL", duration(c.Refresh))
+	}
+	if local && len(c.Events) > 0 {
+		from, to := c.Events[0].Start, c.Events[0].End
+		for _, e := range c.Events {
+			if e.Start.Before(from) {
+				from = e.Start
+			}
+			if e.End.After(to) {
+				to = e.End
+			}
+		}
+		for _, l := range vtimezone(c.Location, from, to) {
+			writeFolded(bw, l)
+		}
+	}
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", e.Stamp.UTC().Format(utcFormat))
+		line("LAST-MODIFIED", e.Stamp.UTC().Format(utcFormat))
+		timeProp("DTSTART", e.Start)
+		timeProp("DTEND", e.End)
+		line("SUMMARY", Escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", Escape(e.Description))
+		}
+		if len(e.Categories) > 0 {
+			cats := make([]string, len(e.Categories))
+			for i, cat := range e.Categories {
+				cats[i] = Escape(cat)
+			}
+			line("CATEGORIES", strings.Join(cats, ","))
+		}
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// Escape escapes a TEXT value (RFC 5545 section 3.3.11).
+func Escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeFolded writes a content line, folding it into lines of at most 75 octets
+// without splitting a UTF-8 sequence.
+func writeFolded(w *bufio.Writer, l string) {
+	limit := maxLine
+	for len(l) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(l[cut]) {
+			cut--
+		}
+		_, _ = w.WriteString(l[:cut] + "\r\n ")
+		l = l[cut:]
+		limit = maxLine - 1 // the leading space counts
+	}
+	_, _ = w.WriteString(l + "\r\n")
+}
+
+// duration formats d as an RFC 5545 DURATION value.
+func duration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("PT%dH", d/time.Hour)
+	}
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("PT%dM", d/time.Minute)
+	}
+	return fmt.Sprintf("PT%dS", d/time.Second)
+}
@@ -0,0 +1,129 @@
+package ical_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/ical"
+)
+
+func encode(t *testing.T, c ical.Calendar) string {
+	t.Helper()
+	var b strings.Builder
+	if err := c.Encode(&b); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return b.String()
+}
+
+// unfold reverses line folding and splits the content lines.
+func unfold(s string) []string {
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n ", ""), "\r\n"), "\r\n")
+}
+
+func has(lines []string, want string) bool {
+	for _, l := range lines {
+		if l == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEncodeFoldsAndEscapes(t *testing.T) {
+	start := time.Date(2025, 8, 13, 18, 0, 0, 0, time.UTC)
+	desc := strings.Repeat("Partly cloudy, with a low around 58°; ", 5) + "\nWinds calm."
+	out := encode(t, ical.Calendar{ProdID: "-//test//EN", Events: []ical.Event{{
+		UID: "a@test", Start: start, End: start.Add(12 * time.Hour), Stamp: start, Summary: "58°F moderate – Clear",
+		Description: desc,
+	}}})
+
+	for _, l := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(l) > 75 {
+			t.Fatalf("line longer than 75 octets: %q", l)
+		}
+		if strings.Contains(l, "\n") {
+			t.Fatalf("bare LF in %q", l)
+		}
+	}
+	lines := unfold(out)
+	if lines[0] != "BEGIN:VCALENDAR" || lines[len(lines)-1] != "END:VCALENDAR" {
+		t.Fatalf("unexpected framing: %q … %q", lines[0], lines[len(lines)-1])
+	}
+	for _, want := range []string{
+		"DTSTART:20250813T180000Z",
+		"DTEND:20250814T060000Z",
+		"SUMMARY:58°F moderate – Clear",
+		`DESCRIPTION:` + strings.Repeat(`Partly cloudy\, with a low around 58°\; `, 5) + `\nWinds calm.`,
+	} {
+		if !has(lines, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "VTIMEZONE") {
+		t.Errorf("UTC calendar has a VTIMEZONE:\n%s", out)
+	}
+}
+
+func TestEncodeTimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+	// Spans the end of daylight saving time on 2025-11-02.
+	start := time.Date(2025, 10, 31, 6, 0, 0, 0, loc)
+	var events []ical.Event
+	for i := 0; i < 4; i++ {
+		s := start.AddDate(0, 0, i)
+		events = append(events, ical.Event{UID: s.String(), Start: s, End: s.Add(12 * time.Hour), Stamp: start, Summary: "x"})
+	}
+	lines := unfold(encode(t, ical.Calendar{ProdID: "-//test//EN", Location: loc, Events: events}))
+
+	for _, want := range []string{
+		"TZID:America/Denver",
+		"DTSTART;TZID=America/Denver:20251031T060000",
+		"DTSTART;TZID=America/Denver:20251103T060000",
+	} {
+		if !has(lines, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	// The daylight observance began on 2025-03-09 and the standard one on
+	// 2025-11-02, each at 02:00 local time before the change.
+	var tz []string
+	for i, l := range lines {
+		if l == "BEGIN:VTIMEZONE" {
+			for _, l2 := range lines[i:] {
+				tz = append(tz, l2)
+				if l2 == "END:VTIMEZONE" {
+					break
+				}
+			}
+		}
+	}
+	want := []string{
+		"BEGIN:VTIMEZONE", "TZID:America/Denver",
+		"BEGIN:DAYLIGHT", "DTSTART:20250309T020000", "TZOFFSETFROM:-0700", "TZOFFSETTO:-0600", "TZNAME:MDT", "END:DAYLIGHT",
+		"BEGIN:STANDARD", "DTSTART:20251102T020000", "TZOFFSETFROM:-0600", "TZOFFSETTO:-0700", "TZNAME:MST", "END:STANDARD",
+		"END:VTIMEZONE",
+	}
+	if strings.Join(tz, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("VTIMEZONE:\n%s\nwant:\n%s", strings.Join(tz, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestEncodeTimeZoneWithoutTransitions(t *testing.T) {
+	loc, err := time.LoadLocation("Pacific/Honolulu")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+	s := time.Date(2025, 6, 1, 6, 0, 0, 0, loc)
+	lines := unfold(encode(t, ical.Calendar{ProdID: "-//test//EN", Location: loc,
+		Events: []ical.Event{{UID: "h", Start: s, End: s.Add(12 * time.Hour), Stamp: s, Summary: "x"}}}))
+	for _, want := range []string{"BEGIN:STANDARD", "TZOFFSETFROM:-1000", "TZOFFSETTO:-1000", "TZNAME:HST"} {
+		if !has(lines, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// vtimezone returns the VTIMEZONE lines describing loc between from and to. Go
+// does not expose a zone's rules, so the offset changes are found by probing,
+// and each becomes an observance with an explicit DTSTART rather than an RRULE.
+// The observance in effect at from is always included.
+func vtimezone(loc *time.Location, from, to time.Time) []string {
+	lines := []string{"BEGIN:VTIMEZONE", "TZID:" + loc.String()}
+
+	changes := transitions(loc, from.AddDate(-1, 0, 0), to)
+	// Only the last change at or before from matters: it starts the
+	// observance in effect at from.
+	for len(changes) > 1 && !changes[1].After(from) {
+		changes = changes[1:]
+	}
+	if len(changes) == 0 || changes[0].After(from) {
+		// No change in the year before from, so the offset at from is the
+		// zone's only one in recent history.
+		t := from.In(loc)
+		name, off := t.Zone()
+		lines = append(lines, observance(t.IsDST(), name, off, off, "19700101T000000")...)
+	}
+	for _, t := range changes {
+		t = t.In(loc)
+		name, off := t.Zone()
+		_, prev := t.Add(-time.Second).Zone()
+		start := t.In(time.FixedZone("", prev)).Format(dateTimeFormat)
+		lines = append(lines, observance(t.IsDST(), name, prev, off, start)...)
+	}
+	return append(lines, "END:VTIMEZONE")
+}
+
+func observance(dst bool, name string, from, to int, start string) []string {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+	return []string{
+		"BEGIN:" + kind,
+		"DTSTART:" + start,
+		"TZOFFSETFROM:" + offset(from),
+		"TZOFFSETTO:" + offset(to),
+		"TZNAME:" + name,
+		"END:" + kind,
+	}
+}
+
+// transitions returns the instants in (from, to] at which loc's offset or
+// abbreviation changes.
+func transitions(loc *time.Location, from, to time.Time) []time.Time {
+	var out []time.Time
+	const step = 24 * time.Hour
+	zone := func(t time.Time) string {
+		name, off := t.In(loc).Zone()
+		return fmt.Sprintf("%s%d", name, off)
+	}
+	for t := from; t.Before(to); t = t.Add(step) {
+		next := t.Add(step)
+		if zone(t) == zone(next) {
+			continue
+		}
+		// Binary search for the first second of the new offset.
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+			if zone(mid) == zone(lo) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		if !hi.After(to) {
+			out = append(out, hi)
+		}
+	}
+	return out
+}
+
+// offset formats a UTC offset in seconds as +hhmm (or +hhmmss).
+func offset(sec int) string {
+	sign := '+'
+	if sec < 0 {
+		sign, sec = '-', -sec
+	}
+	if s := sec % 60; s != 0 {
+		return fmt.Sprintf("%c%02d%02d%02d", sign, sec/3600, sec/60%60, s)
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, sec/3600, sec/60%60)
+}
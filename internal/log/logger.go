@@ -2,11 +2,94 @@
 package log
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"os"
 )
 
-// New constructs a new slog.Logger that writes text logs to stdout at the given level.
-func New(level slog.Level) *slog.Logger {
-	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+// Supported output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type ctxKey int
+
+const (
+	reqIDKey ctxKey = iota
+	callerKey
+)
+
+// New constructs a new slog.Logger that writes logs in the given format (FormatText
+// or FormatJSON) to stdout at the given level. Pass a *slog.LevelVar to be able to
+// change the level at runtime.
+func New(format string, level slog.Leveler) *slog.Logger {
+	return slog.New(NewHandler(os.Stdout, format, level))
+}
+
+// NewHandler returns a slog.Handler writing to w in the given format. Records
+// logged with a context carrying a request ID (see WithRequestID) get a
+// request_id attribute, so any *Context logging call made while serving a
+// request is correlated with it; a caller identity (see WithCaller) likewise
+// adds a caller attribute.
+func NewHandler(w io.Writer, format string, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if format == FormatJSON {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return contextHandler{Handler: h}
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, reqIDKey, id)
+}
+
+// RequestID extracts the request ID from ctx, or "" if none is present.
+func RequestID(ctx context.Context) string {
+	if v, ok := ctx.Value(reqIDKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// WithCaller returns a copy of ctx carrying the authenticated caller's identity,
+// such as a client certificate subject.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey, caller)
+}
+
+// Caller extracts the caller identity from ctx, or "" if the caller is anonymous.
+func Caller(ctx context.Context) string {
+	if v, ok := ctx.Value(callerKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// contextHandler adds attributes carried by the record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if caller := Caller(ctx); caller != "" {
+		r.AddAttrs(slog.String("caller", caller))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
 }
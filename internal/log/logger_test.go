@@ -0,0 +1,51 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	logpkg "weather-service/internal/log"
+)
+
+func TestJSONHandlerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(logpkg.NewHandler(&buf, logpkg.FormatJSON, slog.LevelInfo)).With("component", "nws")
+
+	ctx := logpkg.WithRequestID(context.Background(), "req-1")
+	logger.WarnContext(ctx, "nws throttled, retrying", "status", 503)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("output is not JSON: %v; %s", err, buf.String())
+	}
+	if rec["request_id"] != "req-1" || rec["component"] != "nws" || rec["msg"] != "nws throttled, retrying" {
+		t.Fatalf("unexpected record: %v", rec)
+	}
+}
+
+func TestTextHandlerWithoutRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(logpkg.NewHandler(&buf, logpkg.FormatText, slog.LevelInfo))
+
+	logger.InfoContext(context.Background(), "hello")
+	if out := buf.String(); !strings.Contains(out, "msg=hello") || strings.Contains(out, "request_id") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestLevelVarChangesAtRuntime(t *testing.T) {
+	var buf bytes.Buffer
+	level := &slog.LevelVar{}
+	logger := slog.New(logpkg.NewHandler(&buf, logpkg.FormatText, level))
+
+	logger.Debug("hidden")
+	level.Set(slog.LevelDebug)
+	logger.Debug("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
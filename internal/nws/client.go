@@ -8,14 +8,19 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	clientTimeout   = 5 * time.Second
-	backoffDuration = 250 * time.Millisecond
+	clientTimeout = 5 * time.Second
+	maxErrorBody  = 4 << 10 // bytes of an error response kept in StatusError
+	// maxStationLists bounds how many grid cells' station lists the client
+	// remembers; an arbitrary one is forgotten to make room.
+	maxStationLists = 4096
 )
 
 // Client wraps access to the api.weather.gov HTTP API.
@@ -24,6 +29,11 @@ type Client struct {
 	ua     string
 	http   *http.Client
 	logger *slog.Logger
+	retry  RetryPolicy
+	stats  outcomes
+
+	mu       sync.Mutex
+	stations map[string][]string // by stations URL
 }
 
 // NewClient constructs a new NWS API client.
@@ -32,13 +42,21 @@ func NewClient(baseURL, userAgent string, httpClient *http.Client, logger *slog.
 		httpClient = &http.Client{Timeout: clientTimeout}
 	}
 	return &Client{
-		base:   strings.TrimRight(baseURL, "/"),
-		ua:     userAgent,
-		http:   httpClient,
-		logger: logger,
+		base:     strings.TrimRight(baseURL, "/"),
+		ua:       userAgent,
+		http:     httpClient,
+		logger:   logger,
+		retry:    DefaultRetryPolicy(),
+		stations: make(map[string][]string),
 	}
 }
 
+// SetRetryPolicy replaces DefaultRetryPolicy. It must be called before the
+// client is used.
+func (c *Client) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
+}
+
 // Points returns the NWS points metadata for the given latitude and longitude.
 func (c *Client) Points(ctx context.Context, lat, lon float64) (PointsResponse, error) {
 	var pr PointsResponse
@@ -58,16 +76,93 @@ func (c *Client) Forecast(ctx context.Context, forecastURL string) (Forecast, er
 	return f, nil
 }
 
+// Alerts returns the alerts currently in effect at the given latitude and longitude.
+func (c *Client) Alerts(ctx context.Context, lat, lon float64) ([]Alert, error) {
+	var ac AlertCollection
+	url := fmt.Sprintf("%s/alerts/active?point=%f,%f", c.base, lat, lon)
+	if err := c.doJSON(ctx, http.MethodGet, url, &ac); err != nil {
+		return nil, err
+	}
+	alerts := make([]Alert, len(ac.Features))
+	for i, f := range ac.Features {
+		alerts[i] = f.Properties
+	}
+	return alerts, nil
+}
+
+// Stations returns the URLs of the observation stations listed at stationsURL,
+// the observationStations of a point's grid cell, nearest first. NWS seldom
+// changes them, so each grid cell's list is fetched once and then remembered.
+func (c *Client) Stations(ctx context.Context, stationsURL string) ([]string, error) {
+	if stationsURL == "" {
+		return nil, errors.New("no observation stations URL")
+	}
+	c.mu.Lock()
+	stations, ok := c.stations[stationsURL]
+	c.mu.Unlock()
+	if ok {
+		return stations, nil
+	}
+	var sc StationCollection
+	if err := c.doJSON(ctx, http.MethodGet, stationsURL, &sc); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if len(c.stations) >= maxStationLists {
+		for k := range c.stations {
+			delete(c.stations, k)
+			break
+		}
+	}
+	c.stations[stationsURL] = sc.ObservationStations
+	c.mu.Unlock()
+	return sc.ObservationStations, nil
+}
+
+// LatestObservation returns the most recent observation of the station at
+// stationURL.
+func (c *Client) LatestObservation(ctx context.Context, stationURL string) (Observation, error) {
+	var or ObservationResponse
+	if err := c.doJSON(ctx, http.MethodGet, stationURL+"/observations/latest", &or); err != nil {
+		return Observation{}, err
+	}
+	return or.Properties, nil
+}
+
+// Observations returns the observations the station at stationURL made from
+// start up to end, newest first.
+func (c *Client) Observations(ctx context.Context, stationURL string, start, end time.Time) ([]Observation, error) {
+	q := url.Values{
+		"start": {start.UTC().Format(time.RFC3339)},
+		"end":   {end.UTC().Format(time.RFC3339)},
+	}
+	var oc ObservationCollection
+	if err := c.doJSON(ctx, http.MethodGet, stationURL+"/observations?"+q.Encode(), &oc); err != nil {
+		return nil, err
+	}
+	obs := make([]Observation, 0, len(oc.Features))
+	for _, f := range oc.Features {
+		obs = append(obs, f.Properties)
+	}
+	return obs, nil
+}
+
+// StatusError is returned for a non-retryable HTTP status, such as the 404 NWS
+// answers for points outside its coverage.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("nws http %d: %s", e.Code, e.Body)
+}
+
 // doJSON performs an HTTP request and decodes a JSON (GeoJSON) response into out.
 // It sets required headers (User-Agent and Accept) and fails fast if the
-// client was created without a User-Agent. The request is executed with a
-// small retry policy:
-//   - Up to 3 attempts total
-//   - Transient network errors are retried with exponential backoff starting
-//     at 250ms
-//   - HTTP 429 (Too Many Requests) and 503 (Service Unavailable) are retried;
-//     when the Retry-After header is present it is honored, otherwise the
-//     backoff delay is used
+// client was created without a User-Agent. Failed attempts are retried as the
+// client's RetryPolicy allows; waits end early when ctx is done, and the
+// Retry-After header of 429 and 503 responses is honoured.
 //
 // Non-retryable HTTP statuses cause the body to be read and returned as part
 // of the error message. The response body is always closed. On a 200 OK, the
@@ -83,60 +178,74 @@ func (c *Client) doJSON(ctx context.Context, method, url string, out any) error
 	req.Header.Set("User-Agent", c.ua)
 	req.Header.Set("Accept", "application/geo+json")
 
-	var lastErr error
-	backoff := backoffDuration
+	var waited time.Duration
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		status, retryAfter, retry, err := c.attempt(ctx, req, out)
+		if err == nil || !retry {
+			c.observe(ctx, err, status)
+			return err
+		}
+		delay, ok := c.retry.delay(ctx, attempt, retryAfter, waited, time.Since(start))
+		if !ok {
+			c.observe(ctx, err, status)
+			return err
+		}
+		c.logger.WarnContext(ctx, "nws request failed, retrying",
+			"status", status, "err", err, "attempt", attempt, "delay", delay, "url", url)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			c.observe(ctx, err, status)
+			return fmt.Errorf("%w (last attempt: %w)", sleepErr, err)
+		}
+		waited += delay
+	}
+}
+
+// attempt sends req once and decodes a 200 response into out. It returns the
+// response status (0 without a response), the Retry-After delay the server
+// asked for and whether a failure is worth retrying.
+func (c *Client) attempt(ctx context.Context, req *http.Request, out any) (status int, retryAfter time.Duration, retry bool, err error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// A missing fixture stays missing however often it is asked for.
+		return 0, 0, ctx.Err() == nil && !errors.Is(err, ErrNoFixture), err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			c.logger.ErrorContext(ctx, "Error closing body of readCloser in doJson")
+		}
+	}()
 
-	for attempt := 0; attempt < 3; attempt++ {
-		resp, doErr := c.http.Do(req)
-		if doErr != nil {
-			lastErr = doErr
-			time.Sleep(backoff)
-			backoff *= 2
-			continue
+	if resp.StatusCode == http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return resp.StatusCode, 0, ctx.Err() == nil, err
 		}
-		func() {
-			defer func(Body io.ReadCloser) {
-				closeErr := Body.Close()
-				if closeErr != nil {
-					c.logger.Error("Error closing body of readCloser in doJson")
-				}
-			}(resp.Body)
-			if resp.StatusCode == http.StatusOK {
-				body, readAllErr := io.ReadAll(resp.Body)
-				if readAllErr != nil {
-					lastErr = readAllErr
-					return
-				}
-				if unmarshalErr := json.Unmarshal(body, out); unmarshalErr != nil {
-					lastErr = unmarshalErr
-					return
-				}
-				lastErr = nil
-				return
-			}
-
-			// Retry on 429/503 using Retry-After when present.
-			if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
-				delay := backoff
-				if ra := parseRetryAfter(resp.Header.Get("Retry-After")); ra > 0 {
-					delay = ra
-				}
-				c.logger.Warn("nws throttled, retrying", "status", resp.StatusCode, "delay", delay, "url", url)
-				time.Sleep(delay)
-				backoff *= 2
-				lastErr = fmt.Errorf("nws throttled: %s", resp.Status)
-				return
-			}
-
-			// Non-retryable
-			b, _ := io.ReadAll(resp.Body)
-			lastErr = fmt.Errorf("nws http %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
-		}()
-		if lastErr == nil {
-			return nil
+		// A malformed document comes back the same on every attempt.
+		if err = json.Unmarshal(body, out); err != nil {
+			return resp.StatusCode, 0, false, fmt.Errorf("decode nws response: %w", err)
 		}
+		return resp.StatusCode, 0, false, nil
+	}
+
+	retry = c.retry.retryable(resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		return resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After")), retry,
+			fmt.Errorf("nws throttled: %s", resp.Status)
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return resp.StatusCode, 0, retry, &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+}
+
+// observe records the outcome of a request for SuccessRate. Calls cut short by
+// the caller's context, and client errors other than 429 (the upstream answered
+// correctly), are not held against the upstream.
+func (c *Client) observe(ctx context.Context, err error, status int) {
+	if err != nil && ctx.Err() != nil {
+		return
 	}
-	return lastErr
+	clientErr := status >= 400 && status < 500 && status != http.StatusTooManyRequests
+	c.stats.record(err == nil || clientErr)
 }
 
 func parseRetryAfter(v string) time.Duration {
@@ -0,0 +1,205 @@
+package nws_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"weather-service/internal/nws"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) *nws.Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c := nws.NewClient(srv.URL, "test-agent", srv.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	p := nws.DefaultRetryPolicy()
+	p.BaseBackoff, p.MaxBackoff = time.Millisecond, time.Millisecond
+	c.SetRetryPolicy(p)
+	return c
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var (
+		requests   atomic.Int32
+		statuses   []int
+		retryAfter string
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		n := int(requests.Add(1)) - 1
+		if n < len(statuses) {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(statuses[n])
+			return
+		}
+		_, _ = w.Write([]byte(`{"properties":{}}`))
+	})
+	run := func(ctx context.Context, script []int, after string) (int32, error) {
+		requests.Store(0)
+		statuses, retryAfter = script, after
+		_, err := c.Points(ctx, 1, 2)
+		return requests.Load(), err
+	}
+	ctx := context.Background()
+
+	if n, err := run(ctx, []int{http.StatusBadGateway, http.StatusGatewayTimeout}, ""); err != nil || n != 3 {
+		t.Fatalf("5xx: requests=%d err=%v", n, err)
+	}
+	n, err := run(ctx, []int{500, 500, 500}, "")
+	var se *nws.StatusError
+	if n != 3 || !errors.As(err, &se) || se.Code != http.StatusInternalServerError {
+		t.Fatalf("exhausted: requests=%d err=%v", n, err)
+	}
+	if n, err = run(ctx, []int{http.StatusBadRequest}, ""); n != 1 || err == nil {
+		t.Fatalf("400: requests=%d err=%v", n, err)
+	}
+	if n, err = run(ctx, []int{http.StatusTooManyRequests}, "3600"); n != 1 || err == nil {
+		t.Fatalf("long Retry-After: requests=%d err=%v", n, err)
+	}
+
+	// A Retry-After the deadline leaves no room for is not waited out.
+	deadline, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if n, err = run(deadline, []int{http.StatusServiceUnavailable}, "1"); n != 1 || err == nil || time.Since(start) > 400*time.Millisecond {
+		t.Fatalf("deadline: requests=%d err=%v after %v", n, err, time.Since(start))
+	}
+
+	// Cancellation ends a wait early.
+	canceled, cancel := context.WithCancel(ctx)
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start = time.Now()
+	if n, err = run(canceled, []int{http.StatusServiceUnavailable}, "2"); n != 1 || !errors.Is(err, context.Canceled) || time.Since(start) > time.Second {
+		t.Fatalf("canceled: requests=%d err=%v after %v", n, err, time.Since(start))
+	}
+}
+
+func TestMalformedResponseNotRetried(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"properties":`))
+	})
+	if _, err := c.Points(context.Background(), 1, 2); err == nil || requests.Load() != 1 {
+		t.Fatalf("requests=%d err=%v", requests.Load(), err)
+	}
+}
+
+func TestSuccessRate(t *testing.T) {
+	status := http.StatusOK
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"properties":{}}`))
+	})
+
+	if rate, n := c.SuccessRate(time.Minute); rate != 1 || n != 0 {
+		t.Fatalf("empty: rate=%v n=%d", rate, n)
+	}
+
+	ctx := context.Background()
+	if _, err := c.Points(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status = http.StatusNotFound // out of coverage: the upstream is fine
+	_, _ = c.Points(ctx, 1, 2)
+	status = http.StatusInternalServerError
+	_, _ = c.Points(ctx, 1, 2)
+
+	rate, n := c.SuccessRate(time.Minute)
+	if n != 3 || rate < 0.66 || rate > 0.67 {
+		t.Fatalf("rate=%v n=%d want 2/3 of 3", rate, n)
+	}
+}
+
+func TestAlerts(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/alerts/active" || r.URL.Query().Get("point") != "39.739200,-104.990300" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"features":[{"properties":{"id":"a1","event":"Winter Storm Warning",
+			"severity":"Severe","effective":"2025-01-10T06:00:00-07:00","expires":"2025-01-11T06:00:00-07:00","ends":null}}]}`))
+	})
+
+	alerts, err := c.Alerts(context.Background(), 39.7392, -104.9903)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Event != "Winter Storm Warning" || alerts[0].Ends != nil || alerts[0].Expires.IsZero() {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+}
+
+func TestStationsAndLatestObservation(t *testing.T) {
+	listed := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := "http://" + r.Host
+		switch r.URL.Path {
+		case "/gridpoints/BOU/62,60/stations":
+			listed++
+			_, _ = w.Write([]byte(`{"observationStations":["` + base + `/stations/KDEN","` + base + `/stations/KBKF"]}`))
+		case "/stations/KDEN/observations/latest":
+			_, _ = w.Write([]byte(`{"properties":{"station":"` + base + `/stations/KDEN","timestamp":"2025-08-13T14:53:00+00:00",
+				"temperature":{"unitCode":"wmoUnit:degC","value":30,"qualityControl":"V"}}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer srv.Close()
+	c := nws.NewClient(srv.URL, "test-agent", srv.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var stations []string
+	for range 2 {
+		var err error
+		stations, err = c.Stations(context.Background(), srv.URL+"/gridpoints/BOU/62,60/stations")
+		if err != nil || len(stations) != 2 || !strings.HasSuffix(stations[0], "/stations/KDEN") {
+			t.Fatalf("stations=%v err=%v", stations, err)
+		}
+	}
+	if listed != 1 {
+		t.Fatalf("station list fetched %d times, want once per grid cell", listed)
+	}
+	obs, err := c.LatestObservation(context.Background(), stations[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f, ok := obs.Fahrenheit(); !ok || f != 86 || obs.Timestamp.IsZero() {
+		t.Fatalf("observation=%+v fahrenheit=%v", obs, f)
+	}
+	if _, ok := (nws.Observation{Temperature: nws.Measurement{UnitCode: "wmoUnit:degC"}}).Fahrenheit(); ok {
+		t.Fatal("missing value reported")
+	}
+}
+
+func TestObservations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/stations/KDEN/observations" || q.Get("start") != "2025-08-13T12:00:00Z" || q.Get("end") != "2025-08-14T12:00:00Z" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"type":"FeatureCollection","features":[
+			{"properties":{"timestamp":"2025-08-13T21:53:00+00:00","temperature":{"unitCode":"wmoUnit:degC","value":33}}},
+			{"properties":{"timestamp":"2025-08-13T20:53:00+00:00","temperature":{"unitCode":"wmoUnit:degC","value":null}}}]}`))
+	}))
+	defer srv.Close()
+	c := nws.NewClient(srv.URL, "test-agent", srv.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	denver := time.FixedZone("MDT", -6*60*60)
+	start := time.Date(2025, 8, 13, 6, 0, 0, 0, denver)
+	obs, err := c.Observations(context.Background(), srv.URL+"/stations/KDEN", start, start.Add(24*time.Hour))
+	if err != nil || len(obs) != 2 {
+		t.Fatalf("observations=%+v err=%v", obs, err)
+	}
+	if f, ok := obs[0].Fahrenheit(); !ok || math.Round(f*10) != 914 {
+		t.Fatalf("newest=%+v fahrenheit=%v", obs[0], f)
+	}
+	if _, ok := obs[1].Fahrenheit(); ok {
+		t.Fatal("missing value reported")
+	}
+}
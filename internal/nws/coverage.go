@@ -0,0 +1,30 @@
+package nws
+
+// region is a latitude/longitude bounding box.
+type region struct {
+	minLat, maxLat, minLon, maxLon float64
+}
+
+// coverage approximates the areas NWS forecasts for: the contiguous US, Alaska
+// (including the Aleutians west of the antimeridian), Hawaii, Puerto Rico and the
+// Virgin Islands, Guam and the Northern Marianas, and American Samoa. The boxes
+// also take in some neighbouring land, where /points answers 404.
+var coverage = []region{
+	{24.4, 49.5, -125.0, -66.9},
+	{51.0, 71.5, -180.0, -129.9},
+	{51.0, 53.0, 172.0, 180.0},
+	{18.5, 22.5, -160.5, -154.5},
+	{17.5, 18.6, -67.5, -64.5},
+	{13.0, 21.0, 144.0, 146.5},
+	{-14.6, -11.0, -171.5, -168.0},
+}
+
+// Covers reports whether lat, lon lies inside the NWS forecast area.
+func Covers(lat, lon float64) bool {
+	for _, r := range coverage {
+		if lat >= r.minLat && lat <= r.maxLat && lon >= r.minLon && lon <= r.maxLon {
+			return true
+		}
+	}
+	return false
+}
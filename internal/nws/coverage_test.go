@@ -0,0 +1,28 @@
+package nws_test
+
+import (
+	"testing"
+
+	"weather-service/internal/nws"
+)
+
+func TestCovers(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"Denver", 39.7392, -104.9903, true},
+		{"Anchorage", 61.2181, -149.9003, true},
+		{"Honolulu", 21.3069, -157.8583, true},
+		{"San Juan", 18.4655, -66.1057, true},
+		{"Guam", 13.4443, 144.7937, true},
+		{"Paris", 48.8566, 2.3522, false},
+		{"Mexico City", 19.4326, -99.1332, false},
+		{"Sydney", -33.8688, 151.2093, false},
+	} {
+		if got := nws.Covers(tc.lat, tc.lon); got != tc.want {
+			t.Errorf("%s: Covers=%v want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -8,9 +8,11 @@ type PointsResponse struct {
 		Forecast         string `json:"forecast"`
 		ForecastHourly   string `json:"forecastHourly"`
 		ForecastGridData string `json:"forecastGridData"`
+		Stations         string `json:"observationStations"`
 		GridID           string `json:"gridID"`
 		GridX            int    `json:"gridX"`
 		GridY            int    `json:"gridY"`
+		TimeZone         string `json:"timeZone"`
 	} `json:"properties"`
 }
 
@@ -25,12 +27,82 @@ type Forecast struct {
 
 // Period is an individual forecast period (e.g., Today, Tonight).
 type Period struct {
-	Name             string    `json:"name"`
-	StartTime        time.Time `json:"startTime"`
-	EndTime          time.Time `json:"endTime"`
-	IsDaytime        bool      `json:"isDaytime"`
-	Temperature      int       `json:"temperature"`
-	TemperatureUnit  string    `json:"temperatureUnit"`
-	ShortForecast    string    `json:"shortForecast"`
-	DetailedForecast string    `json:"detailedForecast"`
+	Name             string    `json:"name" xml:"name"`
+	StartTime        time.Time `json:"startTime" xml:"startTime"`
+	EndTime          time.Time `json:"endTime" xml:"endTime"`
+	IsDaytime        bool      `json:"isDaytime" xml:"isDaytime"`
+	Temperature      int       `json:"temperature" xml:"temperature"`
+	TemperatureUnit  string    `json:"temperatureUnit" xml:"temperatureUnit"`
+	ShortForecast    string    `json:"shortForecast" xml:"shortForecast"`
+	DetailedForecast string    `json:"detailedForecast" xml:"detailedForecast"`
+}
+
+// AlertCollection is the response from /alerts/active.
+type AlertCollection struct {
+	Features []struct {
+		Properties Alert `json:"properties"`
+	} `json:"features"`
+}
+
+// Alert is an active watch, warning or advisory.
+type Alert struct {
+	ID          string     `json:"id"`
+	Event       string     `json:"event"`
+	Headline    string     `json:"headline"`
+	Severity    string     `json:"severity"`
+	Urgency     string     `json:"urgency"`
+	AreaDesc    string     `json:"areaDesc"`
+	Description string     `json:"description"`
+	Instruction string     `json:"instruction"`
+	Sent        time.Time  `json:"sent"` // when this version of the alert was issued
+	Effective   time.Time  `json:"effective"`
+	Expires     time.Time  `json:"expires"`
+	Ends        *time.Time `json:"ends"`
+}
+
+// StationCollection is the response from a point's observation stations URL,
+// nearest station first.
+type StationCollection struct {
+	ObservationStations []string `json:"observationStations"`
+}
+
+// ObservationResponse is the response from /stations/{id}/observations/latest.
+type ObservationResponse struct {
+	Properties Observation `json:"properties"`
+}
+
+// ObservationCollection is the response from /stations/{id}/observations.
+type ObservationCollection struct {
+	Features []ObservationResponse `json:"features"`
+}
+
+// Observation is a station's reading at a point in time.
+type Observation struct {
+	Station         string      `json:"station"`
+	Timestamp       time.Time   `json:"timestamp"`
+	TextDescription string      `json:"textDescription"`
+	Temperature     Measurement `json:"temperature"`
+}
+
+// Measurement is a value with a WMO unit code, such as "wmoUnit:degC". Value
+// is nil when the station did not report it.
+type Measurement struct {
+	UnitCode string   `json:"unitCode"`
+	Value    *float64 `json:"value"`
+}
+
+// Fahrenheit returns the temperature in Fahrenheit, and false when it was not
+// reported or has an unknown unit.
+func (o Observation) Fahrenheit() (float64, bool) {
+	v := o.Temperature.Value
+	if v == nil {
+		return 0, false
+	}
+	switch o.Temperature.UnitCode {
+	case "wmoUnit:degC":
+		return *v*9/5 + 32, true
+	case "wmoUnit:degF":
+		return *v, true
+	}
+	return 0, false
 }
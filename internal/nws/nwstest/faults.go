@@ -0,0 +1,105 @@
+package nwstest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Fault is a scripted misbehaviour for Server.Inject. Build one with
+// RateLimited, Unavailable, Status, Malformed or Slow.
+type Fault struct {
+	status     int
+	retryAfter string
+	body       string
+	latency    time.Duration
+	times      int
+}
+
+// fault is an injected Fault with its path prefix and remaining uses.
+type fault struct {
+	Fault
+	prefix string
+}
+
+// RateLimited answers 429 with the given Retry-After value ("" omits the
+// header) for the next times requests; times <= 0 means every request.
+func RateLimited(retryAfter string, times int) Fault {
+	return Fault{status: http.StatusTooManyRequests, retryAfter: retryAfter, times: times}
+}
+
+// Unavailable answers 503 with the given Retry-After value ("" omits the header)
+// for the next times requests; times <= 0 means every request.
+func Unavailable(retryAfter string, times int) Fault {
+	return Fault{status: http.StatusServiceUnavailable, retryAfter: retryAfter, times: times}
+}
+
+// Status answers with an arbitrary HTTP status for the next times requests;
+// times <= 0 means every request.
+func Status(code, times int) Fault {
+	return Fault{status: code, times: times}
+}
+
+// Malformed answers 200 with a truncated JSON body for the next times requests;
+// times <= 0 means every request.
+func Malformed(times int) Fault {
+	return Fault{status: http.StatusOK, body: `{"properties":{"periods":[`, times: times}
+}
+
+// Slow delays the next times requests by d before answering them normally;
+// times <= 0 means every request. A request whose client gives up is dropped.
+func Slow(d time.Duration, times int) Fault {
+	return Fault{latency: d, times: times}
+}
+
+// Then adds latency to f, e.g. Unavailable("", 1).Then(time.Second).
+func (f Fault) Then(latency time.Duration) Fault {
+	f.latency = latency
+	return f
+}
+
+// Inject applies f to requests whose path starts with prefix ("" matches every
+// request), such as "/points" or "/gridpoints". Faults are consulted in
+// injection order and the first one matching a request is used.
+func (s *Server) Inject(prefix string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{Fault: f, prefix: prefix})
+}
+
+// Reset removes every injected fault.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault returns the fault for path and uses it up. s.mu must be held.
+func (s *Server) takeFault(path string) *fault {
+	for i, f := range s.faults {
+		if !strings.HasPrefix(path, f.prefix) {
+			continue
+		}
+		if f.times > 0 {
+			f.times--
+			if f.times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func (f *fault) write(w http.ResponseWriter) {
+	if f.retryAfter != "" {
+		w.Header().Set("Retry-After", f.retryAfter)
+	}
+	if f.body != "" {
+		w.Header().Set("Content-Type", "application/geo+json")
+		w.WriteHeader(f.status)
+		_, _ = w.Write([]byte(f.body))
+		return
+	}
+	problem(w, f.status, http.StatusText(f.status), "injected by nwstest")
+}
@@ -0,0 +1,346 @@
+package nwstest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"weather-service/internal/nws"
+)
+
+// Synthetic forecasts have seven days of day/night periods and 156 hourly ones.
+const (
+	forecastPeriods = 14
+	hourlyPeriods   = 156
+)
+
+var skies = []string{"Sunny", "Partly Cloudy", "Chance Showers", "Mostly Cloudy"}
+
+func (s *Server) points(w http.ResponseWriter, r *http.Request) {
+	lat, lon, err := parsePoint(r.PathValue("coords"))
+	if err != nil {
+		problem(w, http.StatusBadRequest, "Invalid Parameter", err.Error())
+		return
+	}
+	c := cellOf(lat, lon)
+	s.mu.Lock()
+	uncovered, tz := s.uncovered[c], s.zone(c)
+	s.mu.Unlock()
+	if uncovered {
+		problem(w, http.StatusNotFound, "Data Unavailable For Requested Point",
+			fmt.Sprintf("Unable to provide data for requested point %.4f,%.4f", lat, lon))
+		return
+	}
+	grid := s.gridURL(c)
+	writeJSON(w, map[string]any{
+		"properties": map[string]any{
+			"@id":                 s.PointURL(lat, lon),
+			"gridId":              Office,
+			"gridX":               c.x,
+			"gridY":               c.y,
+			"forecast":            grid + "/forecast",
+			"forecastHourly":      grid + "/forecast/hourly",
+			"forecastGridData":    grid,
+			"observationStations": grid + "/stations",
+			"timeZone":            tz,
+		},
+	})
+}
+
+func (s *Server) forecast(w http.ResponseWriter, r *http.Request) {
+	c, ok := gridCell(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	fc, set := s.forecasts[c]
+	s.mu.Unlock()
+	if !set {
+		fc = s.synthetic(c, false)
+	}
+	writeJSON(w, fc)
+}
+
+func (s *Server) hourly(w http.ResponseWriter, r *http.Request) {
+	c, ok := gridCell(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, s.synthetic(c, true))
+}
+
+func (s *Server) stations(w http.ResponseWriter, r *http.Request) {
+	c, ok := gridCell(w, r)
+	if !ok {
+		return
+	}
+	id := stationID(c)
+	lat, lon := c.center()
+	s.mu.Lock()
+	tz := s.zone(c)
+	s.mu.Unlock()
+	station := s.URL + "/stations/" + id
+	writeJSON(w, map[string]any{
+		"type": "FeatureCollection",
+		"features": []any{map[string]any{
+			"id":       station,
+			"geometry": map[string]any{"type": "Point", "coordinates": []float64{lon, lat}},
+			"properties": map[string]any{
+				"@id":               station,
+				"stationIdentifier": id,
+				"name":              "Synthetic station " + id,
+				"timeZone":          tz,
+			},
+		}},
+		"observationStations": []string{station},
+	})
+}
+
+// observation answers the latest observation of a synthetic station: the
+// current hourly forecast temperature in Celsius, as NWS reports it.
+func (s *Server) observation(w http.ResponseWriter, r *http.Request) {
+	c, ok := stationCell(w, r)
+	if !ok {
+		return
+	}
+	p := s.synthetic(c, true).Properties.Periods[0]
+	writeJSON(w, map[string]any{"properties": s.observed(c, p, p.StartTime)})
+}
+
+// observations answers /stations/{id}/observations?start=&end= with an
+// observation on every hour from start up to end or the current time, each
+// reading the current hour's synthetic temperature.
+func (s *Server) observations(w http.ResponseWriter, r *http.Request) {
+	c, ok := stationCell(w, r)
+	if !ok {
+		return
+	}
+	start, err := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+	if err != nil {
+		problem(w, http.StatusBadRequest, "Invalid Parameter", "start: "+err.Error())
+		return
+	}
+	end, err := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+	if err != nil {
+		problem(w, http.StatusBadRequest, "Invalid Parameter", "end: "+err.Error())
+		return
+	}
+	s.mu.Lock()
+	now := s.now()
+	s.mu.Unlock()
+	p := s.synthetic(c, true).Properties.Periods[0]
+	features := []any{}
+	for at := end.Add(-time.Nanosecond).Truncate(time.Hour); !at.Before(start); at = at.Add(-time.Hour) {
+		if !at.After(now) {
+			features = append(features, map[string]any{"properties": s.observed(c, p, at)})
+		}
+	}
+	writeJSON(w, map[string]any{"type": "FeatureCollection", "features": features})
+}
+
+// stationCell returns the grid cell of the synthetic station in r's path,
+// answering 404 for any other station.
+func stationCell(w http.ResponseWriter, r *http.Request) (cell, bool) {
+	var c cell
+	if _, err := fmt.Sscanf(r.PathValue("id"), Office+"%d-%d", &c.x, &c.y); err != nil {
+		problem(w, http.StatusNotFound, "Not Found", "unknown station "+r.PathValue("id"))
+		return c, false
+	}
+	return c, true
+}
+
+// observed is the properties of an observation of hourly period p at c's
+// station, timestamped at.
+func (s *Server) observed(c cell, p nws.Period, at time.Time) map[string]any {
+	celsius := math.Round(float64(p.Temperature-32)*5/9*10) / 10
+	return map[string]any{
+		"station":         s.URL + "/stations/" + stationID(c),
+		"timestamp":       at.Format(time.RFC3339),
+		"textDescription": p.ShortForecast,
+		"temperature":     map[string]any{"unitCode": "wmoUnit:degC", "value": celsius, "qualityControl": "V"},
+	}
+}
+
+// activeAlerts answers /alerts/active?point=lat,lon, or every alert without point.
+func (s *Server) activeAlerts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var alerts []nws.Alert
+	if pt := r.URL.Query().Get("point"); pt != "" {
+		lat, lon, err := parsePoint(pt)
+		if err != nil {
+			s.mu.Unlock()
+			problem(w, http.StatusBadRequest, "Invalid Parameter", err.Error())
+			return
+		}
+		alerts = s.alerts[cellOf(lat, lon)]
+	} else {
+		for _, a := range s.alerts {
+			alerts = append(alerts, a...)
+		}
+	}
+	features := make([]any, len(alerts))
+	for i, a := range alerts {
+		features[i] = map[string]any{"properties": a}
+	}
+	s.mu.Unlock()
+	writeJSON(w, map[string]any{"type": "FeatureCollection", "features": features})
+}
+
+// synthetic generates the forecast of c: day/night periods, or hourly ones,
+// starting at the current period in the cell's time zone. Temperatures fall
+// with latitude and vary from day to day.
+func (s *Server) synthetic(c cell, hourly bool) nws.Forecast {
+	s.mu.Lock()
+	now, tz, updated := s.now(), s.zone(c), s.updated[c]
+	s.mu.Unlock()
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	now = now.In(loc)
+	if updated.IsZero() {
+		updated = now.Truncate(time.Hour)
+	}
+	lat, _ := c.center()
+	base := int(math.Round(95 - 0.8*math.Abs(lat)))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	var fc nws.Forecast
+	fc.Properties.Updated = updated
+	fc.Properties.Units = "us"
+	if hourly {
+		start := now.Truncate(time.Hour)
+		for i := 0; i < hourlyPeriods; i++ {
+			t := start.Add(time.Duration(i) * time.Hour)
+			day := dayIndex(today, t)
+			high, low := temps(base, day)
+			swing := (1 + math.Cos(float64(t.Hour()-15)*math.Pi/12)) / 2
+			fc.Properties.Periods = append(fc.Properties.Periods, nws.Period{
+				StartTime:       t,
+				EndTime:         t.Add(time.Hour),
+				IsDaytime:       t.Hour() >= 6 && t.Hour() < 18,
+				Temperature:     low + int(math.Round(float64(high-low)*swing)),
+				TemperatureUnit: "F",
+				ShortForecast:   skies[(c.x+c.y+day)%len(skies)],
+			})
+		}
+		return fc
+	}
+
+	// Periods run 06:00-18:00 and 18:00-06:00 local time; slot 2n starts at 06:00
+	// on day n and slot 2n+1 at 18:00.
+	first := 0
+	switch {
+	case now.Hour() >= 18:
+		first = 1
+	case now.Hour() < 6:
+		first = -1
+	}
+	slot := func(k int) time.Time {
+		day, hour := k/2, 6
+		if k%2 != 0 {
+			hour = 18
+			if k < 0 {
+				day--
+			}
+		}
+		return time.Date(today.Year(), today.Month(), today.Day()+day, hour, 0, 0, 0, loc)
+	}
+	for i := 0; i < forecastPeriods; i++ {
+		t := slot(first + i)
+		daytime := t.Hour() == 6
+		day := dayIndex(today, t)
+		high, low := temps(base, day)
+		p := nws.Period{
+			Name:            periodName(t, daytime, now),
+			StartTime:       t,
+			EndTime:         slot(first + i + 1),
+			IsDaytime:       daytime,
+			Temperature:     high,
+			TemperatureUnit: "F",
+			ShortForecast:   skies[(c.x+c.y+day+len(skies))%len(skies)],
+		}
+		if daytime {
+			p.DetailedForecast = fmt.Sprintf("%s, with a high near %d.", p.ShortForecast, high)
+		} else {
+			p.Temperature = low
+			p.DetailedForecast = fmt.Sprintf("%s, with a low around %d.", p.ShortForecast, low)
+		}
+		fc.Properties.Periods = append(fc.Properties.Periods, p)
+	}
+	return fc
+}
+
+// dayIndex returns the number of calendar days from today to t.
+func dayIndex(today, t time.Time) int {
+	d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, today.Location())
+	return int(math.Round(d.Sub(today).Hours() / 24))
+}
+
+// temps returns the synthetic high and following low for a day offset.
+func temps(base, day int) (high, low int) {
+	d := ((day % 7) + 7) % 7
+	high = base + (d*3)%7 - 3
+	return high, high - 18
+}
+
+// periodName follows NWS naming: "Today" or "This Afternoon", "Tonight" (or
+// "Overnight" before 06:00) for the current day, weekday names afterwards.
+func periodName(start time.Time, daytime bool, now time.Time) string {
+	sameDay := start.YearDay() == now.YearDay()
+	switch {
+	case sameDay && daytime && now.Hour() >= 12:
+		return "This Afternoon"
+	case sameDay && daytime:
+		return "Today"
+	case sameDay:
+		return "Tonight"
+	case !daytime && start.Before(now):
+		return "Overnight"
+	case daytime:
+		return start.Weekday().String()
+	default:
+		return start.Weekday().String() + " Night"
+	}
+}
+
+func (s *Server) zone(c cell) string {
+	if tz, ok := s.tz[c]; ok {
+		return tz
+	}
+	return s.defaultTZ
+}
+
+func stationID(c cell) string {
+	return fmt.Sprintf("%s%d-%d", Office, c.x, c.y)
+}
+
+// gridCell parses the office and x,y path values, answering 404 for unknown ones.
+func gridCell(w http.ResponseWriter, r *http.Request) (cell, bool) {
+	var c cell
+	xs, ys, ok := strings.Cut(r.PathValue("xy"), ",")
+	x, errX := strconv.Atoi(xs)
+	y, errY := strconv.Atoi(ys)
+	if r.PathValue("office") != Office || !ok || errX != nil || errY != nil {
+		problem(w, http.StatusNotFound, "Not Found", "unknown gridpoint "+r.PathValue("office")+"/"+r.PathValue("xy"))
+		return c, false
+	}
+	return cell{x: x, y: y}, true
+}
+
+func parsePoint(v string) (lat, lon float64, err error) {
+	latStr, lonStr, ok := strings.Cut(v, ",")
+	if ok {
+		lat, err = strconv.ParseFloat(latStr, 64)
+	}
+	if ok && err == nil {
+		lon, err = strconv.ParseFloat(lonStr, 64)
+	}
+	if !ok || err != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("invalid point %q", v)
+	}
+	return lat, lon, nil
+}
@@ -0,0 +1,219 @@
+// Package nwstest provides a fake api.weather.gov for tests. A Server answers
+// the /points, gridpoint forecast, hourly forecast, stations, observations,
+// latest observation and active alerts endpoints with synthetic data for any coordinate, and can be
+// scripted to fail: throttling with Retry-After, outages, latency, malformed
+// JSON and out-of-coverage points.
+//
+// Point an nws.Client at Server.URL:
+//
+//	fake := nwstest.NewServer(t)
+//	client := nws.NewClient(fake.URL, "test-agent", fake.Client(), logger)
+//	fake.Inject("/points", nwstest.Unavailable("2", 1))
+package nwstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"weather-service/internal/nws"
+)
+
+// Office is the forecast office id used in every synthetic gridpoint URL.
+const Office = "TST"
+
+// cellsPerDegree sets the synthetic grid resolution (about 2.5 km cells).
+const cellsPerDegree = 40
+
+// Server is a fake NWS API backed by an httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	now       func() time.Time
+	tz        map[cell]string
+	defaultTZ string
+	updated   map[cell]time.Time
+	forecasts map[cell]nws.Forecast
+	alerts    map[cell][]nws.Alert
+	uncovered map[cell]bool
+	faults    []*fault
+	requests  map[string]int
+}
+
+// cell is a synthetic NWS grid cell.
+type cell struct{ x, y int }
+
+func cellOf(lat, lon float64) cell {
+	return cell{x: int(math.Floor((lon + 180) * cellsPerDegree)), y: int(math.Floor((lat + 90) * cellsPerDegree))}
+}
+
+// center returns the coordinates at the middle of c.
+func (c cell) center() (lat, lon float64) {
+	return (float64(c.y)+0.5)/cellsPerDegree - 90, (float64(c.x)+0.5)/cellsPerDegree - 180
+}
+
+// NewServer starts a fake NWS server that is closed when the test ends. Every
+// coordinate is covered, in UTC, with a forecast generated from the current time.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	s := &Server{
+		now:       time.Now,
+		tz:        map[cell]string{},
+		defaultTZ: "UTC",
+		updated:   map[cell]time.Time{},
+		forecasts: map[cell]nws.Forecast{},
+		alerts:    map[cell][]nws.Alert{},
+		uncovered: map[cell]bool{},
+		requests:  map[string]int{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /points/{coords}", s.points)
+	mux.HandleFunc("GET /gridpoints/{office}/{xy}/forecast", s.forecast)
+	mux.HandleFunc("GET /gridpoints/{office}/{xy}/forecast/hourly", s.hourly)
+	mux.HandleFunc("GET /gridpoints/{office}/{xy}/stations", s.stations)
+	mux.HandleFunc("GET /stations/{id}/observations", s.observations)
+	mux.HandleFunc("GET /stations/{id}/observations/latest", s.observation)
+	mux.HandleFunc("GET /alerts/active", s.activeAlerts)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		problem(w, http.StatusNotFound, "Not Found", "no such endpoint "+r.URL.Path)
+	})
+	s.Server = httptest.NewServer(s.middleware(mux))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// SetNow sets the clock used to generate forecasts and observations.
+func (s *Server) SetNow(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetTimeZone sets the IANA time zone reported for the grid cell of lat, lon.
+func (s *Server) SetTimeZone(lat, lon float64, tz string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tz[cellOf(lat, lon)] = tz
+}
+
+// SetDefaultTimeZone sets the time zone of every cell without its own.
+func (s *Server) SetDefaultTimeZone(tz string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultTZ = tz
+}
+
+// SetForecast replaces the synthetic forecast of the grid cell of lat, lon.
+func (s *Server) SetForecast(lat, lon float64, fc nws.Forecast) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forecasts[cellOf(lat, lon)] = fc
+}
+
+// SetUpdated sets the updateTime of the synthetic forecast of the grid cell of
+// lat, lon, so tests can simulate NWS publishing a new forecast.
+func (s *Server) SetUpdated(lat, lon float64, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updated[cellOf(lat, lon)] = t
+}
+
+// AddAlert makes an alert active at the grid cell of lat, lon.
+func (s *Server) AddAlert(lat, lon float64, a nws.Alert) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := cellOf(lat, lon)
+	s.alerts[c] = append(s.alerts[c], a)
+}
+
+// NotCovered makes /points answer 404 for the grid cell of lat, lon, as NWS does
+// for locations outside its coverage.
+func (s *Server) NotCovered(lat, lon float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uncovered[cellOf(lat, lon)] = true
+}
+
+// Requests returns how many requests with a path starting with prefix reached
+// the server, including those answered by an injected fault.
+func (s *Server) Requests(prefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for path, c := range s.requests {
+		if strings.HasPrefix(path, prefix) {
+			n += c
+		}
+	}
+	return n
+}
+
+// PointURL returns the /points URL for lat, lon as nws.Client requests it.
+func (s *Server) PointURL(lat, lon float64) string {
+	return fmt.Sprintf("%s/points/%f,%f", s.URL, lat, lon)
+}
+
+// ForecastURL returns the gridpoint forecast URL for the cell of lat, lon.
+func (s *Server) ForecastURL(lat, lon float64) string {
+	return s.gridURL(cellOf(lat, lon)) + "/forecast"
+}
+
+func (s *Server) gridURL(c cell) string {
+	return fmt.Sprintf("%s/gridpoints/%s/%d,%d", s.URL, Office, c.x, c.y)
+}
+
+// middleware counts requests, applies injected faults and, like NWS, rejects
+// requests without a User-Agent.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		f := s.takeFault(r.URL.Path)
+		s.mu.Unlock()
+
+		if f != nil {
+			if f.latency > 0 {
+				t := time.NewTimer(f.latency)
+				select {
+				case <-t.C:
+				case <-r.Context().Done():
+					t.Stop()
+					return
+				}
+			}
+			if f.status != 0 {
+				f.write(w)
+				return
+			}
+		}
+		if r.Header.Get("User-Agent") == "" {
+			problem(w, http.StatusForbidden, "Forbidden", "a User-Agent header is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/geo+json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// problem writes an NWS style application/problem+json error.
+func problem(w http.ResponseWriter, status int, title, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type":   "https://api.weather.gov/problems/" + strings.ReplaceAll(title, " ", ""),
+		"title":  title,
+		"status": status,
+		"detail": detail,
+	})
+}
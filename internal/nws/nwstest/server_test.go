@@ -0,0 +1,181 @@
+package nwstest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/nws"
+	"weather-service/internal/nws/nwstest"
+)
+
+func newClient(fake *nwstest.Server) *nws.Client {
+	return nws.NewClient(fake.URL, "test-agent", fake.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestSyntheticForecast(t *testing.T) {
+	fake := nwstest.NewServer(t)
+	fake.SetTimeZone(39.7392, -104.9903, "America/Denver")
+	fake.SetNow(func() time.Time { return time.Date(2025, 8, 13, 20, 0, 0, 0, time.UTC) }) // 14:00 in Denver
+	client := newClient(fake)
+	ctx := context.Background()
+
+	pts, err := client.Points(ctx, 39.7392, -104.9903)
+	if err != nil {
+		t.Fatalf("Points: %v", err)
+	}
+	if pts.Properties.TimeZone != "America/Denver" || pts.Properties.Forecast != fake.ForecastURL(39.7392, -104.9903) {
+		t.Fatalf("unexpected points: %+v", pts.Properties)
+	}
+	fc, err := client.Forecast(ctx, pts.Properties.Forecast)
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+	periods := fc.Properties.Periods
+	if len(periods) != 14 || periods[0].Name != "This Afternoon" || !periods[0].IsDaytime || periods[1].Name != "Tonight" {
+		t.Fatalf("unexpected periods: %+v", periods[:2])
+	}
+	if periods[2].Name != "Thursday" || periods[2].StartTime.Format(time.RFC3339) != "2025-08-14T06:00:00-06:00" {
+		t.Fatalf("third period %q at %s", periods[2].Name, periods[2].StartTime)
+	}
+	if periods[1].Temperature >= periods[0].Temperature {
+		t.Fatalf("night %d not cooler than day %d", periods[1].Temperature, periods[0].Temperature)
+	}
+
+	hourly, err := client.Forecast(ctx, pts.Properties.ForecastHourly)
+	if err != nil || len(hourly.Properties.Periods) != 156 {
+		t.Fatalf("hourly: %d periods, err=%v", len(hourly.Properties.Periods), err)
+	}
+}
+
+func TestSetForecastAndUpdated(t *testing.T) {
+	fake := nwstest.NewServer(t)
+	var fc nws.Forecast
+	fc.Properties.Periods = []nws.Period{{Name: "Today", Temperature: 101}}
+	fake.SetForecast(1, 2, fc)
+	updated := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	fake.SetUpdated(3, 4, updated)
+	client := newClient(fake)
+
+	got, err := client.Forecast(context.Background(), fake.ForecastURL(1, 2))
+	if err != nil || len(got.Properties.Periods) != 1 || got.Properties.Periods[0].Temperature != 101 {
+		t.Fatalf("got %+v err=%v", got.Properties, err)
+	}
+	got, err = client.Forecast(context.Background(), fake.ForecastURL(3, 4))
+	if err != nil || !got.Properties.Updated.Equal(updated) {
+		t.Fatalf("updated=%v err=%v", got.Properties.Updated, err)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	fake := nwstest.NewServer(t)
+	client := newClient(fake)
+	ctx := context.Background()
+
+	fake.Inject("/points", nwstest.RateLimited("0", 1))
+	fake.Inject("/points", nwstest.Unavailable("0", 1))
+	if _, err := client.Points(ctx, 1, 2); err != nil {
+		t.Fatalf("retries should succeed: %v", err)
+	}
+	if n := fake.Requests("/points"); n != 3 {
+		t.Fatalf("points requests=%d want 3", n)
+	}
+
+	fake.Inject("/gridpoints", nwstest.Malformed(0))
+	if _, err := client.Forecast(ctx, fake.ForecastURL(1, 2)); err == nil {
+		t.Fatal("want error for malformed JSON")
+	}
+	fake.Reset()
+
+	fake.NotCovered(51.5, -0.12)
+	if _, err := client.Points(ctx, 51.5, -0.12); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err=%v want 404", err)
+	}
+
+	fake.Inject("", nwstest.Slow(time.Second, 0))
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Points(ctx, 1, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err=%v want deadline exceeded", err)
+	}
+}
+
+func TestAlertsAndObservations(t *testing.T) {
+	fake := nwstest.NewServer(t)
+	fake.AddAlert(39.7392, -104.9903, nws.Alert{ID: "a1", Event: "Heat Advisory", Severity: "Moderate"})
+	client := newClient(fake)
+
+	alerts, err := client.Alerts(context.Background(), 39.7392, -104.9903)
+	if err != nil || len(alerts) != 1 || alerts[0].Event != "Heat Advisory" {
+		t.Fatalf("alerts=%+v err=%v", alerts, err)
+	}
+	if alerts, _ = client.Alerts(context.Background(), 10, 10); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts elsewhere: %+v", alerts)
+	}
+
+	var stations struct {
+		ObservationStations []string `json:"observationStations"`
+	}
+	getJSON(t, strings.TrimSuffix(fake.ForecastURL(39.7392, -104.9903), "/forecast")+"/stations", &stations)
+	if len(stations.ObservationStations) != 1 {
+		t.Fatalf("stations=%v", stations.ObservationStations)
+	}
+	var obs struct {
+		Properties struct {
+			Temperature struct {
+				UnitCode string   `json:"unitCode"`
+				Value    *float64 `json:"value"`
+			} `json:"temperature"`
+		} `json:"properties"`
+	}
+	getJSON(t, stations.ObservationStations[0]+"/observations/latest", &obs)
+	if obs.Properties.Temperature.UnitCode != "wmoUnit:degC" || obs.Properties.Temperature.Value == nil {
+		t.Fatalf("observation=%+v", obs.Properties)
+	}
+
+	end := time.Now().Truncate(time.Hour)
+	window, err := client.Observations(context.Background(), stations.ObservationStations[0], end.Add(-6*time.Hour), end)
+	if err != nil || len(window) != 6 || !window[0].Timestamp.Equal(end.Add(-time.Hour)) {
+		t.Fatalf("observations=%+v err=%v", window, err)
+	}
+	if _, ok := window[5].Fahrenheit(); !ok || !window[5].Timestamp.Equal(end.Add(-6*time.Hour)) {
+		t.Fatalf("oldest observation=%+v", window[5])
+	}
+}
+
+func TestRequiresUserAgent(t *testing.T) {
+	fake := nwstest.NewServer(t)
+	req, _ := http.NewRequest(http.MethodGet, fake.PointURL(1, 2), nil)
+	req.Header.Set("User-Agent", "") // suppresses Go's default
+	resp, err := fake.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status=%d want 403", resp.StatusCode)
+	}
+}
+
+func getJSON(t *testing.T, url string, out any) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("User-Agent", "test-agent")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s", url, resp.Status)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatal(err)
+	}
+}
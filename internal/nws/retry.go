@@ -0,0 +1,102 @@
+package nws
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// RetryPolicy controls how the client retries failed requests. Transport
+// errors and the statuses in RetryStatuses are retried; other statuses and
+// undecodable responses fail at once.
+type RetryPolicy struct {
+	MaxAttempts int           // attempts in total, including the first; <= 1 disables retries
+	BaseBackoff time.Duration // backoff ceiling after the first attempt, doubled after each one
+	MaxBackoff  time.Duration // cap on the backoff ceiling; the wait is drawn uniformly below it
+	// Budget caps the total time spent waiting between attempts of one call.
+	Budget time.Duration
+	// MaxRetryAfter is the longest Retry-After honoured. A response asking for
+	// a longer wait is returned to the caller instead.
+	MaxRetryAfter time.Duration
+	RetryStatuses []int
+}
+
+// DefaultRetryPolicy returns the policy NewClient uses: three attempts with
+// 250ms-based full-jitter backoff, retrying 429 and the 5xx statuses NWS
+// answers intermittently.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:   3,
+		BaseBackoff:   250 * time.Millisecond,
+		MaxBackoff:    2 * time.Second,
+		Budget:        8 * time.Second,
+		MaxRetryAfter: 5 * time.Second,
+		RetryStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (p RetryPolicy) retryable(status int) bool {
+	return slices.Contains(p.RetryStatuses, status)
+}
+
+// backoff returns the wait after the given attempt (1-based): full jitter
+// below min(MaxBackoff, BaseBackoff*2^(attempt-1)).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseBackoff
+	for i := 1; i < attempt && ceiling < p.MaxBackoff; i++ {
+		ceiling *= 2
+	}
+	if p.MaxBackoff > 0 {
+		ceiling = min(ceiling, p.MaxBackoff)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// delay returns how long to wait before the attempt after attempt, given the
+// server's Retry-After (0 if absent), the time already waited and the duration
+// of the last attempt, or false when no further attempt should be made: the
+// attempts or budget are used up, the server asked for too long a wait, or the
+// next attempt, assumed to take as long as the last, would not finish before
+// ctx's deadline.
+func (p RetryPolicy) delay(ctx context.Context, attempt int, retryAfter, waited, last time.Duration) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	d := p.backoff(attempt)
+	if retryAfter > 0 {
+		if retryAfter > p.MaxRetryAfter {
+			return 0, false
+		}
+		d = retryAfter
+	}
+	if waited+d > p.Budget {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d+last {
+		return 0, false
+	}
+	return d, true
+}
+
+// sleep waits for d or until ctx is done, returning ctx's error in that case.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
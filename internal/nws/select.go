@@ -1,34 +1,102 @@
 package nws
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // SelectToday chooses the best "today" period from a list of periods.
+// The caller must pass now in the location's own time zone (see LoadLocation);
+// every comparison is made on the local calendar date in that zone, so the
+// answer does not depend on the server's clock zone.
+//
 // Preference order:
-//  1. Exact name match: If any period has Name == "Today", return it immediately.
-//  2. Same local calendar date and daytime: Otherwise, scan daytime periods (IsDaytime == true) and pick the first
-//     whose StartTime has the same local date as “now” interpreted in that period’s time zone/offset.
-//  3. Fallback: If neither 1 nor 2 yields a result, return the first period in the list.
+//  1. Exact name match: a period named "Today" that starts on the local date of now.
+//  2. Same local calendar date and daytime: the first daytime period (IsDaytime == true)
+//     whose StartTime falls on the local date of now.
+//  3. Current period: the first period that has not yet ended (e.g. "Tonight" late in the day).
+//  4. Fallback: the first period in the list.
 func SelectToday(periods []Period, now time.Time) (Period, bool) {
 	if len(periods) == 0 {
 		return Period{}, false
 	}
+	loc := now.Location()
 	for _, p := range periods {
-		if p.Name == "Today" {
+		if p.Name == "Today" && sameDate(p.StartTime.In(loc), now) {
 			return p, true
 		}
 	}
+	if dn, ok := SelectDate(periods, now); ok && dn.Day != nil {
+		return *dn.Day, true
+	}
 	for _, p := range periods {
-		if !p.IsDaytime {
-			continue
-		}
-		locNow := now.In(p.StartTime.Location())
-		if sameDate(p.StartTime, locNow) {
+		if p.EndTime.After(now) {
 			return p, true
 		}
 	}
 	return periods[0], true
 }
 
+// DayNight pairs the daytime and overnight periods of a single local calendar date.
+// Either may be nil: late in the day NWS no longer lists the daytime period
+// ("Tonight" comes first), and the last day of the horizon may lack a night.
+type DayNight struct {
+	Day   *Period
+	Night *Period
+}
+
+// SelectDate picks the daytime and overnight periods for the calendar date of date,
+// interpreted in date's location (which should be the forecast location's zone).
+//
+// The daytime period is the first daytime period starting on that date, whatever its
+// name ("Today", "This Afternoon", "Wednesday"). The overnight period is the last
+// nighttime period starting on that date, so that the evening "Tonight" wins over the
+// early-morning "Overnight" period NWS emits shortly after midnight.
+//
+// It returns false when neither period falls on the date, i.e. the date is outside the
+// forecast horizon.
+func SelectDate(periods []Period, date time.Time) (DayNight, bool) {
+	loc := date.Location()
+	var dn DayNight
+	for i := range periods {
+		p := &periods[i]
+		if !sameDate(p.StartTime.In(loc), date) {
+			continue
+		}
+		if p.IsDaytime {
+			if dn.Day == nil {
+				dn.Day = p
+			}
+			continue
+		}
+		dn.Night = p
+	}
+	return dn, dn.Day != nil || dn.Night != nil
+}
+
+// LoadLocation resolves the IANA time zone name reported by /points (e.g.
+// "America/Anchorage"). When /points omits the name, the UTC offset of ref,
+// normally the first period's start time as NWS published it, stands in for
+// it (without DST rules); only when ref is zero too does it resolve to UTC.
+// Whole-hour offsets resolve to the matching Etc/GMT zone, so the result's
+// name can be loaded again.
+func LoadLocation(tz string, ref time.Time) (*time.Location, error) {
+	if tz != "" {
+		return time.LoadLocation(tz)
+	}
+	if ref.IsZero() {
+		return time.UTC, nil
+	}
+	name, offset := ref.Zone()
+	if offset%3600 == 0 && offset >= -12*3600 && offset <= 14*3600 {
+		// Etc/GMT names invert the sign: Etc/GMT+10 is UTC-10.
+		if loc, err := time.LoadLocation(fmt.Sprintf("Etc/GMT%+d", -offset/3600)); err == nil {
+			return loc, nil
+		}
+	}
+	return time.FixedZone(name, offset), nil
+}
+
 func sameDate(a, b time.Time) bool {
 	ay, am, ad := a.Date()
 	by, bm, bd := b.Date()
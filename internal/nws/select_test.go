@@ -29,3 +29,162 @@ func TestSelectTodayDaytimeSameDate(t *testing.T) {
 		t.Fatalf("expected This Afternoon, got %+v ok=%v", p, ok)
 	}
 }
+
+func mustLoad(t *testing.T, tz string) *time.Location {
+	t.Helper()
+	loc, err := nws.LoadLocation(tz, time.Time{})
+	if err != nil {
+		t.Fatalf("load %s: %v", tz, err)
+	}
+	return loc
+}
+
+// periodsAround builds a NWS-style day/night sequence in loc starting on the given date,
+// mirroring how NWS emits 6am-6pm daytime and 6pm-6am overnight periods.
+func periodsAround(loc *time.Location, y int, m time.Month, d int, firstName string) []nws.Period {
+	names := []string{firstName, "Tonight", "Tomorrow", "Tomorrow Night"}
+	var out []nws.Period
+	start := time.Date(y, m, d, 6, 0, 0, 0, loc)
+	for i, n := range names {
+		day := i%2 == 0
+		s := time.Date(start.Year(), start.Month(), start.Day()+i/2, 6, 0, 0, 0, loc)
+		e := time.Date(s.Year(), s.Month(), s.Day(), 18, 0, 0, 0, loc)
+		if !day {
+			s = e
+			e = time.Date(s.Year(), s.Month(), s.Day()+1, 6, 0, 0, 0, loc)
+		}
+		out = append(out, nws.Period{Name: n, IsDaytime: day, StartTime: s, EndTime: e})
+	}
+	return out
+}
+
+func TestSelectTodayTimeZones(t *testing.T) {
+	cases := []struct {
+		name string
+		tz   string
+		utc  time.Time // server clock in UTC
+		day  int       // local date the forecast periods start on
+		want string
+		date string
+	}{
+		// 05:30Z on the 14th is still 19:30 on the 13th in Honolulu.
+		{"hawaii evening", "Pacific/Honolulu", time.Date(2025, 8, 14, 5, 30, 0, 0, time.UTC), 13, "Tonight", "2025-08-13"},
+		// 07:30Z on the 14th is still 23:30 on the 13th in Anchorage (AKDT, UTC-8).
+		{"alaska late", "America/Anchorage", time.Date(2025, 8, 14, 7, 30, 0, 0, time.UTC), 13, "Tonight", "2025-08-13"},
+		// 20:00Z on the 13th is already 06:00 on the 14th in Guam (UTC+10).
+		{"guam ahead of utc", "Pacific/Guam", time.Date(2025, 8, 13, 20, 0, 0, 0, time.UTC), 14, "Today", "2025-08-14"},
+		// Spring forward: 2025-03-09 in New York jumps from EST to EDT at 02:00.
+		{"dst spring forward", "America/New_York", time.Date(2025, 3, 9, 13, 0, 0, 0, time.UTC), 9, "Today", "2025-03-09"},
+		// Fall back: 2025-11-02 in Los Angeles, 03:30Z on the 3rd is 19:30 PST on the 2nd.
+		{"dst fall back", "America/Los_Angeles", time.Date(2025, 11, 3, 3, 30, 0, 0, time.UTC), 2, "Tonight", "2025-11-02"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			loc := mustLoad(t, c.tz)
+			periods := periodsAround(loc, c.utc.Year(), c.utc.Month(), c.day, "Today")
+			now := c.utc.In(loc)
+			if c.want == "Tonight" {
+				// Late in the day NWS drops the daytime period from the list.
+				periods = periods[1:]
+			}
+			p, ok := nws.SelectToday(periods, now)
+			if !ok || p.Name != c.want {
+				t.Fatalf("got %q ok=%v, want %q", p.Name, ok, c.want)
+			}
+			if got := p.StartTime.In(loc).Format("2006-01-02"); got != c.date {
+				t.Fatalf("date=%s want %s", got, c.date)
+			}
+		})
+	}
+}
+
+func TestSelectTodayIgnoresStaleToday(t *testing.T) {
+	loc := mustLoad(t, "America/Chicago")
+	// Cached forecast from yesterday still leads with yesterday's "Today".
+	periods := periodsAround(loc, 2025, 8, 12, "Today")
+	now := time.Date(2025, 8, 13, 9, 0, 0, 0, loc)
+	p, ok := nws.SelectToday(periods, now)
+	if !ok || p.Name != "Tomorrow" {
+		t.Fatalf("got %q ok=%v, want Tomorrow (the 13th)", p.Name, ok)
+	}
+}
+
+func TestLoadLocationEmpty(t *testing.T) {
+	if loc := mustLoad(t, ""); loc != time.UTC {
+		t.Fatalf("got %v want UTC", loc)
+	}
+	if _, err := nws.LoadLocation("Not/AZone", time.Time{}); err == nil {
+		t.Fatalf("expected error for unknown zone")
+	}
+
+	// Without a zone name the offset of the first period decides the local
+	// date: 20:00 in Honolulu is already tomorrow in UTC.
+	start := time.Date(2025, 8, 13, 18, 0, 0, 0, time.FixedZone("", -10*3600))
+	loc, err := nws.LoadLocation("", start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loc.String() != "Etc/GMT+10" {
+		t.Fatalf("got %v want Etc/GMT+10", loc)
+	}
+	if again := mustLoad(t, loc.String()); again.String() != loc.String() {
+		t.Fatalf("zone name %q does not load again", loc)
+	}
+	now := time.Date(2025, 8, 14, 6, 0, 0, 0, time.UTC).In(loc)
+	periods := []nws.Period{{Name: "Tonight", StartTime: start, EndTime: start.Add(12 * time.Hour)}}
+	if p, ok := nws.SelectToday(periods, now); !ok || p.Name != "Tonight" || now.Day() != 13 {
+		t.Fatalf("got %q on day %d", p.Name, now.Day())
+	}
+
+	odd := time.Date(2025, 8, 13, 6, 0, 0, 0, time.FixedZone("", 5*3600+1800))
+	if loc, err = nws.LoadLocation("", odd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, offset := odd.In(loc).Zone(); offset != 5*3600+1800 {
+		t.Fatalf("half-hour offset lost: %d", offset)
+	}
+}
+
+func TestSelectDate(t *testing.T) {
+	loc := mustLoad(t, "America/Denver")
+	at := func(d, h int) time.Time { return time.Date(2025, 8, d, h, 0, 0, 0, loc) }
+	// Shortly after midnight NWS leads with "Overnight", then "Today" and "Tonight".
+	periods := []nws.Period{
+		{Name: "Overnight", IsDaytime: false, StartTime: at(13, 1), EndTime: at(13, 6)},
+		{Name: "Today", IsDaytime: true, StartTime: at(13, 6), EndTime: at(13, 18)},
+		{Name: "Tonight", IsDaytime: false, StartTime: at(13, 18), EndTime: at(14, 6)},
+		{Name: "Thursday", IsDaytime: true, StartTime: at(14, 6), EndTime: at(14, 18)},
+	}
+
+	dn, ok := nws.SelectDate(periods, at(13, 0))
+	if !ok || dn.Day == nil || dn.Day.Name != "Today" || dn.Night == nil || dn.Night.Name != "Tonight" {
+		t.Fatalf("13th: got %+v ok=%v", dn, ok)
+	}
+
+	dn, ok = nws.SelectDate(periods, at(14, 0))
+	if !ok || dn.Day == nil || dn.Day.Name != "Thursday" || dn.Night != nil {
+		t.Fatalf("14th: got %+v ok=%v", dn, ok)
+	}
+
+	if _, ok = nws.SelectDate(periods, at(20, 0)); ok {
+		t.Fatalf("20th: expected out of horizon")
+	}
+}
+
+func TestSelectDateLateInDay(t *testing.T) {
+	loc := mustLoad(t, "America/Denver")
+	at := func(d, h int) time.Time { return time.Date(2025, 8, d, h, 0, 0, 0, loc) }
+	periods := []nws.Period{
+		{Name: "This Afternoon", IsDaytime: true, StartTime: at(13, 14), EndTime: at(13, 18)},
+		{Name: "Tonight", IsDaytime: false, StartTime: at(13, 18), EndTime: at(14, 6)},
+	}
+	dn, ok := nws.SelectDate(periods, at(13, 15))
+	if !ok || dn.Day == nil || dn.Day.Name != "This Afternoon" || dn.Night == nil {
+		t.Fatalf("got %+v ok=%v", dn, ok)
+	}
+
+	dn, ok = nws.SelectDate(periods[1:], at(13, 20))
+	if !ok || dn.Day != nil || dn.Night == nil || dn.Night.Name != "Tonight" {
+		t.Fatalf("evening: got %+v ok=%v", dn, ok)
+	}
+}
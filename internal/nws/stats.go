@@ -0,0 +1,64 @@
+package nws
+
+import (
+	"sync"
+	"time"
+)
+
+// outcomeCapacity bounds how many recent upstream call outcomes are remembered.
+const outcomeCapacity = 256
+
+// outcomes is a fixed-size ring of recent upstream call results used to report
+// the upstream success rate for readiness checks.
+type outcomes struct {
+	mu   sync.Mutex
+	buf  [outcomeCapacity]outcome
+	next int
+	n    int
+}
+
+type outcome struct {
+	at time.Time
+	ok bool
+}
+
+func (o *outcomes) record(ok bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf[o.next] = outcome{at: time.Now(), ok: ok}
+	o.next = (o.next + 1) % outcomeCapacity
+	if o.n < outcomeCapacity {
+		o.n++
+	}
+}
+
+// rate returns the fraction of successful outcomes recorded since the given time
+// and how many outcomes that fraction is based on.
+func (o *outcomes) rate(since time.Time) (float64, int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var total, ok int
+	for i := 0; i < o.n; i++ {
+		e := o.buf[i]
+		if e.at.Before(since) {
+			continue
+		}
+		total++
+		if e.ok {
+			ok++
+		}
+	}
+	if total == 0 {
+		return 1, 0
+	}
+	return float64(ok) / float64(total), total
+}
+
+// SuccessRate reports the fraction of upstream calls that succeeded within the
+// trailing window, along with the number of calls observed. With no calls in the
+// window the rate is 1. Calls abandoned because the caller's context ended and
+// 4xx answers other than 429 (e.g. out-of-coverage points) are not counted
+// against the upstream.
+func (c *Client) SuccessRate(window time.Duration) (float64, int) {
+	return c.stats.rate(time.Now().Add(-window))
+}
@@ -0,0 +1,179 @@
+package nws
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Transport modes selectable with NWS_MODE.
+const (
+	ModeLive   = "live"
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// ErrNoFixture is returned in replay mode for a request that was never recorded.
+var ErrNoFixture = errors.New("nws replay: no fixture")
+
+// NewTransport returns the RoundTripper for mode: next itself when live, a
+// Recorder saving every successful exchange into dir when recording, and a Replayer
+// serving only from dir when replaying.
+func NewTransport(mode, dir string, next http.RoundTripper) (http.RoundTripper, error) {
+	switch mode {
+	case ModeLive, "":
+		return next, nil
+	case ModeRecord:
+		return &Recorder{Dir: dir, Next: next}, nil
+	case ModeReplay:
+		return &Replayer{Dir: dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown NWS mode %q", mode)
+	}
+}
+
+// fixture is the on-disk form of one recorded exchange.
+type fixture struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status int         `json:"status"`
+		Header http.Header `json:"header"`
+		Body   string      `json:"body"`
+	} `json:"response"`
+}
+
+// Recorder is an http.RoundTripper that forwards requests to Next and saves each
+// request and 200 response (status, headers and body) as a fixture file in Dir.
+// Other responses are passed through unrecorded, so a throttled or failing
+// upstream never ends up in the fixtures. Recording the same request again
+// overwrites its fixture.
+type Recorder struct {
+	Dir  string
+	Next http.RoundTripper
+}
+
+// RoundTrip forwards req and records a successful exchange before returning
+// the response.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := r.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var fx fixture
+	fx.Request.Method, fx.Request.URL = req.Method, req.URL.String()
+	fx.Response.Status, fx.Response.Header, fx.Response.Body = resp.StatusCode, resp.Header, string(body)
+	if err = writeFixture(filepath.Join(r.Dir, FixtureName(req)), fx); err != nil {
+		return nil, fmt.Errorf("nws record: %w", err)
+	}
+	return resp, nil
+}
+
+// Replayer is an http.RoundTripper that answers only from fixtures in Dir. A
+// request without a fixture fails with ErrNoFixture naming the missing file, and
+// never reaches the network.
+type Replayer struct {
+	Dir string
+}
+
+// RoundTrip serves req from its fixture.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := FixtureName(req)
+	b, err := os.ReadFile(filepath.Join(r.Dir, name)) //nolint:gosec // name is a sanitised fixture key
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s (expected %s in %s)", ErrNoFixture, req.Method, req.URL, name, r.Dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("nws replay: %w", err)
+	}
+	var fx fixture
+	if err = json.Unmarshal(b, &fx); err != nil {
+		return nil, fmt.Errorf("nws replay: decode %s: %w", name, err)
+	}
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fx.Response.Status, http.StatusText(fx.Response.Status)),
+		StatusCode:    fx.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        fx.Response.Header,
+		Body:          io.NopCloser(strings.NewReader(fx.Response.Body)),
+		ContentLength: int64(len(fx.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// FixtureName is the normalised fixture file name of req. It depends only on the
+// method, path and sorted query, not on the scheme or host, so fixtures recorded
+// against api.weather.gov replay against any base URL. The readable prefix is
+// followed by a hash of the full key to keep names unique.
+func FixtureName(req *http.Request) string {
+	path := strings.TrimRight(req.URL.EscapedPath(), "/")
+	key := req.Method + " " + path
+	if q := req.URL.Query(); len(q) > 0 {
+		key += "?" + q.Encode() // Encode sorts by key
+	}
+	sum := sha256.Sum256([]byte(key))
+
+	readable := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == ',':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimPrefix(path, "/"))
+	const maxReadable = 80
+	if len(readable) > maxReadable {
+		readable = readable[:maxReadable]
+	}
+	return fmt.Sprintf("%s_%s_%s.json", req.Method, readable, hex.EncodeToString(sum[:4]))
+}
+
+// writeFixture writes fx to path atomically.
+func writeFixture(path string, fx fixture) error {
+	b, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".fixture-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(append(b, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
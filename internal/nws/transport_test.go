@@ -0,0 +1,92 @@
+package nws_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"weather-service/internal/nws"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/geo+json")
+		w.Header().Set("X-Upstream", "nws")
+		if r.URL.Path != "/points/39.739200,-104.990300" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"title":"Not Found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"properties":{"forecast":"https://api.weather.gov/gridpoints/BOU/62,61/forecast","timeZone":"America/Denver"}}`))
+	}))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	rt, err := nws.NewTransport(nws.ModeRecord, dir, srv.Client().Transport)
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	rec := nws.NewClient(srv.URL, "test-agent", &http.Client{Transport: rt}, logger)
+	ctx := context.Background()
+	if _, err = rec.Points(ctx, 39.7392, -104.9903); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	_, _ = rec.Points(ctx, 1, 2) // errors are not recorded
+	srv.Close()
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("recorded %d fixtures want 1 (the 200)", len(entries))
+	}
+
+	// Replay against a different base URL: fixtures are keyed without the host.
+	rt, err = nws.NewTransport(nws.ModeReplay, dir, nil)
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	client := &http.Client{Transport: rt}
+	rep := nws.NewClient("https://api.weather.gov", "test-agent", client, logger)
+	pts, err := rep.Points(ctx, 39.7392, -104.9903)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if pts.Properties.TimeZone != "America/Denver" {
+		t.Fatalf("replayed timeZone=%q", pts.Properties.TimeZone)
+	}
+
+	resp, err := client.Get("https://example.com/points/39.739200,-104.990300/")
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Upstream") != "nws" {
+		t.Fatalf("replayed status=%d header=%v", resp.StatusCode, resp.Header)
+	}
+
+	for _, u := range []string{"https://api.weather.gov/points/1.000000,2.000000", "https://api.weather.gov/points/5.000000,6.000000"} {
+		if _, err = client.Get(u); !errors.Is(err, nws.ErrNoFixture) {
+			t.Fatalf("%s: err=%v want ErrNoFixture", u, err)
+		}
+	}
+}
+
+func TestFixtureNameNormalised(t *testing.T) {
+	a := httptest.NewRequest(http.MethodGet, "https://api.weather.gov/alerts/active?point=1,2&status=actual", nil)
+	b := httptest.NewRequest(http.MethodGet, "http://localhost:8081/alerts/active/?status=actual&point=1,2", nil)
+	if nws.FixtureName(a) != nws.FixtureName(b) {
+		t.Fatalf("names differ: %s vs %s", nws.FixtureName(a), nws.FixtureName(b))
+	}
+	c := httptest.NewRequest(http.MethodGet, "https://api.weather.gov/alerts/active?point=1,3", nil)
+	if nws.FixtureName(a) == nws.FixtureName(c) {
+		t.Fatalf("different queries share fixture %s", nws.FixtureName(a))
+	}
+}
+
+func TestNewTransportUnknownMode(t *testing.T) {
+	if _, err := nws.NewTransport("mock", t.TempDir(), nil); err == nil {
+		t.Fatal("want error for unknown mode")
+	}
+}
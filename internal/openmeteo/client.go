@@ -0,0 +1,228 @@
+// Package openmeteo is a forecast.Provider backed by the Open-Meteo forecast API
+// (https://open-meteo.com), which covers the whole globe without an API key. Its
+// daily highs, lows and weather codes are converted into NWS style day and night
+// periods.
+package openmeteo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+)
+
+// Source is the provider name reported in forecast results.
+const Source = "open-meteo.com"
+
+const (
+	clientTimeout = 5 * time.Second
+	forecastDays  = 7
+	// maxRevisions bounds how many forecast URLs the client remembers the
+	// content of; an arbitrary one is forgotten to make room.
+	maxRevisions = 4096
+)
+
+// Client wraps access to the Open-Meteo forecast API.
+type Client struct {
+	base   string
+	http   *http.Client
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	revisions map[string]revision
+}
+
+// revision is the content of a forecast URL as last seen, and when it changed.
+type revision struct {
+	sum     [sha256.Size]byte
+	updated time.Time
+}
+
+// NewClient constructs a new Open-Meteo API client.
+func NewClient(baseURL string, httpClient *http.Client, logger *slog.Logger) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: clientTimeout}
+	}
+	return &Client{
+		base:      strings.TrimRight(baseURL, "/"),
+		http:      httpClient,
+		logger:    logger,
+		now:       time.Now,
+		revisions: make(map[string]revision),
+	}
+}
+
+// Name implements forecast.Provider.
+func (c *Client) Name() string { return Source }
+
+// response is the subset of the /v1/forecast response the client uses.
+type response struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+	Daily     struct {
+		Time        []string  `json:"time"`
+		WeatherCode []int     `json:"weather_code"`
+		Max         []float64 `json:"temperature_2m_max"`
+		Min         []float64 `json:"temperature_2m_min"`
+	} `json:"daily"`
+}
+
+// Point resolves the model grid point and time zone serving lat, lon. The
+// forecast URL is built from the grid point, so nearby coordinates share it.
+func (c *Client) Point(ctx context.Context, lat, lon float64) (forecast.Point, error) {
+	q := url.Values{
+		"latitude":      {strconv.FormatFloat(lat, 'f', 4, 64)},
+		"longitude":     {strconv.FormatFloat(lon, 'f', 4, 64)},
+		"timezone":      {"auto"},
+		"forecast_days": {"1"},
+	}
+	var r response
+	if err := c.getJSON(ctx, c.base+"/v1/forecast?"+q.Encode(), &r); err != nil {
+		return forecast.Point{}, err
+	}
+	if r.Timezone == "" {
+		return forecast.Point{}, errors.New("open-meteo: no time zone for point")
+	}
+	q = url.Values{
+		"latitude":         {strconv.FormatFloat(r.Latitude, 'f', -1, 64)},
+		"longitude":        {strconv.FormatFloat(r.Longitude, 'f', -1, 64)},
+		"daily":            {"weather_code,temperature_2m_max,temperature_2m_min"},
+		"temperature_unit": {"fahrenheit"},
+		"timezone":         {r.Timezone},
+		"forecast_days":    {strconv.Itoa(forecastDays)},
+	}
+	return forecast.Point{ForecastURL: c.base + "/v1/forecast?" + q.Encode(), TimeZone: r.Timezone}, nil
+}
+
+// updated returns when the forecast at forecastURL last changed. The hash
+// covers the time zone and daily series only: the rest of the response, such
+// as generationtime_ms, differs on every call.
+func (c *Client) updated(forecastURL string, r response) time.Time {
+	b, _ := json.Marshal(struct {
+		Timezone string
+		Daily    any
+	}{r.Timezone, r.Daily})
+	sum := sha256.Sum256(b)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rev, ok := c.revisions[forecastURL]; ok && rev.sum == sum {
+		return rev.updated
+	}
+	if len(c.revisions) >= maxRevisions {
+		for k := range c.revisions {
+			delete(c.revisions, k)
+			break
+		}
+	}
+	now := c.now().UTC().Truncate(time.Second)
+	c.revisions[forecastURL] = revision{sum: sum, updated: now}
+	return now
+}
+
+// Hourly always fails: points from Open-Meteo have no HourlyURL.
+func (c *Client) Hourly(context.Context, string) (nws.Forecast, error) {
+	return nws.Forecast{}, forecast.ErrNoHourly
+}
+
+// Forecast fetches the daily forecast at forecastURL and converts each day into a
+// daytime period (06:00-18:00, the high) and an overnight one (18:00-06:00, the
+// low). Open-Meteo does not publish an update time; the time the client first
+// saw the current daily series stands in for it, so refetching an unchanged
+// forecast keeps its update time.
+func (c *Client) Forecast(ctx context.Context, forecastURL string) (nws.Forecast, error) {
+	var r response
+	if err := c.getJSON(ctx, forecastURL, &r); err != nil {
+		return nws.Forecast{}, err
+	}
+	loc, err := nws.LoadLocation(r.Timezone, time.Time{})
+	if err != nil {
+		return nws.Forecast{}, fmt.Errorf("open-meteo: load time zone %q: %w", r.Timezone, err)
+	}
+	d := r.Daily
+	if len(d.WeatherCode) != len(d.Time) || len(d.Max) != len(d.Time) || len(d.Min) != len(d.Time) {
+		return nws.Forecast{}, errors.New("open-meteo: daily series differ in length")
+	}
+
+	var fc nws.Forecast
+	fc.Properties.Updated = c.updated(forecastURL, r)
+	fc.Properties.Units = "us"
+	for i, day := range d.Time {
+		date, err := time.ParseInLocation(time.DateOnly, day, loc)
+		if err != nil {
+			return nws.Forecast{}, fmt.Errorf("open-meteo: bad date %q: %w", day, err)
+		}
+		y, m, dd := date.Date()
+		morning := time.Date(y, m, dd, 6, 0, 0, 0, loc)
+		evening := time.Date(y, m, dd, 18, 0, 0, 0, loc)
+		next := time.Date(y, m, dd+1, 6, 0, 0, 0, loc)
+		dayName, nightName := date.Weekday().String(), date.Weekday().String()+" Night"
+		if i == 0 {
+			dayName, nightName = "Today", "Tonight"
+		}
+		fc.Properties.Periods = append(fc.Properties.Periods,
+			period(dayName, morning, evening, true, d.Max[i], d.WeatherCode[i]),
+			period(nightName, evening, next, false, d.Min[i], d.WeatherCode[i]))
+	}
+	return fc, nil
+}
+
+func period(name string, start, end time.Time, daytime bool, temp float64, code int) nws.Period {
+	return nws.Period{
+		Name:            name,
+		StartTime:       start,
+		EndTime:         end,
+		IsDaytime:       daytime,
+		Temperature:     int(math.Round(temp)),
+		TemperatureUnit: "F",
+		ShortForecast:   describe(code, daytime),
+	}
+}
+
+// getJSON performs a GET request and decodes the JSON response into out. Error
+// responses carry a "reason", which is returned in the error.
+func (c *Client) getJSON(ctx context.Context, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			c.logger.ErrorContext(ctx, "Error closing open-meteo response body")
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Reason string `json:"reason"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Reason != "" {
+			return fmt.Errorf("open-meteo http %d: %s", resp.StatusCode, e.Reason)
+		}
+		return fmt.Errorf("open-meteo http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
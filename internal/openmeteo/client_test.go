@@ -0,0 +1,120 @@
+package openmeteo_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/openmeteo"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) *openmeteo.Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return openmeteo.NewClient(srv.URL, srv.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestPointAndForecast(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/v1/forecast" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if q.Get("daily") == "" {
+			if q.Get("latitude") != "48.8566" || q.Get("timezone") != "auto" {
+				t.Errorf("unexpected point query %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`{"latitude":48.86,"longitude":2.3399997,"timezone":"Europe/Paris"}`))
+			return
+		}
+		if q.Get("latitude") != "48.86" || q.Get("temperature_unit") != "fahrenheit" || q.Get("timezone") != "Europe/Paris" {
+			t.Errorf("unexpected forecast query %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"timezone":"Europe/Paris","daily":{"time":["2025-08-13","2025-08-14"],
+			"weather_code":[0,61],"temperature_2m_max":[88.6,70.1],"temperature_2m_min":[61.4,-0.6]}}`))
+	})
+	ctx := context.Background()
+
+	pt, err := c.Point(ctx, 48.8566, 2.3522)
+	if err != nil {
+		t.Fatalf("Point: %v", err)
+	}
+	if pt.TimeZone != "Europe/Paris" || !strings.Contains(pt.ForecastURL, "latitude=48.86") {
+		t.Fatalf("unexpected point %+v", pt)
+	}
+	fc, err := c.Forecast(ctx, pt.ForecastURL)
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+	p := fc.Properties.Periods
+	if len(p) != 4 {
+		t.Fatalf("got %d periods, want 4", len(p))
+	}
+	if p[0].Name != "Today" || !p[0].IsDaytime || p[0].Temperature != 89 || p[0].ShortForecast != "Sunny" {
+		t.Fatalf("unexpected day: %+v", p[0])
+	}
+	if p[1].Name != "Tonight" || p[1].IsDaytime || p[1].Temperature != 61 || p[1].ShortForecast != "Clear" {
+		t.Fatalf("unexpected night: %+v", p[1])
+	}
+	if p[2].Name != "Thursday" || p[3].Name != "Thursday Night" || p[3].Temperature != -1 || p[2].ShortForecast != "Rain" {
+		t.Fatalf("unexpected second day: %+v %+v", p[2], p[3])
+	}
+	if got := p[1].EndTime.Format(time.RFC3339); got != "2025-08-14T06:00:00+02:00" {
+		t.Fatalf("night ends %s", got)
+	}
+}
+
+func TestErrorReason(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":true,"reason":"Latitude must be in range of -90 to 90°."}`))
+	})
+	_, err := c.Point(context.Background(), 1, 2)
+	if err == nil || !strings.Contains(err.Error(), "Latitude must be in range") {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestForecastUpdatedFollowsContent(t *testing.T) {
+	var calls int
+	highs := "88.6"
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		// generationtime_ms differs on every call and must not count as a change.
+		_, _ = fmt.Fprintf(w, `{"generationtime_ms":%d,"timezone":"Europe/Paris","daily":{"time":["2025-08-13"],
+			"weather_code":[0],"temperature_2m_max":[%s],"temperature_2m_min":[61.4]}}`, calls, highs)
+	})
+	now := time.Date(2025, 8, 13, 9, 15, 30, 0, time.UTC)
+	openmeteo.SetNow(c, func() time.Time { return now })
+	ctx := context.Background()
+	pt, err := c.Point(ctx, 48.86, 2.34)
+	if err != nil {
+		t.Fatalf("Point: %v", err)
+	}
+	u := pt.ForecastURL
+
+	first, err := c.Forecast(ctx, u)
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+	if !first.Properties.Updated.Equal(now) {
+		t.Fatalf("updated=%v want first fetch time %v", first.Properties.Updated, now)
+	}
+	now = now.Add(2 * time.Hour)
+	again, _ := c.Forecast(ctx, u)
+	if !again.Properties.Updated.Equal(first.Properties.Updated) {
+		t.Fatalf("unchanged forecast: updated=%v want %v", again.Properties.Updated, first.Properties.Updated)
+	}
+	highs = "90.1"
+	changed, _ := c.Forecast(ctx, u)
+	if !changed.Properties.Updated.Equal(now) {
+		t.Fatalf("changed forecast: updated=%v want %v", changed.Properties.Updated, now)
+	}
+}
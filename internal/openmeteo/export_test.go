@@ -0,0 +1,8 @@
+package openmeteo
+
+import "time"
+
+// SetNow replaces the clock c uses for update times.
+func SetNow(c *Client, now func() time.Time) {
+	c.now = now
+}
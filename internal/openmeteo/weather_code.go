@@ -0,0 +1,50 @@
+package openmeteo
+
+// describe turns a WMO weather interpretation code into an NWS style short
+// forecast.
+func describe(code int, daytime bool) string {
+	switch code {
+	case 0:
+		if daytime {
+			return "Sunny"
+		}
+		return "Clear"
+	case 1:
+		if daytime {
+			return "Mostly Sunny"
+		}
+		return "Mostly Clear"
+	case 2:
+		return "Partly Cloudy"
+	case 3:
+		return "Cloudy"
+	case 45, 48:
+		return "Fog"
+	case 51, 53, 55:
+		return "Drizzle"
+	case 56, 57:
+		return "Freezing Drizzle"
+	case 61, 63:
+		return "Rain"
+	case 65:
+		return "Heavy Rain"
+	case 66, 67:
+		return "Freezing Rain"
+	case 71, 73:
+		return "Snow"
+	case 75:
+		return "Heavy Snow"
+	case 77:
+		return "Snow Grains"
+	case 80, 81, 82:
+		return "Rain Showers"
+	case 85, 86:
+		return "Snow Showers"
+	case 95:
+		return "Thunderstorms"
+	case 96, 99:
+		return "Thunderstorms With Hail"
+	default:
+		return "Unknown"
+	}
+}
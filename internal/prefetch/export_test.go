@@ -0,0 +1,13 @@
+package prefetch
+
+import "time"
+
+// SetTrackerNow overrides the clock of a Tracker.
+func SetTrackerNow(t *Tracker, now func() time.Time) {
+	t.now = now
+}
+
+// SetNow overrides the clock of a Prefetcher.
+func SetNow(p *Prefetcher, now func() time.Time) {
+	p.now = now
+}
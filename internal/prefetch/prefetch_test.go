@@ -0,0 +1,153 @@
+package prefetch_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/prefetch"
+)
+
+func TestParseLocations(t *testing.T) {
+	locs, err := prefetch.ParseLocations(" 39.73924,-104.9903 ; 47.6062, -122.3321;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []prefetch.Location{{Lat: 39.7392, Lon: -104.9903}, {Lat: 47.6062, Lon: -122.3321}}
+	if !slices.Equal(locs, want) {
+		t.Fatalf("locations=%v want %v", locs, want)
+	}
+	for _, bad := range []string{"39.7", "91,0", "1,x"} {
+		if _, err = prefetch.ParseLocations(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestTrackerTop(t *testing.T) {
+	now := time.Date(2025, 8, 13, 18, 0, 0, 0, time.UTC)
+	tr := prefetch.NewTracker()
+	prefetch.SetTrackerNow(tr, func() time.Time { return now })
+	record := func(lat, lon float64, n int) {
+		for range n {
+			tr.Record(lat, lon)
+		}
+	}
+	record(39.7392, -104.9903, 8)
+	record(47.6062, -122.3321, 3)
+	record(40.0150, -105.2705, 1) // one-off
+
+	top := tr.Top(5)
+	if want := []prefetch.Location{{Lat: 39.7392, Lon: -104.9903}, {Lat: 47.6062, Lon: -122.3321}}; !slices.Equal(top, want) {
+		t.Fatalf("top=%v want %v", top, want)
+	}
+	if top = tr.Top(1); len(top) != 1 || top[0].Lat != 39.7392 {
+		t.Fatalf("top(1)=%v", top)
+	}
+
+	// Overnight, yesterday's 3 requests decay below the threshold while the
+	// busiest location stays hot; fresh demand outranks it.
+	now = now.Add(12 * time.Hour)
+	record(40.0150, -105.2705, 4)
+	if want := []prefetch.Location{{Lat: 40.0150, Lon: -105.2705}, {Lat: 39.7392, Lon: -104.9903}}; !slices.Equal(tr.Top(5), want) {
+		t.Fatalf("top=%v want %v", tr.Top(5), want)
+	}
+}
+
+func TestTrackCountsRequests(t *testing.T) {
+	tr := prefetch.NewTracker()
+	svc := prefetch.Track(&fakeSvc{}, tr)
+	ctx := context.Background()
+	_, _ = svc.GetTodaysForcast(ctx, 1, 2)
+	_, _ = svc.GetDailyForecast(ctx, 1, 2, time.Now())
+	_, _ = svc.GetPeriods(ctx, 1, 2)
+	_ = svc.Purge(3, 4)
+	if top := tr.Top(5); len(top) != 1 || top[0] != (prefetch.Location{Lat: 1, Lon: 2}) {
+		t.Fatalf("top=%v", top)
+	}
+}
+
+// fakeSvc answers Prefetch with an expiry ttl from now, or err.
+type fakeSvc struct {
+	forecast.Internal
+	now   func() time.Time
+	ttl   time.Duration
+	err   error
+	calls []prefetch.Location
+}
+
+func (f *fakeSvc) GetTodaysForcast(context.Context, float64, float64) (forecast.Result, error) {
+	return forecast.Result{}, nil
+}
+
+func (f *fakeSvc) GetDailyForecast(context.Context, float64, float64, time.Time) (forecast.DailyResult, error) {
+	return forecast.DailyResult{}, nil
+}
+
+func (f *fakeSvc) GetPeriods(context.Context, float64, float64) (forecast.PeriodsResult, error) {
+	return forecast.PeriodsResult{}, nil
+}
+
+func (f *fakeSvc) Purge(float64, float64) int { return 0 }
+
+func (f *fakeSvc) Prefetch(_ context.Context, lat, lon float64, _ time.Duration) (time.Time, error) {
+	f.calls = append(f.calls, prefetch.Location{Lat: lat, Lon: lon})
+	return f.now().Add(f.ttl), f.err
+}
+
+func TestPrefetcherSchedule(t *testing.T) {
+	now := time.Date(2025, 8, 13, 18, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	svc := &fakeSvc{now: clock, ttl: 10 * time.Minute}
+	depot := prefetch.Location{Lat: 39.7392, Lon: -104.9903}
+	tr := prefetch.NewTracker()
+	prefetch.SetTrackerNow(tr, clock)
+	p := prefetch.New(svc, tr, prefetch.Options{Locations: []prefetch.Location{depot}, TopN: 1, Lead: time.Minute},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	prefetch.SetNow(p, clock)
+	ctx := context.Background()
+
+	// New targets are spread over the next Lead.
+	if n := p.Prefetch(ctx); n != 0 {
+		t.Fatalf("prefetched %d on first sight", n)
+	}
+	now = now.Add(time.Minute)
+	if n := p.Prefetch(ctx); n != 1 || !slices.Equal(svc.calls, []prefetch.Location{depot}) {
+		t.Fatalf("n=%d calls=%v", n, svc.calls)
+	}
+
+	// The next refresh falls in the first half of the last minute before expiry.
+	now = now.Add(8*time.Minute + 59*time.Second)
+	if n := p.Prefetch(ctx); n != 0 {
+		t.Fatalf("refreshed %d entries before the lead", n)
+	}
+	now = now.Add(31 * time.Second)
+	if n := p.Prefetch(ctx); n != 1 {
+		t.Fatalf("n=%d in the lead window", n)
+	}
+
+	// Learned locations join the targets; failures back off.
+	for range 3 {
+		tr.Record(47.6062, -122.3321)
+	}
+	svc.err = errors.New("upstream down")
+	p.Prefetch(ctx)
+	now = now.Add(time.Minute)
+	svc.calls = nil
+	if n := p.Prefetch(ctx); n != 0 || len(svc.calls) != 1 || svc.calls[0].Lat != 47.6062 {
+		t.Fatalf("n=%d calls=%v", n, svc.calls)
+	}
+	if got := p.Targets(); len(got) != 2 || got[0] != depot || got[1].Lat != 47.6062 {
+		t.Fatalf("targets=%v", got)
+	}
+	svc.calls = nil
+	now = now.Add(30 * time.Second)
+	if p.Prefetch(ctx); len(svc.calls) != 0 {
+		t.Fatalf("failed location retried after 30s: %v", svc.calls)
+	}
+}
@@ -0,0 +1,162 @@
+package prefetch
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"weather-service/internal/forecast"
+)
+
+const (
+	// checkInterval is how often Run looks for locations due for a refresh.
+	checkInterval = 5 * time.Second
+	// retryDelay is how long a location waits after a failed refresh.
+	retryDelay = time.Minute
+	// refreshTimeout bounds one refresh, including the time spent yielding to
+	// live requests.
+	refreshTimeout = 30 * time.Second
+)
+
+// Options configures a Prefetcher.
+type Options struct {
+	Locations []Location    // kept warm at all times
+	TopN      int           // the most requested locations also kept warm; 0 disables learning
+	Lead      time.Duration // how long before expiry cached entries are refreshed
+}
+
+// Prefetcher refreshes the configured locations and the tracker's top ones
+// through forecast.Internal.Prefetch, each once per cache lifetime, in the last
+// Lead before its entries expire. Refresh times are jittered so locations
+// cached together do not refresh in one burst.
+type Prefetcher struct {
+	svc     forecast.Internal
+	tracker *Tracker
+	opts    Options
+	logger  *slog.Logger
+	now     func() time.Time
+
+	due map[Location]time.Time // next refresh per target
+}
+
+// New constructs a Prefetcher. tracker may be nil when opts.TopN is 0.
+func New(svc forecast.Internal, tracker *Tracker, opts Options, logger *slog.Logger) *Prefetcher {
+	return &Prefetcher{
+		svc:     svc,
+		tracker: tracker,
+		opts:    opts,
+		logger:  logger,
+		now:     time.Now,
+		due:     make(map[Location]time.Time),
+	}
+}
+
+// Run refreshes due locations until ctx is done.
+func (p *Prefetcher) Run(ctx context.Context) {
+	t := time.NewTicker(checkInterval)
+	defer t.Stop()
+	for {
+		p.Prefetch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Prefetch refreshes, one after the other, every target location that is due
+// and returns how many refreshes succeeded. A new target is scheduled within
+// the next Lead, so a burst of them at start-up is spread out.
+func (p *Prefetcher) Prefetch(ctx context.Context) int {
+	targets := p.targets()
+	for loc := range p.due {
+		if _, ok := targets[loc]; !ok {
+			delete(p.due, loc)
+		}
+	}
+
+	n := 0
+	for loc := range targets {
+		if ctx.Err() != nil {
+			return n
+		}
+		due, ok := p.due[loc]
+		if !ok {
+			p.due[loc] = p.now().Add(jitter(p.opts.Lead))
+			continue
+		}
+		if p.now().Before(due) {
+			continue
+		}
+		if p.refresh(ctx, loc) {
+			n++
+		}
+	}
+	return n
+}
+
+// refresh prefetches loc and schedules its next refresh.
+func (p *Prefetcher) refresh(ctx context.Context, loc Location) bool {
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+	exp, err := p.svc.Prefetch(ctx, loc.Lat, loc.Lon, p.opts.Lead)
+	now := p.now()
+	if err != nil {
+		p.due[loc] = now.Add(retryDelay + jitter(retryDelay/2))
+		p.logger.WarnContext(ctx, "prefetch failed", "location", loc.String(), "err", err)
+		return false
+	}
+	// Refresh within the first half of the last Lead before expiry, but not
+	// before the entries are half way to expiring when the TTL is short.
+	next := exp.Add(-p.opts.Lead + jitter(p.opts.Lead/2))
+	if half := now.Add(exp.Sub(now) / 2); next.Before(half) {
+		next = half
+	}
+	p.due[loc] = next
+	p.logger.DebugContext(ctx, "prefetched forecast", "location", loc.String(), "expires", exp, "next", next)
+	return true
+}
+
+// Targets returns the locations kept warm, configured ones first. It is safe
+// to call while Run is running.
+func (p *Prefetcher) Targets() []Location {
+	targets := p.targets()
+	out := make([]Location, 0, len(targets))
+	for _, loc := range p.opts.Locations {
+		if _, ok := targets[loc]; ok {
+			out = append(out, loc)
+			delete(targets, loc)
+		}
+	}
+	learned := make([]Location, 0, len(targets))
+	for loc := range targets {
+		learned = append(learned, loc)
+	}
+	sort.Slice(learned, func(i, j int) bool { return learned[i].String() < learned[j].String() })
+	return append(out, learned...)
+}
+
+// targets returns the configured locations and the tracker's top ones.
+func (p *Prefetcher) targets() map[Location]struct{} {
+	targets := make(map[Location]struct{}, len(p.opts.Locations)+p.opts.TopN)
+	for _, loc := range p.opts.Locations {
+		targets[loc] = struct{}{}
+	}
+	if p.tracker != nil && p.opts.TopN > 0 {
+		for _, loc := range p.tracker.Top(p.opts.TopN) {
+			targets[loc] = struct{}{}
+		}
+	}
+	return targets
+}
+
+// jitter returns a random duration in [0, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
@@ -0,0 +1,167 @@
+// Package prefetch keeps forecasts for busy locations warm in the cache by
+// refreshing them shortly before they expire, so the first request after an
+// expiry does not wait for upstream.
+package prefetch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-service/internal/forecast"
+)
+
+const (
+	// halfLife is how quickly past requests stop counting. Six hours carries
+	// yesterday's busy locations through the night into the morning.
+	halfLife = 6 * time.Hour
+	// minHotScore keeps one-off requests out of the top locations.
+	minHotScore = 2
+	// maxTracked bounds the number of locations tracked.
+	maxTracked = 4096
+)
+
+// Location is a pair of coordinates, rounded to the 4 decimals the forecast
+// cache keys use.
+type Location struct {
+	Lat, Lon float64
+}
+
+// At returns the Location of lat/lon.
+func At(lat, lon float64) Location {
+	return Location{Lat: math.Round(lat*1e4) / 1e4, Lon: math.Round(lon*1e4) / 1e4}
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%.4f,%.4f", l.Lat, l.Lon)
+}
+
+// ParseLocations parses semicolon-separated "lat,lon" pairs, such as
+// "39.7392,-104.9903; 47.6062,-122.3321".
+func ParseLocations(s string) ([]Location, error) {
+	var locs []Location
+	for _, pair := range strings.Split(s, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		latStr, lonStr, ok := strings.Cut(pair, ",")
+		if !ok {
+			return nil, fmt.Errorf("location %q is not lat,lon", pair)
+		}
+		lat, err1 := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+		lon, err2 := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+		if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("location %q has invalid coordinates", pair)
+		}
+		locs = append(locs, At(lat, lon))
+	}
+	return locs, nil
+}
+
+// Tracker counts forecast requests per location. Counts decay with a half-life
+// of six hours, so the top locations follow recent demand.
+type Tracker struct {
+	mu     sync.Mutex
+	scores map[Location]score
+	now    func() time.Time
+}
+
+type score struct {
+	v  float64
+	at time.Time
+}
+
+// decayed returns the score as of now.
+func (s score) decayed(now time.Time) float64 {
+	return s.v * math.Exp2(-float64(now.Sub(s.at))/float64(halfLife))
+}
+
+// NewTracker returns an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{scores: make(map[Location]score), now: time.Now}
+}
+
+// Record counts a request for lat/lon.
+func (t *Tracker) Record(lat, lon float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	loc := At(lat, lon)
+	s, ok := t.scores[loc]
+	if !ok && len(t.scores) >= maxTracked {
+		t.evictLowest(now)
+	}
+	t.scores[loc] = score{v: s.decayed(now) + 1, at: now}
+}
+
+// evictLowest removes the location with the lowest score. t.mu must be held.
+func (t *Tracker) evictLowest(now time.Time) {
+	var lowest Location
+	low := math.Inf(1)
+	for loc, s := range t.scores {
+		if v := s.decayed(now); v < low {
+			lowest, low = loc, v
+		}
+	}
+	delete(t.scores, lowest)
+}
+
+// Top returns up to n locations with the highest scores, highest first.
+// Locations requested only about once recently are left out.
+func (t *Tracker) Top(n int) []Location {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	type ranked struct {
+		loc Location
+		v   float64
+	}
+	var hot []ranked
+	for loc, s := range t.scores {
+		if v := s.decayed(now); v >= minHotScore {
+			hot = append(hot, ranked{loc, v})
+		}
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		if hot[i].v != hot[j].v {
+			return hot[i].v > hot[j].v
+		}
+		return hot[i].loc.String() < hot[j].loc.String()
+	})
+	top := make([]Location, 0, min(n, len(hot)))
+	for _, r := range hot[:min(n, len(hot))] {
+		top = append(top, r.loc)
+	}
+	return top
+}
+
+// Track returns svc with the forecast requests it serves counted by t.
+func Track(svc forecast.Service, t *Tracker) forecast.Service {
+	return tracked{Service: svc, t: t}
+}
+
+type tracked struct {
+	forecast.Service
+	t *Tracker
+}
+
+func (s tracked) GetTodaysForcast(ctx context.Context, lat, lon float64) (forecast.Result, error) {
+	s.t.Record(lat, lon)
+	return s.Service.GetTodaysForcast(ctx, lat, lon)
+}
+
+func (s tracked) GetDailyForecast(ctx context.Context, lat, lon float64, date time.Time) (forecast.DailyResult, error) {
+	s.t.Record(lat, lon)
+	return s.Service.GetDailyForecast(ctx, lat, lon, date)
+}
+
+func (s tracked) GetPeriods(ctx context.Context, lat, lon float64) (forecast.PeriodsResult, error) {
+	s.t.Record(lat, lon)
+	return s.Service.GetPeriods(ctx, lat, lon)
+}
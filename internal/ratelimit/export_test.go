@@ -0,0 +1,18 @@
+package ratelimit
+
+import "time"
+
+// SetNow replaces the clock l refills buckets by.
+func SetNow(l *Limiter, now func() time.Time) {
+	l.now = now
+}
+
+// Buckets returns how many callers l tracks.
+func Buckets(l *Limiter) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// MaxBuckets is the most callers a Limiter tracks.
+const MaxBuckets = maxBuckets
@@ -0,0 +1,131 @@
+// Package ratelimit limits how often each caller may call the service, with a
+// token bucket per caller.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	logpkg "weather-service/internal/log"
+)
+
+// maxBuckets is the most callers tracked at once. Beyond it, buckets that have
+// refilled completely, and so limit nothing, are dropped first, then the least
+// recently used tenth.
+const maxBuckets = 10000
+
+// Limiter allows each caller rate requests per second on average, in bursts of
+// up to burst. A rate of 0, or a nil *Limiter, allows everything. It is safe
+// for concurrent use.
+type Limiter struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// New returns a Limiter allowing rate requests per second and bursts of burst
+// per caller. A rate of 0 allows everything until SetRate sets one.
+func New(rate, burst int) *Limiter {
+	l := &Limiter{now: time.Now, buckets: make(map[string]*bucket)}
+	l.SetRate(rate, burst)
+	return l
+}
+
+// SetRate changes the rate and burst for every caller. Buckets keep the tokens
+// they had, up to the new burst; a rate of 0 disables limiting.
+func (l *Limiter) SetRate(rate, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rate <= 0 {
+		l.rate, l.burst = 0, 0
+		clear(l.buckets)
+		return
+	}
+	now := l.now()
+	for _, b := range l.buckets {
+		l.refill(b, now)
+	}
+	l.rate, l.burst = float64(rate), float64(max(burst, 1))
+	for _, b := range l.buckets {
+		b.tokens = math.Min(l.burst, b.tokens)
+	}
+}
+
+// Key identifies the caller of a request: the caller identity from ctx (see
+// logpkg.WithCaller), or else the host of the client address addr.
+func Key(ctx context.Context, addr string) string {
+	if caller := logpkg.Caller(ctx); caller != "" {
+		return "caller:" + caller
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return "addr:" + addr
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it returns
+// false and how long until the next token.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate == 0 {
+		return true, 0
+	}
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.evict(now)
+		}
+		b = &bucket{tokens: l.burst, at: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// refill adds the tokens b earned since it was last refilled. l.mu must be held.
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.at).Seconds()*l.rate)
+	b.at = now
+}
+
+// evict drops the buckets that are full by now and, when that leaves the map
+// at maxBuckets, the least recently used tenth. l.mu must be held.
+func (l *Limiter) evict(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.at).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	if len(l.buckets) < maxBuckets {
+		return
+	}
+	keys := make([]string, 0, len(l.buckets))
+	for key := range l.buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return l.buckets[keys[i]].at.Before(l.buckets[keys[j]].at) })
+	for _, key := range keys[:len(keys)/10] {
+		delete(l.buckets, key)
+	}
+}
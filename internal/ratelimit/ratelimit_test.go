@@ -0,0 +1,131 @@
+package ratelimit_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	logpkg "weather-service/internal/log"
+	"weather-service/internal/ratelimit"
+)
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := ratelimit.New(2, 3)
+	ratelimit.SetNow(l, func() time.Time { return now })
+
+	for i := range 3 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d of the burst refused", i+1)
+		}
+	}
+	if ok, wait := l.Allow("a"); ok || wait != 500*time.Millisecond {
+		t.Fatalf("over the burst: ok=%v wait=%s, want refused for 500ms", ok, wait)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Fatal("another caller refused")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("refilled token refused")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("second request after one refill allowed")
+	}
+
+	now = now.Add(time.Hour)
+	for i := range 3 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d after idling refused; the bucket should refill to the burst", i+1)
+		}
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("idling allowed more than the burst")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	l := ratelimit.New(0, 1)
+	for range 100 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatal("disabled limiter refused a request")
+		}
+	}
+}
+
+func TestSetRate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := ratelimit.New(0, 1)
+	ratelimit.SetNow(l, func() time.Time { return now })
+
+	l.SetRate(1, 2)
+	for i := range 2 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d of the new burst refused", i+1)
+		}
+	}
+	if ok, wait := l.Allow("a"); ok || wait != time.Second {
+		t.Fatalf("over the burst: ok=%v wait=%s, want refused for 1s", ok, wait)
+	}
+
+	// A higher rate applies to the existing bucket at once.
+	l.SetRate(4, 2)
+	if ok, wait := l.Allow("a"); ok || wait != 250*time.Millisecond {
+		t.Fatalf("after raising the rate: ok=%v wait=%s, want refused for 250ms", ok, wait)
+	}
+	// A smaller burst caps the tokens already held.
+	now = now.Add(time.Hour)
+	l.SetRate(4, 1)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("refilled bucket refused")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("bucket kept more than the new burst")
+	}
+
+	l.SetRate(0, 1)
+	if ok, _ := l.Allow("a"); !ok || ratelimit.Buckets(l) != 0 {
+		t.Fatalf("disabled limiter: ok=%v buckets=%d", ok, ratelimit.Buckets(l))
+	}
+}
+
+func TestBucketsAreBounded(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := ratelimit.New(1, 5)
+	ratelimit.SetNow(l, func() time.Time { return now })
+
+	// Every caller spends a token, so none of the buckets is idle.
+	for i := range ratelimit.MaxBuckets * 2 {
+		now = now.Add(time.Microsecond)
+		if ok, _ := l.Allow(strconv.Itoa(i)); !ok {
+			t.Fatalf("first request of caller %d refused", i)
+		}
+		if n := ratelimit.Buckets(l); n > ratelimit.MaxBuckets {
+			t.Fatalf("%d buckets tracked, want at most %d", n, ratelimit.MaxBuckets)
+		}
+	}
+	// The most recent callers are still limited.
+	last := strconv.Itoa(ratelimit.MaxBuckets*2 - 1)
+	for range 4 {
+		l.Allow(last)
+	}
+	if ok, _ := l.Allow(last); ok {
+		t.Fatal("recent caller's bucket was dropped")
+	}
+}
+
+func TestKey(t *testing.T) {
+	ctx := context.Background()
+	if got := ratelimit.Key(ctx, "192.0.2.1:51234"); got != "addr:192.0.2.1" {
+		t.Fatalf("anonymous key=%q", got)
+	}
+	if got := ratelimit.Key(ctx, "[2001:db8::1]:443"); got != "addr:2001:db8::1" {
+		t.Fatalf("IPv6 key=%q", got)
+	}
+	ctx = logpkg.WithCaller(ctx, "CN=forecast-ui")
+	if got := ratelimit.Key(ctx, "192.0.2.1:51234"); got != "caller:CN=forecast-ui" {
+		t.Fatalf("caller key=%q", got)
+	}
+}
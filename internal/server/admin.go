@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"weather-service/internal/cache"
+	"weather-service/internal/forecast"
+)
+
+// AdminHandler wires operator-only routes: configuration, log level, cache
+// inspection and purging, forced refreshes and pprof. Its mux must only be
+// served on the admin listener, never on the public port.
+type AdminHandler struct {
+	log    *slog.Logger
+	cache  *cache.Memory
+	svc    forecast.Service
+	config func() map[string]string
+	level  *slog.LevelVar
+}
+
+// NewAdminHandler creates the admin HTTP handler. config should return the active
+// configuration with secrets redacted.
+func NewAdminHandler(log *slog.Logger, c *cache.Memory, svc forecast.Service, config func() map[string]string,
+	level *slog.LevelVar,
+) *AdminHandler {
+	return &AdminHandler{log: log, cache: c, svc: svc, config: config, level: level}
+}
+
+// Routes returns the admin HTTP mux.
+func (h *AdminHandler) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /admin/config", ConfigHandler(h.config))
+	mux.Handle("/admin/log-level", LogLevelHandler(h.level))
+	mux.HandleFunc("GET /admin/cache/stats", h.CacheStats)
+	mux.HandleFunc("GET /admin/cache/keys", h.CacheKeys)
+	mux.HandleFunc("GET /admin/cache/entry", h.CacheEntry)
+	mux.HandleFunc("DELETE /admin/cache", h.CachePurge)
+	mux.HandleFunc("POST /admin/refresh", h.Refresh)
+
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// CacheStats handles GET /admin/cache/stats.
+func (h *AdminHandler) CacheStats(w http.ResponseWriter, _ *http.Request) {
+	st := h.cache.Stats()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"entries": st.Entries,
+		"hits":    st.Hits,
+		"misses":  st.Misses,
+		"ttl":     st.TTL.String(),
+	})
+}
+
+// CacheKeys handles GET /admin/cache/keys?prefix= listing unexpired keys.
+func (h *AdminHandler) CacheKeys(w http.ResponseWriter, r *http.Request) {
+	keys := h.cache.Keys(r.URL.Query().Get("prefix"))
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(keys), "keys": keys})
+}
+
+// CacheEntry handles GET /admin/cache/entry?key= returning a cached value (such
+// as an nws.Forecast) with its expiry.
+func (h *AdminHandler) CacheEntry(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		writeErr(w, http.StatusBadRequest, errors.New("missing key"))
+		return
+	}
+	v, exp, ok := h.cache.Peek(key)
+	if !ok {
+		writeErr(w, http.StatusNotFound, fmt.Errorf("no cache entry for %q", key))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"key":       key,
+		"type":      fmt.Sprintf("%T", v),
+		"expiresAt": exp.UTC().Format(time.RFC3339),
+		"value":     v,
+	})
+}
+
+// CachePurge handles DELETE /admin/cache with exactly one selector: ?key=,
+// ?prefix= or ?lat=&lon= (which evicts the point and its forecast document).
+func (h *AdminHandler) CachePurge(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var removed int
+	switch {
+	case q.Has("key"):
+		if h.cache.Delete(q.Get("key")) {
+			removed = 1
+		}
+	case q.Has("prefix"):
+		if q.Get("prefix") == "" {
+			writeErr(w, http.StatusBadRequest, errors.New("empty prefix; refusing to purge everything implicitly"))
+			return
+		}
+		removed = h.cache.DeletePrefix(q.Get("prefix"))
+	case q.Has("lat") || q.Has("lon"):
+		lat, lon, err := parseLatLon(q.Get("lat"), q.Get("lon"))
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, err)
+			return
+		}
+		removed = h.svc.Purge(lat, lon)
+	default:
+		writeErr(w, http.StatusBadRequest, errors.New("specify key, prefix or lat/lon"))
+		return
+	}
+	h.log.InfoContext(r.Context(), "cache purged", "query", r.URL.RawQuery, "removed", removed)
+	writeJSON(w, http.StatusOK, map[string]any{"removed": removed})
+}
+
+// Refresh handles POST /admin/refresh?lat=&lon= evicting the location and
+// fetching it again from upstream.
+func (h *AdminHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	lat, lon, err := parseLatLon(r.URL.Query().Get("lat"), r.URL.Query().Get("lon"))
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	res, err := h.svc.Refresh(r.Context(), lat, lon)
+	if err != nil {
+		writeErr(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// ConfigHandler serves the active configuration as returned by current, which
+// should already have secrets redacted (see config.Config.Redacted).
+func ConfigHandler(current func() map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, current())
+	}
+}
+
+// LogLevelHandler reports (GET) and changes (PUT) the runtime log level held by
+// level. The new level is read from the "level" query parameter or a JSON body
+// such as {"level":"DEBUG"}.
+func LogLevelHandler(level *slog.LevelVar) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			name := r.URL.Query().Get("level")
+			if name == "" {
+				var body struct {
+					Level string `json:"level"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeErr(w, http.StatusBadRequest, errors.New("missing level"))
+					return
+				}
+				name = body.Level
+			}
+			var l slog.Level
+			if err := l.UnmarshalText([]byte(name)); err != nil {
+				writeErr(w, http.StatusBadRequest, err)
+				return
+			}
+			level.Set(l)
+			slog.Default().InfoContext(r.Context(), "log level changed", "level", l)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET or PUT"))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"level": level.Level().String()})
+	}
+}
@@ -0,0 +1,131 @@
+package server_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/cache"
+	"weather-service/internal/nws"
+	"weather-service/internal/server"
+)
+
+func newAdmin(t *testing.T, c *cache.Memory, f *fakeSvc) http.Handler {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	config := func() map[string]string { return map[string]string{"NWS_USER_AGENT": "[redacted]"} }
+	return server.NewAdminHandler(logger, c, f, config, &slog.LevelVar{}).Routes()
+}
+
+func serve(h http.Handler, method, url string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, url, nil))
+	return rec
+}
+
+func TestAdminCacheInspection(t *testing.T) {
+	c := cache.NewCache(time.Minute)
+	var fc nws.Forecast
+	fc.Properties.Units = "us"
+	c.Set("forecast:https://nws/a", fc)
+	c.Set("points:1.0000,2.0000", "x")
+	h := newAdmin(t, c, &fakeSvc{})
+
+	rec := serve(h, http.MethodGet, "/admin/cache/stats")
+	if m := decodeBody[map[string]any](t, rec.Body.Bytes()); m["entries"] != float64(2) {
+		t.Fatalf("stats: %s", rec.Body.String())
+	}
+
+	rec = serve(h, http.MethodGet, "/admin/cache/keys?prefix=forecast:")
+	if m := decodeBody[map[string]any](t, rec.Body.Bytes()); m["count"] != float64(1) {
+		t.Fatalf("keys: %s", rec.Body.String())
+	}
+
+	rec = serve(h, http.MethodGet, "/admin/cache/entry?key=forecast:https://nws/a")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"units":"us"`) ||
+		!strings.Contains(rec.Body.String(), "nws.Forecast") {
+		t.Fatalf("entry: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec = serve(h, http.MethodGet, "/admin/cache/entry?key=nope"); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing entry: status=%d", rec.Code)
+	}
+}
+
+func TestAdminCachePurge(t *testing.T) {
+	c := cache.NewCache(time.Minute)
+	c.Set("points:a", 1)
+	c.Set("points:b", 2)
+	c.Set("forecast:x", 3)
+	f := &fakeSvc{}
+	h := newAdmin(t, c, f)
+
+	cases := []struct {
+		url     string
+		code    int
+		removed float64
+	}{
+		{"/admin/cache?key=forecast:x", http.StatusOK, 1},
+		{"/admin/cache?prefix=points:", http.StatusOK, 2},
+		{"/admin/cache?lat=10&lon=20", http.StatusOK, 2}, // fakeSvc.Purge
+		{"/admin/cache?prefix=", http.StatusBadRequest, 0},
+		{"/admin/cache?lat=100&lon=20", http.StatusBadRequest, 0},
+		{"/admin/cache", http.StatusBadRequest, 0},
+	}
+	for _, tc := range cases {
+		rec := serve(h, http.MethodDelete, tc.url)
+		if rec.Code != tc.code {
+			t.Fatalf("%s: status=%d want %d", tc.url, rec.Code, tc.code)
+		}
+		if tc.code == http.StatusOK {
+			if m := decodeBody[map[string]any](t, rec.Body.Bytes()); m["removed"] != tc.removed {
+				t.Fatalf("%s: %s", tc.url, rec.Body.String())
+			}
+		}
+	}
+	if f.gotLat != 10 || f.gotLon != 20 {
+		t.Fatalf("Purge received (%v,%v)", f.gotLat, f.gotLon)
+	}
+}
+
+func TestAdminRefreshAndPprof(t *testing.T) {
+	f := &fakeSvc{}
+	f.res.Today.Name = "Today"
+	h := newAdmin(t, cache.NewCache(time.Minute), f)
+
+	rec := serve(h, http.MethodPost, "/admin/refresh?lat=1&lon=2")
+	if rec.Code != http.StatusOK || f.gotLat != 1 || f.gotLon != 2 {
+		t.Fatalf("refresh: status=%d got (%v,%v)", rec.Code, f.gotLat, f.gotLon)
+	}
+
+	if rec = serve(h, http.MethodGet, "/debug/pprof/"); rec.Code != http.StatusOK {
+		t.Fatalf("pprof index: status=%d", rec.Code)
+	}
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	level := &slog.LevelVar{}
+	h := server.LogLevelHandler(level)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusOK || level.Level() != slog.LevelDebug {
+		t.Fatalf("status=%d level=%v", rec.Code, level.Level())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/log-level?level=loud", nil))
+	if rec.Code != http.StatusBadRequest || level.Level() != slog.LevelDebug {
+		t.Fatalf("status=%d level=%v", rec.Code, level.Level())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/log-level", nil))
+	if !strings.Contains(rec.Body.String(), `"DEBUG"`) {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/ical"
+	"weather-service/internal/nws"
+)
+
+const (
+	// calendarRefresh is the polling interval suggested to calendar clients.
+	calendarRefresh = time.Hour
+	// alertDuration is the length given to an alert event when NWS reports
+	// neither an end nor an expiry.
+	alertDuration = time.Hour
+)
+
+// AlertsFunc returns the alerts in effect at a location, like nws.Client.Alerts.
+type AlertsFunc func(ctx context.Context, lat, lon float64) ([]nws.Alert, error)
+
+// CalendarHandler serves the forecast as an iCalendar feed.
+type CalendarHandler struct {
+	log    *slog.Logger
+	svc    forecast.Service
+	alerts AlertsFunc
+}
+
+// NewCalendarHandler creates the iCalendar handler. alerts may be nil to leave
+// alerts out of the feed.
+func NewCalendarHandler(log *slog.Logger, svc forecast.Service, alerts AlertsFunc) *CalendarHandler {
+	return &CalendarHandler{log: log, svc: svc, alerts: alerts}
+}
+
+// Register adds the /v1/forecast.ics route to mux.
+func (h *CalendarHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/forecast.ics", h.Serve)
+}
+
+// Serve handles GET /v1/forecast.ics?lat=&lon= with an RFC 5545 calendar holding
+// one timed event per remaining forecast period and one per active alert. Event
+// UIDs depend only on the location and the period start (or the alert ID), so
+// clients subscribed to the feed update events instead of duplicating them.
+// Failing to fetch alerts is logged and leaves them out. Conditional requests are
+// answered as for the other forecast reads, except that If-Modified-Since only
+// applies when the handler leaves alerts out.
+func (h *CalendarHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	lat, lon, err := parseLatLon(r.URL.Query().Get("lat"), r.URL.Query().Get("lon"))
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	res, err := h.svc.GetPeriods(r.Context(), lat, lon)
+	if err != nil {
+		writeErr(w, http.StatusBadGateway, err)
+		return
+	}
+	loc, err := nws.LoadLocation(res.TimeZone, time.Time{})
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	stamp := time.Now()
+	if res.Meta != nil {
+		if t, perr := time.Parse(time.RFC3339, res.Meta.Updated); perr == nil {
+			stamp = t
+		}
+	}
+	cal := ical.Calendar{
+		ProdID:   "-//weather-service//forecast//EN",
+		Name:     fmt.Sprintf("Forecast for %s, %s", ftoa(lat), ftoa(lon)),
+		Location: loc,
+		Refresh:  calendarRefresh,
+	}
+	for _, p := range res.Periods {
+		cal.Events = append(cal.Events, periodEvent(lat, lon, p, stamp))
+	}
+	if h.alerts != nil {
+		alerts, aerr := h.alerts(r.Context(), lat, lon)
+		if aerr != nil {
+			h.log.WarnContext(r.Context(), "calendar alerts unavailable", "lat", lat, "lon", lon, "err", aerr)
+		}
+		for _, a := range alerts {
+			ev := alertEvent(a)
+			cal.Events = append(cal.Events, ev)
+			if ev.Stamp.After(stamp) {
+				stamp = ev.Stamp
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	// An alert drops out of the feed when it ends or is cancelled, and nothing
+	// dates that, so a feed with alerts has no Last-Modified and is only
+	// revalidated by its ETag.
+	meta := &forecast.Meta{}
+	if res.Meta != nil {
+		meta.Expires = res.Meta.Expires
+		if h.alerts == nil {
+			meta.Modified = res.Meta.Modified
+		}
+	}
+	if notModified(w, r, etagBytes(buf.Bytes()), meta) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="forecast.ics"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		h.log.DebugContext(r.Context(), "write calendar", "err", err)
+	}
+}
+
+// periodEvent turns a forecast period into an event summarized like
+// "72°F moderate – Partly Cloudy".
+func periodEvent(lat, lon float64, p forecast.Period, stamp time.Time) ical.Event {
+	t := p.Temperature
+	desc := p.Name
+	if p.DetailedForecast != "" {
+		desc += ": " + p.DetailedForecast
+	}
+	return ical.Event{
+		UID:         fmt.Sprintf("%s-%.4f_%.4f@weather-service", p.Start.UTC().Format("20060102T150405Z"), lat, lon),
+		Start:       p.Start,
+		End:         p.End,
+		Stamp:       stamp,
+		Summary:     fmt.Sprintf("%d°%s %s – %s", t.Value, t.Unit, t.Type, p.ShortForecast),
+		Description: desc,
+		Categories:  []string{"FORECAST"},
+	}
+}
+
+// alertEvent turns an alert into an event lasting until it ends or expires, or
+// for alertDuration when NWS gives neither. The event is stamped with the time
+// the alert was sent, so an updated alert gets a newer DTSTAMP.
+func alertEvent(a nws.Alert) ical.Event {
+	start, stamp := a.Effective, a.Sent
+	if start.IsZero() {
+		start = a.Sent
+	}
+	if stamp.IsZero() {
+		stamp = start
+	}
+	end := a.Expires
+	switch {
+	case a.Ends != nil && !a.Ends.IsZero():
+		end = *a.Ends
+	case end.IsZero() || !end.After(start):
+		end = start.Add(alertDuration)
+	}
+	summary := a.Headline
+	if summary == "" {
+		summary = a.Event
+	}
+	desc := strings.TrimSpace(a.Description)
+	if a.Instruction != "" {
+		desc += "\n\n" + strings.TrimSpace(a.Instruction)
+	}
+	cats := []string{"ALERT"}
+	if a.Severity != "" {
+		cats = append(cats, a.Severity)
+	}
+	return ical.Event{
+		UID:         a.ID,
+		Start:       start,
+		End:         end,
+		Stamp:       stamp,
+		Summary:     "⚠ " + summary,
+		Description: desc,
+		Categories:  cats,
+	}
+}
@@ -0,0 +1,143 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+	"weather-service/internal/server"
+)
+
+func newCalendarMux(f *fakeSvc, alerts server.AlertsFunc) *http.ServeMux {
+	mux := http.NewServeMux()
+	server.NewCalendarHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), f, alerts).Register(mux)
+	return mux
+}
+
+func TestCalendar(t *testing.T) {
+	loc, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+	start := time.Date(2025, 8, 13, 6, 0, 0, 0, loc)
+	f := &fakeSvc{}
+	f.periods.Coords.Lat, f.periods.Coords.Lon = 39.7392, -104.9903
+	f.periods.TimeZone = "America/Denver"
+	f.periods.Meta = &forecast.Meta{Updated: "2025-08-13T10:00:00Z"}
+	f.periods.Periods = []forecast.Period{{
+		PeriodSummary: forecast.PeriodSummary{Name: "Today", ShortForecast: "Partly Cloudy",
+			Temperature: forecast.Temperature{Value: 72, Unit: "F", Type: "moderate"}},
+		Start: start, End: start.Add(12 * time.Hour), IsDaytime: true, DetailedForecast: "Partly cloudy, high near 72.",
+	}}
+	alerts := func(context.Context, float64, float64) ([]nws.Alert, error) {
+		return []nws.Alert{{ID: "urn:oid:2.49.0.1.840.0.1", Event: "Heat Advisory", Headline: "Heat Advisory until 8 PM",
+			Severity: "Moderate", Effective: start, Expires: start.Add(14 * time.Hour)}}, nil
+	}
+
+	get := func() string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		newCalendarMux(f, alerts).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/forecast.ics?lat=39.7392&lon=-104.9903", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/calendar; charset=utf-8" {
+			t.Fatalf("Content-Type=%q", ct)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/v1/forecast.ics?lat=39.7392&lon=-104.9903", nil)
+		req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+		cond := httptest.NewRecorder()
+		newCalendarMux(f, alerts).ServeHTTP(cond, req)
+		if cond.Code != http.StatusNotModified {
+			t.Fatalf("conditional status=%d", cond.Code)
+		}
+		return strings.ReplaceAll(rec.Body.String(), "\r\n ", "")
+	}
+	body := get()
+	for _, want := range []string{
+		"UID:20250813T120000Z-39.7392_-104.9903@weather-service\r\n",
+		"DTSTART;TZID=America/Denver:20250813T060000\r\n",
+		"DTSTAMP:20250813T100000Z\r\n",
+		"SUMMARY:72°F moderate – Partly Cloudy\r\n",
+		`DESCRIPTION:Today: Partly cloudy\, high near 72.` + "\r\n",
+		"TZID:America/Denver\r\n",
+		"UID:urn:oid:2.49.0.1.840.0.1\r\n",
+		"SUMMARY:⚠ Heat Advisory until 8 PM\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in\n%s", want, body)
+		}
+	}
+	if again := get(); again != body {
+		t.Fatalf("calendar not stable across requests:\n%s\n---\n%s", body, again)
+	}
+}
+
+func TestCalendarErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newCalendarMux(&fakeSvc{}, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/forecast.ics?lat=x&lon=1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad coords status=%d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	newCalendarMux(&fakeSvc{err: errors.New("upstream down")}, nil).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/forecast.ics?lat=1&lon=2", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("upstream failure status=%d", rec.Code)
+	}
+}
+
+func TestCalendarAlertTimes(t *testing.T) {
+	start := time.Date(2025, 8, 13, 6, 0, 0, 0, time.FixedZone("MDT", -6*3600))
+	f := &fakeSvc{}
+	f.periods.TimeZone = "America/Denver"
+	f.periods.Meta = &forecast.Meta{Updated: "2025-08-13T10:00:00Z"}
+	f.periods.Periods = []forecast.Period{{PeriodSummary: forecast.PeriodSummary{Name: "Today"}, Start: start, End: start.Add(12 * time.Hour)}}
+	alerts := func(context.Context, float64, float64) ([]nws.Alert, error) {
+		return []nws.Alert{{ID: "urn:oid:2", Event: "Special Weather Statement",
+			Sent: start.Add(-30 * time.Minute), Effective: start}}, nil
+	}
+	rec := httptest.NewRecorder()
+	newCalendarMux(f, alerts).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/forecast.ics?lat=39.7392&lon=-104.9903", nil))
+	body := rec.Body.String()
+	event := body[strings.Index(body, "UID:urn:oid:2"):]
+	for _, want := range []string{
+		"DTSTART;TZID=America/Denver:20250813T060000\r\n",
+		"DTEND;TZID=America/Denver:20250813T070000\r\n",
+		"DTSTAMP:20250813T113000Z\r\n",
+	} {
+		if !strings.Contains(event, want) {
+			t.Errorf("missing %q in\n%s", want, event)
+		}
+	}
+	if strings.Contains(body, "0001") {
+		t.Fatalf("zero time in calendar:\n%s", body)
+	}
+	// Alerts drop out undated, so a feed with alerts only validates by ETag.
+	if lm := rec.Header().Get("Last-Modified"); lm != "" {
+		t.Fatalf("Last-Modified=%q with alerts", lm)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/v1/forecast.ics?lat=39.7392&lon=-104.9903", nil)
+	req.Header.Set("If-Modified-Since", "Thu, 14 Aug 2025 00:00:00 GMT")
+	rec = httptest.NewRecorder()
+	newCalendarMux(f, alerts).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("If-Modified-Since with alerts: status=%d", rec.Code)
+	}
+
+	f.periods.Meta.Modified = time.Date(2025, 8, 13, 10, 0, 0, 0, time.UTC)
+	rec = httptest.NewRecorder()
+	newCalendarMux(f, nil).ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Header().Get("Last-Modified") != "Wed, 13 Aug 2025 10:00:00 GMT" {
+		t.Fatalf("without alerts: status=%d Last-Modified=%q", rec.Code, rec.Header().Get("Last-Modified"))
+	}
+}
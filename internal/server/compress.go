@@ -0,0 +1,225 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// minCompressSize is the smallest body worth compressing; smaller ones are sent
+// as they are unless the handler flushes first.
+const minCompressSize = 1024
+
+// incompressible lists Content-Type prefixes whose bodies are already compressed.
+var incompressible = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/gzip", "application/zip", "application/zstd", "application/x-bzip2", "application/x-7z-compressed",
+	"application/octet-stream",
+}
+
+// compressor compresses responses with gzip or zstd at one level, reusing
+// encoders across responses.
+type compressor struct {
+	level int
+	gzip  sync.Pool
+	zstd  sync.Pool
+}
+
+// compress returns middleware compressing responses for clients that accept
+// gzip or zstd, preferring zstd. level is 1 (fastest) to 9 (smallest); 0
+// disables compression.
+func compress(level int) func(http.Handler) http.Handler {
+	if level <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	c := &compressor{level: min(level, gzip.BestCompression)}
+	c.gzip.New = func() any {
+		zw, _ := gzip.NewWriterLevel(io.Discard, c.level)
+		return zw
+	}
+	c.zstd.New = func() any {
+		zw, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.level)), zstd.WithEncoderConcurrency(1))
+		return zw
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			enc := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+			if enc == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressWriter{ResponseWriter: w, c: c, encoding: enc, status: http.StatusOK}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// acceptedEncoding picks zstd or gzip from an Accept-Encoding header by q-value,
+// preferring zstd on a tie, or returns "" when neither is acceptable.
+func acceptedEncoding(header string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			weight = f
+		}
+		q[strings.ToLower(strings.TrimSpace(name))] = weight
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range []string{"zstd", "gzip"} {
+		w, ok := q[enc]
+		if !ok {
+			w, ok = q["*"]
+		}
+		if ok && w > bestQ {
+			best, bestQ = enc, w
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a response until it knows whether the
+// body is worth compressing, then either compresses everything or passes it
+// through. WriteHeader is deferred with it so Content-Encoding can still be set.
+type compressWriter struct {
+	http.ResponseWriter
+
+	c        *compressor
+	encoding string
+	status   int
+	buf      []byte
+	decided  bool
+	enc      interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided || code < http.StatusOK {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < minCompressSize {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush compresses whatever is buffered, however small, so streaming handlers
+// send events as they happen, then flushes the connection.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the deferred header, compressing the body when want is set and
+// the response allows it, and then the buffered start of the body.
+func (w *compressWriter) decide(want bool) error {
+	w.decided = true
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if want && w.compressible() {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			h.Set("ETag", "W/"+etag) // the bytes differ from the identity encoding
+		}
+		switch w.encoding {
+		case "zstd":
+			zw := w.c.zstd.Get().(*zstd.Encoder)
+			zw.Reset(w.ResponseWriter)
+			w.enc = zw
+		default:
+			zw := w.c.gzip.Get().(*gzip.Writer)
+			zw.Reset(w.ResponseWriter)
+			w.enc = zw
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	ct := strings.ToLower(h.Get("Content-Type"))
+	for _, prefix := range incompressible {
+		if strings.HasPrefix(ct, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// close finishes the response: a body that never reached minCompressSize is
+// sent uncompressed and the encoder, if any, is flushed and pooled.
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.enc == nil {
+		return
+	}
+	_ = w.enc.Close()
+	switch zw := w.enc.(type) {
+	case *zstd.Encoder:
+		zw.Reset(nil)
+		w.c.zstd.Put(zw)
+	case *gzip.Writer:
+		zw.Reset(io.Discard)
+		w.c.gzip.Put(zw)
+	}
+	w.enc = nil
+}
@@ -0,0 +1,152 @@
+package server_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"weather-service/internal/server"
+)
+
+func decompress(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			t.Fatalf("gzip: %v", err)
+		}
+		r = zr
+	case "zstd":
+		zr, err := zstd.NewReader(body)
+		if err != nil {
+			t.Fatalf("zstd: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		r = body
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read %s body: %v", encoding, err)
+	}
+	return string(b)
+}
+
+func TestCompression(t *testing.T) {
+	large := `{"periods":[` + strings.Repeat(`{"name":"Tonight","temperature":58},`, 100) + `{}]}`
+	h := server.WithMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/png":
+			w.Header().Set("Content-Type", "image/png")
+		case "/small":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"ok":true}`)
+			return
+		default:
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(large)))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, large[:500])
+		_, _ = io.WriteString(w, large[500:])
+	}), 5)
+
+	for _, tc := range []struct {
+		name, path, accept, wantEncoding string
+	}{
+		{"zstd preferred", "/", "gzip, deflate, br, zstd", "zstd"},
+		{"gzip", "/", "gzip", "gzip"},
+		{"q values", "/", "zstd;q=0.5, gzip;q=0.8", "gzip"},
+		{"refused", "/", "zstd;q=0, gzip;q=0", ""},
+		{"wildcard", "/", "*", "zstd"},
+		{"identity", "/", "", ""},
+		{"small body", "/small", "gzip", ""},
+		{"already compressed", "/png", "gzip", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept-Encoding", tc.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tc.wantEncoding {
+				t.Fatalf("Content-Encoding=%q want %q", got, tc.wantEncoding)
+			}
+			if vary := rec.Header().Values("Vary"); len(vary) == 0 || vary[0] != "Accept-Encoding" {
+				t.Fatalf("Vary=%q", vary)
+			}
+			if tc.path == "/small" {
+				return
+			}
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("status=%d want 201", rec.Code)
+			}
+			if cl := rec.Header().Get("Content-Length"); tc.wantEncoding != "" && cl != "" {
+				t.Fatalf("compressed response keeps Content-Length %s", cl)
+			}
+			if got := decompress(t, tc.wantEncoding, rec.Body); got != large {
+				t.Fatalf("body round trip mismatch: %d bytes", len(got))
+			}
+			if tc.wantEncoding != "" && rec.Body.Len() >= len(large) {
+				t.Fatalf("body not smaller: %d", rec.Body.Len())
+			}
+		})
+	}
+}
+
+func TestCompressionStreams(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(server.WithMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: first\n\n")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("flush: %v", err)
+		}
+		<-release
+		_, _ = io.WriteString(w, "data: second\n\n")
+	}), 5))
+	defer srv.Close()
+	defer close(release)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding=%q", resp.Header.Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+
+	got := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(zr).ReadString('\n')
+		got <- line
+	}()
+	select {
+	case line := <-got:
+		if line != "data: first\n" {
+			t.Fatalf("first line %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("flushed event not delivered before the handler finished")
+	}
+}
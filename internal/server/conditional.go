@@ -0,0 +1,88 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"weather-service/internal/forecast"
+)
+
+// etagOf returns a weak ETag for payload v rendered in format. The ETag is weak
+// because it ignores Result.LocalTime, and the bounds of a history window that
+// default to now, which change on every request while the forecasts they come
+// with do not.
+func etagOf(format string, v any) string {
+	switch res := v.(type) {
+	case forecast.Result:
+		res.LocalTime = ""
+		v = res
+	case historyWindow:
+		res.From, res.To = time.Time{}, time.Time{}
+		v = res
+	case historyChanges:
+		res.Since = time.Time{}
+		v = res
+	}
+	b, _ := json.Marshal(v)
+	return etagBytes(append([]byte(format+"\n"), b...))
+}
+
+func etagBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// notModified sets the caching headers of a successful read response: the ETag,
+// Last-Modified from Meta.Modified, the newest input of the response, and
+// Cache-Control with the time left before the upstream document is refetched
+// (no-cache when it is not cached). It answers 304 and returns true when the
+// request's If-None-Match or, failing that, If-Modified-Since shows the client
+// already has the response. Without a Meta.Modified there is no Last-Modified
+// and If-Modified-Since is ignored.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, m *forecast.Meta) bool {
+	h := w.Header()
+	h.Set("ETag", etag)
+	var modified time.Time
+	cacheControl := "no-cache"
+	if m != nil {
+		if !m.Modified.IsZero() {
+			modified = m.Modified
+			h.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+		}
+		if left := time.Until(m.Expires); !m.Expires.IsZero() && left > 0 {
+			cacheControl = "public, max-age=" + strconv.Itoa(int(math.Ceil(left.Seconds())))
+		}
+	}
+	h.Set("Cache-Control", cacheControl)
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if !etagMatches(inm, etag) {
+			return false
+		}
+	} else {
+		ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || modified.IsZero() || modified.Truncate(time.Second).After(ims) {
+			return false
+		}
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match list matches etag using the weak
+// comparison RFC 9110 prescribes for it.
+func etagMatches(list, etag string) bool {
+	for _, t := range strings.Split(list, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
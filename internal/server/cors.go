@@ -0,0 +1,123 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exposedHeaders are the response headers browser scripts may read besides the
+// CORS-safelisted ones.
+const exposedHeaders = "ETag, Last-Modified, X-Request-ID, Retry-After, Content-Disposition"
+
+// CORSOptions configures WithCORS.
+type CORSOptions struct {
+	// AllowedOrigins are exact origins ("https://app.example.com"), subdomain
+	// patterns ("https://*.example.com") or "*" for any origin.
+	AllowedOrigins   []string
+	AllowedMethods   []string // e.g. GET, POST; "*" allows any
+	AllowedHeaders   []string // request headers; "*" allows any
+	AllowCredentials bool
+	MaxAge           time.Duration // how long browsers may cache a preflight answer
+}
+
+// WithCORS answers CORS preflight requests for every path, whatever methods the
+// route behind it has, and adds CORS headers to the responses of next for
+// allowed origins. With no allowed origins it returns next unchanged.
+func WithCORS(next http.Handler, opts CORSOptions) http.Handler {
+	if len(opts.AllowedOrigins) == 0 {
+		return next
+	}
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		reqMethod := r.Header.Get("Access-Control-Request-Method")
+		preflight := r.Method == http.MethodOptions && reqMethod != ""
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !opts.originAllowed(origin) {
+			if preflight {
+				writeErr(w, http.StatusForbidden, errors.New("origin not allowed"))
+				return
+			}
+			next.ServeHTTP(w, r) // the browser withholds the response without CORS headers
+			return
+		}
+		if slices.Contains(opts.AllowedOrigins, "*") && !opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			h.Set("Access-Control-Expose-Headers", exposedHeaders)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !allowed(opts.AllowedMethods, reqMethod) {
+			writeErr(w, http.StatusForbidden, errors.New("method "+reqMethod+" not allowed"))
+			return
+		}
+		reqHeaders := r.Header.Get("Access-Control-Request-Headers")
+		for _, name := range strings.Split(reqHeaders, ",") {
+			if name = strings.TrimSpace(name); name != "" && !allowed(opts.AllowedHeaders, name) {
+				writeErr(w, http.StatusForbidden, errors.New("header "+name+" not allowed"))
+				return
+			}
+		}
+		h.Set("Access-Control-Allow-Methods", methods)
+		if reqHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", reqHeaders)
+		}
+		h.Set("Access-Control-Max-Age", maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// originAllowed matches origin against the allowed origins and patterns.
+func (o CORSOptions) originAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, a := range o.AllowedOrigins {
+		a = strings.ToLower(a)
+		if a == "*" || a == origin {
+			return true
+		}
+		// "https://*.example.com" matches "https://api.example.com" and deeper
+		// subdomains, but not "https://example.com".
+		scheme, domain, ok := strings.Cut(a, "://*.")
+		if !ok {
+			continue
+		}
+		host, found := strings.CutPrefix(origin, scheme+"://")
+		if found && strings.HasSuffix(host, "."+domain) && !strings.ContainsAny(strings.TrimSuffix(host, "."+domain), "/:") {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether name is in list, case-insensitively, or list has "*".
+func allowed(list []string, name string) bool {
+	for _, v := range list {
+		if v == "*" || strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/server"
+)
+
+func TestCORS(t *testing.T) {
+	mux := newHandlerWithFake(t, &fakeSvc{}).Routes()
+	mux.HandleFunc("POST /v1/things", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusCreated) })
+	h := server.WithCORS(mux, server.CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.internal.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	do := func(method, target, origin string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/healthz", "https://app.example.com")
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Fatalf("simple request: status=%d headers=%v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		!strings.Contains(rec.Header().Get("Access-Control-Expose-Headers"), "ETag") {
+		t.Fatalf("simple request headers=%v", rec.Header())
+	}
+	if got := rec.Header().Values("Vary"); len(got) == 0 || got[0] != "Origin" {
+		t.Fatalf("Vary=%v", got)
+	}
+
+	for _, tc := range []struct {
+		name, target, origin, method, headers string
+		wantCode                              int
+	}{
+		{"GET route", "/v1/forecast?lat=1&lon=2", "https://app.example.com", "GET", "", http.StatusNoContent},
+		{"POST route", "/v1/things", "https://a.b.internal.example.com", "POST", "content-type, x-request-id", http.StatusNoContent},
+		{"unknown route", "/v1/future", "https://app.example.com", "GET", "", http.StatusNoContent},
+		{"apex of wildcard", "/healthz", "https://internal.example.com", "GET", "", http.StatusForbidden},
+		{"wrong scheme", "/healthz", "http://app.example.com", "GET", "", http.StatusForbidden},
+		{"suffix trick", "/healthz", "https://evilinternal.example.com", "GET", "", http.StatusForbidden},
+		{"method", "/v1/things", "https://app.example.com", "DELETE", "", http.StatusForbidden},
+		{"header", "/v1/things", "https://app.example.com", "POST", "Authorization", http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hdr := []string{"Access-Control-Request-Method", tc.method}
+			if tc.headers != "" {
+				hdr = append(hdr, "Access-Control-Request-Headers", tc.headers)
+			}
+			rec := do(http.MethodOptions, tc.target, tc.origin, hdr...)
+			if rec.Code != tc.wantCode {
+				t.Fatalf("status=%d want %d; body=%s", rec.Code, tc.wantCode, rec.Body.String())
+			}
+			if tc.wantCode != http.StatusNoContent {
+				return
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tc.origin {
+				t.Fatalf("Allow-Origin=%q", got)
+			}
+			if rec.Header().Get("Access-Control-Allow-Methods") != "GET, POST" || rec.Header().Get("Access-Control-Max-Age") != "600" {
+				t.Fatalf("preflight headers=%v", rec.Header())
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Headers"); got != tc.headers {
+				t.Fatalf("Allow-Headers=%q want %q", got, tc.headers)
+			}
+		})
+	}
+
+	if rec = do(http.MethodGet, "/healthz", "https://other.example.org"); rec.Code != http.StatusOK ||
+		rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("disallowed origin: status=%d headers=%v", rec.Code, rec.Header())
+	}
+	// A plain OPTIONS request without CORS headers still reaches the routes.
+	if rec = do(http.MethodOptions, "/healthz", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("non-CORS OPTIONS status=%d", rec.Code)
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	h := server.WithCORS(http.NotFoundHandler(), server.CORSOptions{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"*"}})
+	req := httptest.NewRequest(http.MethodOptions, "/x", nil)
+	req.Header.Set("Origin", "https://anything.example")
+	req.Header.Set("Access-Control-Request-Method", "PATCH")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("status=%d headers=%v", rec.Code, rec.Header())
+	}
+}
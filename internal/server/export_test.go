@@ -0,0 +1,6 @@
+package server
+
+import "time"
+
+// SetHistoryNow replaces the clock h uses for its default windows.
+func SetHistoryNow(h *HistoryHandler, now func() time.Time) { h.now = now }
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"weather-service/internal/gql"
+)
+
+// maxGraphQLBody bounds the size of a GraphQL request body.
+const maxGraphQLBody = 1 << 16
+
+// GraphQLHandler serves the GraphQL API.
+type GraphQLHandler struct {
+	log  *slog.Logger
+	exec *gql.Executor
+}
+
+// NewGraphQLHandler creates the GraphQL HTTP handler.
+func NewGraphQLHandler(log *slog.Logger, exec *gql.Executor) *GraphQLHandler {
+	return &GraphQLHandler{log: log, exec: exec}
+}
+
+// Register adds the /graphql routes to mux.
+func (h *GraphQLHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /graphql", h.Serve)
+	mux.HandleFunc("POST /graphql", h.Serve)
+}
+
+// Serve handles GET /graphql?query=… and POST /graphql with a JSON body
+// {"query", "operationName", "variables"}. Query errors, including exceeded depth
+// or complexity limits, are reported in the "errors" member with status 200, as
+// GraphQL clients expect; only malformed HTTP requests get a 400.
+func (h *GraphQLHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	var req gql.Request
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeErr(w, http.StatusBadRequest, errors.New("invalid variables"))
+				return
+			}
+		}
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBody)).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Query == "" {
+		writeErr(w, http.StatusBadRequest, errors.New("query is required"))
+		return
+	}
+
+	res := h.exec.Do(r.Context(), req)
+	if res.HasErrors() {
+		h.log.DebugContext(r.Context(), "graphql errors", "errors", res.Errors)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
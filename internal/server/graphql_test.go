@@ -0,0 +1,74 @@
+package server_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/gql"
+	"weather-service/internal/server"
+)
+
+func newGraphQLMux(t *testing.T, f *fakeSvc) *http.ServeMux {
+	t.Helper()
+	exec, err := gql.NewExecutor(f, nil, nil)
+	if err != nil {
+		t.Fatalf("NewExecutor: %v", err)
+	}
+	mux := http.NewServeMux()
+	server.NewGraphQLHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), exec).Register(mux)
+	return mux
+}
+
+func TestGraphQL(t *testing.T) {
+	f := &fakeSvc{res: forecast.Result{Today: forecast.PeriodSummary{Name: "Today", ShortForecast: "Sunny"}}}
+	mux := newGraphQLMux(t, f)
+
+	body := `{"query":"query($lat: Float!) { location(lat: $lat, lon: -97) { today { shortForecast } } }","variables":{"lat":32.5}}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST status=%d want 200; body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Body.String(); !strings.Contains(got, `"shortForecast":"Sunny"`) {
+		t.Fatalf("POST body=%s", got)
+	}
+	if f.gotLat != 32.5 || f.gotLon != -97 {
+		t.Fatalf("service got lat=%v lon=%v", f.gotLat, f.gotLon)
+	}
+
+	rec = httptest.NewRecorder()
+	q := url.Values{"query": {`{ location(lat: 1, lon: 2) { today { name } } }`}}
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql?"+q.Encode(), nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name":"Today"`) {
+		t.Fatalf("GET status=%d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGraphQLBadRequests(t *testing.T) {
+	mux := newGraphQLMux(t, &fakeSvc{})
+
+	for _, tc := range []struct {
+		name, method, target, body string
+		wantCode                   int
+		wantBody                   string
+	}{
+		{"missing query", http.MethodGet, "/graphql", "", http.StatusBadRequest, "query is required"},
+		{"bad json", http.MethodPost, "/graphql", "{", http.StatusBadRequest, ""},
+		{"syntax error", http.MethodPost, "/graphql", `{"query":"{ location("}`, http.StatusOK, `"errors"`},
+		{"unknown field", http.MethodPost, "/graphql", `{"query":"{ nope }"}`, http.StatusOK, `Cannot query field`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+			if rec.Code != tc.wantCode || !strings.Contains(rec.Body.String(), tc.wantBody) {
+				t.Fatalf("status=%d body=%s; want %d containing %q", rec.Code, rec.Body.String(), tc.wantCode, tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/history"
+)
+
+const (
+	// historyWindowDefault is the window of a query without from.
+	historyWindowDefault = 24 * time.Hour
+	// historyWindowMax bounds the window of one query.
+	historyWindowMax = 31 * 24 * time.Hour
+)
+
+// GridCellFunc resolves coordinates to their grid cell, like
+// forecast.Internal.GridCell.
+type GridCellFunc func(ctx context.Context, lat, lon float64) (string, error)
+
+// HistoryHandler serves archived forecasts and what each update changed.
+type HistoryHandler struct {
+	log   *slog.Logger
+	cells GridCellFunc
+	store *history.Store
+	bands *forecast.BandsVar
+	now   func() time.Time // end of the default windows
+}
+
+// NewHistoryHandler creates the forecast history HTTP handler. cells resolves
+// coordinates to the grid cells the store is keyed by; bands classifies the
+// temperatures of changed periods.
+func NewHistoryHandler(log *slog.Logger, cells GridCellFunc, store *history.Store,
+	bands *forecast.BandsVar) *HistoryHandler {
+	return &HistoryHandler{log: log, cells: cells, store: store, bands: bands, now: time.Now}
+}
+
+// Register adds the history routes to mux.
+func (h *HistoryHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/history/forecast", h.Forecast)
+	mux.HandleFunc("GET /v1/forecast/changes", h.Changes)
+}
+
+// historyCell identifies the grid cell a history response is for.
+type historyCell struct {
+	Coords struct {
+		Lat float64 `json:"lat" xml:"lat"`
+		Lon float64 `json:"lon" xml:"lon"`
+	} `json:"coords" xml:"coords"`
+	GridCell string `json:"gridCell" xml:"gridCell"`
+}
+
+// historyWindow is the body of a GET /v1/history/forecast window query.
+type historyWindow struct {
+	historyCell
+	From      time.Time          `json:"from" xml:"from"`
+	To        time.Time          `json:"to" xml:"to"`
+	Forecasts []history.Forecast `json:"forecasts" xml:"forecast"`
+}
+
+// historyAsOf is the body of a GET /v1/history/forecast as-issued query.
+type historyAsOf struct {
+	historyCell
+	At       time.Time        `json:"at" xml:"at"`
+	Forecast history.Forecast `json:"forecast" xml:"forecast"`
+}
+
+// Forecast handles GET /v1/history/forecast?lat=&lon=[&from=&to=|&at=]. With
+// from and to (RFC 3339; to defaults to now and from to a day before to) it
+// returns every archived forecast in effect during the window, oldest first.
+// With at it returns the forecast in effect at that time, as it was issued, or
+// 404 when none was archived yet. Responses are in the negotiated format and
+// honour conditional requests.
+func (h *HistoryHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiate(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	lat, lon, err := parseLatLon(q.Get("lat"), q.Get("lon"))
+	if err != nil {
+		renderErr(w, format, http.StatusBadRequest, err)
+		return
+	}
+	var cell historyCell
+	cell.Coords.Lat, cell.Coords.Lon = lat, lon
+
+	if q.Has("at") {
+		if q.Has("from") || q.Has("to") {
+			renderErr(w, format, http.StatusBadRequest, errors.New("at cannot be combined with from or to"))
+			return
+		}
+		at, perr := parseTime(q, "at", time.Time{})
+		if perr != nil {
+			renderErr(w, format, http.StatusBadRequest, perr)
+			return
+		}
+		if cell.GridCell, err = h.cells(r.Context(), lat, lon); err != nil {
+			renderErr(w, format, http.StatusBadGateway, err)
+			return
+		}
+		fc, ferr := h.store.AsOf(cell.GridCell, at)
+		if errors.Is(ferr, history.ErrNotFound) {
+			renderErr(w, format, http.StatusNotFound, fmt.Errorf("%w at %s", ferr, at.Format(time.RFC3339)))
+			return
+		}
+		if ferr != nil {
+			renderErr(w, format, http.StatusInternalServerError, ferr)
+			return
+		}
+		res := historyAsOf{historyCell: cell, At: at, Forecast: fc}
+		if notModified(w, r, etagOf(format, res), nil) {
+			return
+		}
+		render(w, format, http.StatusOK, res)
+		return
+	}
+
+	to, err := parseTime(q, "to", h.now())
+	if err != nil {
+		renderErr(w, format, http.StatusBadRequest, err)
+		return
+	}
+	from, err := parseTime(q, "from", to.Add(-historyWindowDefault))
+	if err != nil {
+		renderErr(w, format, http.StatusBadRequest, err)
+		return
+	}
+	if !from.Before(to) || to.Sub(from) > historyWindowMax {
+		renderErr(w, format, http.StatusBadRequest, fmt.Errorf("from must be before to and at most %d days earlier", historyWindowMax/(24*time.Hour)))
+		return
+	}
+	if cell.GridCell, err = h.cells(r.Context(), lat, lon); err != nil {
+		renderErr(w, format, http.StatusBadGateway, err)
+		return
+	}
+	fcs, err := h.store.Range(cell.GridCell, from, to)
+	if err != nil {
+		renderErr(w, format, http.StatusInternalServerError, err)
+		return
+	}
+	if fcs == nil {
+		fcs = []history.Forecast{}
+	}
+	res := historyWindow{historyCell: cell, From: from, To: to, Forecasts: fcs}
+	if notModified(w, r, etagOf(format, res), nil) {
+		return
+	}
+	render(w, format, http.StatusOK, res)
+}
+
+// historyChanges is the body of a GET /v1/forecast/changes response.
+type historyChanges struct {
+	historyCell
+	Since     time.Time         `json:"since" xml:"since"`
+	Threshold int               `json:"threshold" xml:"threshold"`
+	Changes   []forecast.Change `json:"changes" xml:"change"`
+}
+
+// Changes handles GET /v1/forecast/changes?lat=&lon=[&since=&threshold=]. It
+// diffs each archived forecast issued after since (RFC 3339, default a day
+// ago) against the one it replaced, oldest first. threshold is how many
+// degrees a period's temperature must move to be reported (default 3).
+// Responses are in the negotiated format and honour conditional requests.
+func (h *HistoryHandler) Changes(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiate(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	lat, lon, err := parseLatLon(q.Get("lat"), q.Get("lon"))
+	if err != nil {
+		renderErr(w, format, http.StatusBadRequest, err)
+		return
+	}
+	var cell historyCell
+	cell.Coords.Lat, cell.Coords.Lon = lat, lon
+
+	now := h.now().UTC()
+	since, err := parseTime(q, "since", now.Add(-historyWindowDefault))
+	if err != nil {
+		renderErr(w, format, http.StatusBadRequest, err)
+		return
+	}
+	if !since.Before(now) || now.Sub(since) > historyWindowMax {
+		renderErr(w, format, http.StatusBadRequest, fmt.Errorf("since must be in the past and at most %d days ago", historyWindowMax/(24*time.Hour)))
+		return
+	}
+	threshold := forecast.ChangeThresholdDefault
+	if v := q.Get("threshold"); v != "" {
+		if threshold, err = strconv.Atoi(v); err != nil || threshold < 0 {
+			renderErr(w, format, http.StatusBadRequest, errors.New("invalid threshold (want a non-negative number of degrees)"))
+			return
+		}
+	}
+	if cell.GridCell, err = h.cells(r.Context(), lat, lon); err != nil {
+		renderErr(w, format, http.StatusBadGateway, err)
+		return
+	}
+	// The window starts with the forecast in effect at since, which the first
+	// update after since is compared with.
+	fcs, err := h.store.Range(cell.GridCell, since, now)
+	if err != nil {
+		renderErr(w, format, http.StatusInternalServerError, err)
+		return
+	}
+	res := historyChanges{historyCell: cell, Since: since, Threshold: threshold, Changes: []forecast.Change{}}
+	bands := h.bands.Load()
+	for i := 1; i < len(fcs); i++ {
+		res.Changes = append(res.Changes, forecast.Diff(fcs[i-1].Document(), fcs[i].Document(), bands, threshold))
+	}
+	if notModified(w, r, etagOf(format, res), nil) {
+		return
+	}
+	render(w, format, http.StatusOK, res)
+}
+
+// parseTime parses the RFC 3339 query parameter name, or returns def when it
+// is absent and def is set.
+func parseTime(q map[string][]string, name string, def time.Time) (time.Time, error) {
+	vs, ok := q[name]
+	if !ok || len(vs) == 0 {
+		if def.IsZero() {
+			return time.Time{}, fmt.Errorf("%s is required", name)
+		}
+		return def.UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, vs[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s (want RFC 3339, e.g. 2025-08-13T06:00:00-06:00)", name)
+	}
+	return t.UTC(), nil
+}
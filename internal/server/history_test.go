@@ -0,0 +1,231 @@
+package server_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/history"
+	"weather-service/internal/nws"
+	"weather-service/internal/server"
+)
+
+func newHistoryMux(t *testing.T, f *fakeSvc) *http.ServeMux {
+	t.Helper()
+	mux, _ := newHistoryHandler(t, f)
+	return mux
+}
+
+func newHistoryHandler(t *testing.T, f *fakeSvc) (*http.ServeMux, *server.HistoryHandler) {
+	t.Helper()
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.db"), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	issued := time.Date(2025, 8, 13, 6, 0, 0, 0, time.UTC)
+	for i, temp := range []int{80, 84} {
+		var fc nws.Forecast
+		fc.Properties.Updated = issued.Add(time.Duration(i) * 6 * time.Hour)
+		fc.Properties.Periods = []nws.Period{{Name: "Today", Temperature: temp}}
+		if _, err = store.Put("grid:40,-105", fc); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+	// A recent update of another cell warmed today and added tonight.
+	now := time.Now().UTC().Truncate(time.Hour)
+	today := nws.Period{Name: "Today", StartTime: now.Add(-time.Hour), EndTime: now.Add(11 * time.Hour), Temperature: 70}
+	tonight := nws.Period{Name: "Tonight", StartTime: now.Add(11 * time.Hour), EndTime: now.Add(23 * time.Hour), Temperature: 60}
+	for i, periods := range [][]nws.Period{{today}, {{Name: "Today", StartTime: today.StartTime, EndTime: today.EndTime, Temperature: 74}, tonight}} {
+		var fc nws.Forecast
+		fc.Properties.Updated = now.Add(time.Duration(i-3) * time.Hour)
+		fc.Properties.Periods = periods
+		if _, err = store.Put("grid:41,-105", fc); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+	mux := http.NewServeMux()
+	bands := forecast.NewBandsVar(forecast.Bands{ColdMax: 45, HotMin: 85})
+	h := server.NewHistoryHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), f.GridCell, store, bands)
+	h.Register(mux)
+	return mux, h
+}
+
+type historyBody struct {
+	GridCell  string             `json:"gridCell"`
+	From      time.Time          `json:"from"`
+	Forecasts []history.Forecast `json:"forecasts"`
+	Forecast  *history.Forecast  `json:"forecast"`
+}
+
+func TestHistoryForecast(t *testing.T) {
+	mux := newHistoryMux(t, &fakeSvc{})
+
+	rec := serve(mux, http.MethodGet, "/v1/history/forecast?lat=40&lon=-105&from=2025-08-13T08:00:00Z&to=2025-08-13T13:00:00Z")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body)
+	}
+	got := decodeBody[historyBody](t, rec.Body.Bytes())
+	if got.GridCell != "grid:40,-105" || len(got.Forecasts) != 2 || got.Forecasts[1].Periods[0].Temperature != 84 {
+		t.Fatalf("unexpected body: %s", rec.Body)
+	}
+
+	// to defaults to now and from to a day earlier.
+	rec = serve(mux, http.MethodGet, "/v1/history/forecast?lat=40&lon=-105")
+	if got = decodeBody[historyBody](t, rec.Body.Bytes()); rec.Code != http.StatusOK || time.Since(got.From) < 23*time.Hour {
+		t.Fatalf("status=%d from=%v", rec.Code, got.From)
+	}
+
+	rec = serve(mux, http.MethodGet, "/v1/history/forecast?lat=40&lon=-105&at=2025-08-13T10:00:00-06:00")
+	if got = decodeBody[historyBody](t, rec.Body.Bytes()); rec.Code != http.StatusOK || got.Forecast == nil || got.Forecast.Periods[0].Temperature != 84 {
+		t.Fatalf("as issued: status=%d body=%s", rec.Code, rec.Body)
+	}
+	if rec = serve(mux, http.MethodGet, "/v1/history/forecast?lat=40&lon=-105&at=2025-08-12T00:00:00Z"); rec.Code != http.StatusNotFound {
+		t.Fatalf("before the archive: status=%d", rec.Code)
+	}
+}
+
+func TestHistoryDefaultWindowsFollowClock(t *testing.T) {
+	mux, h := newHistoryHandler(t, &fakeSvc{})
+	now := time.Date(2025, 8, 13, 14, 0, 0, 0, time.UTC)
+	server.SetHistoryNow(h, func() time.Time { return now })
+
+	rec := serve(mux, http.MethodGet, "/v1/history/forecast?lat=40&lon=-105")
+	got := decodeBody[historyBody](t, rec.Body.Bytes())
+	if rec.Code != http.StatusOK || !got.From.Equal(now.Add(-24*time.Hour)) || len(got.Forecasts) != 2 {
+		t.Fatalf("window: status=%d body=%s", rec.Code, rec.Body)
+	}
+	rec = serve(mux, http.MethodGet, "/v1/forecast/changes?lat=40&lon=-105")
+	if changes := decodeBody[changesBody](t, rec.Body.Bytes()); rec.Code != http.StatusOK || len(changes.Changes) != 1 {
+		t.Fatalf("changes: status=%d body=%s", rec.Code, rec.Body)
+	}
+
+	// A day later the default window holds only the forecast still in effect.
+	now = now.Add(24 * time.Hour)
+	rec = serve(mux, http.MethodGet, "/v1/forecast/changes?lat=40&lon=-105")
+	if changes := decodeBody[changesBody](t, rec.Body.Bytes()); rec.Code != http.StatusOK || len(changes.Changes) != 0 {
+		t.Fatalf("changes a day later: status=%d body=%s", rec.Code, rec.Body)
+	}
+}
+
+func TestHistoryNegotiationAndConditionalRequests(t *testing.T) {
+	mux := newHistoryMux(t, &fakeSvc{})
+	get := func(target string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The default window moves with the clock; the archived forecasts do not.
+	for _, target := range []string{"/v1/history/forecast?lat=40&lon=-105", "/v1/forecast/changes?lat=41&lon=-105"} {
+		rec := get(target)
+		etag := rec.Header().Get("ETag")
+		if rec.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) || rec.Header().Get("Cache-Control") != "no-cache" {
+			t.Fatalf("%s: status=%d headers=%v", target, rec.Code, rec.Header())
+		}
+		time.Sleep(time.Millisecond)
+		if rec = get(target, "If-None-Match", etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Fatalf("%s: If-None-Match status=%d body=%q", target, rec.Code, rec.Body)
+		}
+		if rec = get(target, "Accept", "text/csv", "If-None-Match", etag); rec.Code != http.StatusOK {
+			t.Fatalf("%s: other format status=%d", target, rec.Code)
+		}
+	}
+
+	rec := get("/v1/history/forecast?lat=40&lon=-105&at=2025-08-13T10:00:00-06:00", "Accept", "application/xml")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/xml") ||
+		!strings.Contains(rec.Body.String(), "<history>") || !strings.Contains(rec.Body.String(), "<temperature>84</temperature>") {
+		t.Fatalf("xml: status=%d body=%s", rec.Code, rec.Body)
+	}
+	rec = get("/v1/history/forecast?lat=40&lon=-105&from=2025-08-13T08:00:00Z&to=2025-08-13T13:00:00Z&format=csv")
+	if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); rec.Code != http.StatusOK || len(lines) != 3 ||
+		!strings.HasPrefix(lines[0], "lat,lon,gridCell,issuedAt") || !strings.Contains(lines[2], ",84,") {
+		t.Fatalf("csv: status=%d body=%s", rec.Code, rec.Body)
+	}
+	rec = get("/v1/forecast/changes?lat=41&lon=-105&format=csv")
+	if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); rec.Code != http.StatusOK || len(lines) != 3 ||
+		!strings.Contains(lines[1], ",changed,") || !strings.Contains(lines[2], ",added,") {
+		t.Fatalf("changes csv: status=%d body=%s", rec.Code, rec.Body)
+	}
+	if rec = get("/v1/history/forecast?lat=x&lon=-105", "Accept", "application/xml"); rec.Code != http.StatusBadRequest ||
+		!strings.Contains(rec.Body.String(), "<error>") {
+		t.Fatalf("xml error: status=%d body=%s", rec.Code, rec.Body)
+	}
+	if rec = get("/v1/forecast/changes?lat=41&lon=-105", "Accept", "image/png"); rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("unsupported Accept: status=%d", rec.Code)
+	}
+}
+
+func TestHistoryForecastBadParams(t *testing.T) {
+	mux := newHistoryMux(t, &fakeSvc{})
+	for _, url := range []string{
+		"/v1/history/forecast?lat=x&lon=-105",
+		"/v1/history/forecast?lat=40&lon=-105&from=yesterday",
+		"/v1/history/forecast?lat=40&lon=-105&from=2025-08-14T00:00:00Z&to=2025-08-13T00:00:00Z",
+		"/v1/history/forecast?lat=40&lon=-105&from=2025-06-01T00:00:00Z&to=2025-08-13T00:00:00Z",
+		"/v1/history/forecast?lat=40&lon=-105&at=2025-08-13T00:00:00Z&to=2025-08-13T00:00:00Z",
+	} {
+		if rec := serve(mux, http.MethodGet, url); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status=%d want 400", url, rec.Code)
+		}
+	}
+}
+
+type changesBody struct {
+	GridCell  string            `json:"gridCell"`
+	Threshold int               `json:"threshold"`
+	Changes   []forecast.Change `json:"changes"`
+}
+
+func TestForecastChanges(t *testing.T) {
+	mux := newHistoryMux(t, &fakeSvc{})
+
+	rec := serve(mux, http.MethodGet, "/v1/forecast/changes?lat=41&lon=-105")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body)
+	}
+	got := decodeBody[changesBody](t, rec.Body.Bytes())
+	if got.GridCell != "grid:41,-105" || got.Threshold != forecast.ChangeThresholdDefault || len(got.Changes) != 1 {
+		t.Fatalf("unexpected body: %s", rec.Body)
+	}
+	periods := got.Changes[0].Periods
+	if len(periods) != 2 || periods[0].Status != forecast.ChangeUpdated || periods[0].TemperatureDelta != 4 ||
+		periods[1].Status != forecast.ChangeAdded || periods[1].After.Name != "Tonight" {
+		t.Fatalf("unexpected periods: %s", rec.Body)
+	}
+
+	// A 4° warming is below a threshold of 5.
+	rec = serve(mux, http.MethodGet, "/v1/forecast/changes?lat=41&lon=-105&threshold=5")
+	if got = decodeBody[changesBody](t, rec.Body.Bytes()); rec.Code != http.StatusOK || len(got.Changes[0].Periods) != 1 {
+		t.Fatalf("threshold 5: status=%d body=%s", rec.Code, rec.Body)
+	}
+
+	// Updates since the latest one: none.
+	since := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	rec = serve(mux, http.MethodGet, "/v1/forecast/changes?lat=41&lon=-105&since="+since)
+	if got = decodeBody[changesBody](t, rec.Body.Bytes()); rec.Code != http.StatusOK || got.Changes == nil || len(got.Changes) != 0 {
+		t.Fatalf("no updates: status=%d body=%s", rec.Code, rec.Body)
+	}
+
+	for _, url := range []string{
+		"/v1/forecast/changes?lat=41",
+		"/v1/forecast/changes?lat=41&lon=-105&since=yesterday",
+		"/v1/forecast/changes?lat=41&lon=-105&since=2025-01-01T00:00:00Z",
+		"/v1/forecast/changes?lat=41&lon=-105&threshold=-1",
+	} {
+		if rec := serve(mux, http.MethodGet, url); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status=%d want 400", url, rec.Code)
+		}
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"weather-service/internal/forecast"
+	"weather-service/internal/health"
 	"weather-service/internal/version"
 )
 
@@ -27,27 +29,72 @@ func NewHandler(log *slog.Logger, svc forecast.Service) *Handler {
 func (h *Handler) Routes() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /v1/forecast", h.GetForecast)
+	mux.HandleFunc("GET /v1/forecast/daily/{date}", h.GetDailyForecast)
 	mux.HandleFunc("GET /healthz", h.Health)
 	return mux
 }
 
-// GetForecast handles GET /v1/forecast returning today's forecast.
+// GetForecast handles GET /v1/forecast returning today's forecast in the
+// negotiated format (see negotiate). Like every forecast read it carries caching
+// headers and honours conditional requests (see notModified).
 func (h *Handler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiate(w, r)
+	if !ok {
+		return
+	}
 	latStr := r.URL.Query().Get("lat")
 	lonStr := r.URL.Query().Get("lon")
 	lat, lon, err := parseLatLon(latStr, lonStr)
 	if err != nil {
-		writeErr(w, http.StatusBadRequest, err)
+		renderErr(w, format, http.StatusBadRequest, err)
 		return
 	}
 
 	res, err := h.svc.GetTodaysForcast(r.Context(), lat, lon)
 	if err != nil {
-		writeErr(w, http.StatusBadGateway, err)
+		renderErr(w, format, http.StatusBadGateway, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, res)
+	if notModified(w, r, etagOf(format, res), res.Meta) {
+		return
+	}
+	render(w, format, http.StatusOK, res)
+}
+
+// GetDailyForecast handles GET /v1/forecast/daily/{date} returning the daytime and
+// overnight periods for a local calendar date (YYYY-MM-DD) at the location, in the
+// negotiated format.
+func (h *Handler) GetDailyForecast(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiate(w, r)
+	if !ok {
+		return
+	}
+	lat, lon, err := parseLatLon(r.URL.Query().Get("lat"), r.URL.Query().Get("lon"))
+	if err != nil {
+		renderErr(w, format, http.StatusBadRequest, err)
+		return
+	}
+	date, err := time.Parse("2006-01-02", r.PathValue("date"))
+	if err != nil {
+		renderErr(w, format, http.StatusBadRequest, errors.New("invalid date (want YYYY-MM-DD)"))
+		return
+	}
+
+	res, err := h.svc.GetDailyForecast(r.Context(), lat, lon, date)
+	if errors.Is(err, forecast.ErrDateOutOfRange) {
+		renderErr(w, format, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		renderErr(w, format, http.StatusBadGateway, err)
+		return
+	}
+
+	if notModified(w, r, etagOf(format, res), res.Meta) {
+		return
+	}
+	render(w, format, http.StatusOK, res)
 }
 
 // Health handles GET /healthz returning a simple health status.
@@ -58,6 +105,33 @@ func (h *Handler) Health(w http.ResponseWriter, _ *http.Request) {
 	})
 }
 
+// ReadyHandler serves the readiness report from r: 200 when every check passes and
+// 503 otherwise. The body maps each check to "ok" or "failing"; with ?verbose the
+// per-check details are included as well.
+func ReadyHandler(r *health.Readiness) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		rep := r.Check(req.Context())
+		status, code := "ready", http.StatusOK
+		if !rep.Ready {
+			status, code = "not ready", http.StatusServiceUnavailable
+		}
+		checks := make(map[string]string, len(rep.Checks))
+		details := make(map[string]string, len(rep.Checks))
+		for _, c := range rep.Checks {
+			checks[c.Name] = "ok"
+			if !c.OK {
+				checks[c.Name] = "failing"
+			}
+			details[c.Name] = c.Detail
+		}
+		body := map[string]any{"status": status, "checks": checks}
+		if req.URL.Query().Has("verbose") {
+			body["details"] = details
+		}
+		writeJSON(w, code, body)
+	}
+}
+
 func parseLatLon(latStr, lonStr string) (float64, float64, error) {
 	lat, err := strconv.ParseFloat(latStr, 64)
 	if err != nil || math.IsNaN(lat) || lat < -90 || lat > 90 {
@@ -71,10 +145,7 @@ func parseLatLon(latStr, lonStr string) (float64, float64, error) {
 }
 
 func writeErr(w http.ResponseWriter, code int, err error) {
-	writeJSON(w, code, map[string]any{
-		"error": http.StatusText(code),
-		"msg":   err.Error(),
-	})
+	writeJSON(w, code, errorBody{Error: http.StatusText(code), Msg: err.Error()})
 }
 
 func writeJSON(w http.ResponseWriter, code int, v any) {
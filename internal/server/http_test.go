@@ -4,20 +4,28 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"weather-service/internal/forecast"
+	"weather-service/internal/health"
 	"weather-service/internal/server"
 	"weather-service/internal/version"
 )
 
 type fakeSvc struct {
-	res    forecast.Result
-	err    error
-	gotLat float64
-	gotLon float64
+	res     forecast.Result
+	daily   forecast.DailyResult
+	periods forecast.PeriodsResult
+	err     error
+	gotLat  float64
+	gotLon  float64
+	gotDate time.Time
 }
 
 func (f *fakeSvc) GetTodaysForcast(_ context.Context, lat, lon float64) (forecast.Result, error) {
@@ -25,6 +33,35 @@ func (f *fakeSvc) GetTodaysForcast(_ context.Context, lat, lon float64) (forecas
 	return f.res, f.err
 }
 
+func (f *fakeSvc) GridCell(_ context.Context, lat, lon float64) (string, error) {
+	return fmt.Sprintf("grid:%.0f,%.0f", lat, lon), f.err
+}
+
+func (f *fakeSvc) Purge(lat, lon float64) int {
+	f.gotLat, f.gotLon = lat, lon
+	return 2
+}
+
+func (f *fakeSvc) Refresh(_ context.Context, lat, lon float64) (forecast.Result, error) {
+	f.gotLat, f.gotLon = lat, lon
+	return f.res, f.err
+}
+
+func (f *fakeSvc) GetDailyForecast(_ context.Context, lat, lon float64, date time.Time) (forecast.DailyResult, error) {
+	f.gotLat, f.gotLon, f.gotDate = lat, lon, date
+	return f.daily, f.err
+}
+
+func (f *fakeSvc) GetPeriods(_ context.Context, lat, lon float64) (forecast.PeriodsResult, error) {
+	f.gotLat, f.gotLon = lat, lon
+	return f.periods, f.err
+}
+
+func (f *fakeSvc) GetHourlyForecast(_ context.Context, lat, lon float64, _ int) (forecast.PeriodsResult, error) {
+	f.gotLat, f.gotLon = lat, lon
+	return f.periods, f.err
+}
+
 func newHandlerWithFake(t *testing.T, f *fakeSvc) *server.Handler {
 	t.Helper()
 	return server.NewHandler(nil, f)
@@ -142,3 +179,195 @@ func TestGetForecast_UpstreamError(t *testing.T) {
 		t.Fatalf("error field = %v", m["error"])
 	}
 }
+
+func TestGetDailyForecast_Success(t *testing.T) {
+	fake := &fakeSvc{daily: forecast.DailyResult{Date: "2025-08-15", Source: "testsrc"}}
+	fake.daily.High = &forecast.Temperature{Value: 88, Unit: "F", Type: "hot"}
+	h := newHandlerWithFake(t, fake)
+	mux := h.Routes()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/forecast/daily/2025-08-15?lat=10&lon=20", nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d want %d", rec.Code, http.StatusOK)
+	}
+	got := decodeBody[forecast.DailyResult](t, rec.Body.Bytes())
+	if got.Date != "2025-08-15" || got.High == nil || got.High.Value != 88 || got.Low != nil {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+	if y, m, d := fake.gotDate.Date(); y != 2025 || m != time.August || d != 15 {
+		t.Fatalf("service received date %v", fake.gotDate)
+	}
+}
+
+func TestGetDailyForecast_Errors(t *testing.T) {
+	cases := []struct {
+		url  string
+		err  error
+		want int
+	}{
+		{"/v1/forecast/daily/2025-13-01?lat=1&lon=2", nil, http.StatusBadRequest},
+		{"/v1/forecast/daily/tomorrow?lat=1&lon=2", nil, http.StatusBadRequest},
+		{"/v1/forecast/daily/2025-08-15?lat=x&lon=2", nil, http.StatusBadRequest},
+		{"/v1/forecast/daily/2030-01-01?lat=1&lon=2", forecast.ErrDateOutOfRange, http.StatusNotFound},
+		{"/v1/forecast/daily/2025-08-15?lat=1&lon=2", context.DeadlineExceeded, http.StatusBadGateway},
+	}
+	for _, c := range cases {
+		h := newHandlerWithFake(t, &fakeSvc{err: c.err})
+		req := httptest.NewRequest(http.MethodGet, c.url, nil)
+		rec := httptest.NewRecorder()
+		h.Routes().ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Fatalf("%s: status=%d want %d", c.url, rec.Code, c.want)
+		}
+		m := decodeBody[map[string]any](t, rec.Body.Bytes())
+		if m["error"] != http.StatusText(c.want) {
+			t.Fatalf("%s: error field = %v", c.url, m["error"])
+		}
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	r := health.NewReadiness()
+	r.Add("upstream", func(context.Context) (string, error) { return "all good", nil })
+
+	rec := httptest.NewRecorder()
+	server.ReadyHandler(r).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d want %d", rec.Code, http.StatusOK)
+	}
+	m := decodeBody[map[string]any](t, rec.Body.Bytes())
+	if m["status"] != "ready" || m["details"] != nil {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	r.SetShuttingDown()
+	rec = httptest.NewRecorder()
+	server.ReadyHandler(r).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status=%d want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	m = decodeBody[map[string]any](t, rec.Body.Bytes())
+	checks, _ := m["checks"].(map[string]any)
+	details, _ := m["details"].(map[string]any)
+	if checks["shutdown"] != "failing" || checks["upstream"] != "ok" || details["upstream"] != "all good" {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	fake := &fakeSvc{res: forecast.Result{Source: "testsrc", Date: "2025-08-15"}}
+	fake.res.Today = forecast.PeriodSummary{Name: "Today", ShortForecast: "Sunny, Breezy",
+		Temperature: forecast.Temperature{Value: 75, Unit: "F", Type: "moderate"}}
+	fake.daily = forecast.DailyResult{Day: &fake.res.Today, Night: &forecast.PeriodSummary{Name: "Tonight"}}
+	mux := newHandlerWithFake(t, fake).Routes()
+
+	for _, tc := range []struct {
+		name, target, accept string
+		wantCode             int
+		wantType             string
+		wantBody             []string
+	}{
+		{"default json", "/v1/forecast?lat=1&lon=2", "", 200, "application/json", []string{`"source":"testsrc"`}},
+		{"xml", "/v1/forecast?lat=1&lon=2", "application/xml", 200, "application/xml",
+			[]string{"<forecast>", "<source>testsrc</source>", "<type>moderate</type>"}},
+		{"q values", "/v1/forecast?lat=1&lon=2", "text/csv;q=0.5, text/xml", 200, "application/xml", []string{"<forecast>"}},
+		{"csv", "/v1/forecast?lat=1&lon=2&format=csv", "application/json", 200, "text/csv",
+			[]string{"lat,lon,date,", "\n0,0,2025-08-15,,today,Today,75,F,moderate,\"Sunny, Breezy\",testsrc,\n"}},
+		{"daily csv rows", "/v1/forecast/daily/2025-08-15?lat=1&lon=2&format=csv", "", 200, "text/csv",
+			[]string{",day,Today,", ",night,Tonight,"}},
+		{"text", "/v1/forecast?lat=1&lon=2", "text/plain", 200, "text/plain", []string{"Today:", "75°F (moderate)", "Sunny"}},
+		{"text error", "/v1/forecast?lat=x&lon=2&format=text", "", 400, "text/plain", []string{"Bad Request: invalid lat"}},
+		{"unsupported accept", "/v1/forecast?lat=1&lon=2", "text/html", 406, "application/json", []string{"supported formats"}},
+		{"unknown format", "/v1/forecast?lat=1&lon=2&format=yaml", "", 406, "application/json", []string{`unknown format \"yaml\"`}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tc.wantCode || !strings.HasPrefix(rec.Header().Get("Content-Type"), tc.wantType) {
+				t.Fatalf("status=%d type=%q; want %d %s; body=%s", rec.Code, rec.Header().Get("Content-Type"),
+					tc.wantCode, tc.wantType, rec.Body.String())
+			}
+			for _, want := range tc.wantBody {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Fatalf("body missing %q:\n%s", want, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	fake := &fakeSvc{res: forecast.Result{Source: "testsrc", LocalTime: "2025-08-15T09:00:00-06:00"}}
+	fake.res.Meta = &forecast.Meta{Updated: "2025-08-15T11:00:00Z", Expires: time.Now().Add(90 * time.Second),
+		Modified: time.Date(2025, 8, 15, 12, 0, 0, 0, time.UTC)}
+	mux := newHandlerWithFake(t, fake).Routes()
+	get := func(target string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/v1/forecast?lat=1&lon=2")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("status=%d ETag=%q", rec.Code, etag)
+	}
+	if lm := rec.Header().Get("Last-Modified"); lm != "Fri, 15 Aug 2025 12:00:00 GMT" {
+		t.Fatalf("Last-Modified=%q want the newest input", lm)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=90" && cc != "public, max-age=89" {
+		t.Fatalf("Cache-Control=%q", cc)
+	}
+
+	// The local time moves on but the forecast is the same.
+	fake.res.LocalTime = "2025-08-15T09:00:05-06:00"
+	if rec = get("/v1/forecast?lat=1&lon=2", "If-None-Match", `"other", `+etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match status=%d", rec.Code)
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("ETag") != etag {
+		t.Fatalf("304 body=%q ETag=%q", rec.Body.String(), rec.Header().Get("ETag"))
+	}
+	if rec = get("/v1/forecast?lat=1&lon=2&format=xml", "If-None-Match", etag); rec.Code != http.StatusOK {
+		t.Fatalf("other format status=%d", rec.Code)
+	}
+	if rec = get("/v1/forecast?lat=1&lon=2", "If-Modified-Since", "Fri, 15 Aug 2025 12:00:00 GMT"); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since status=%d", rec.Code)
+	}
+	if rec = get("/v1/forecast?lat=1&lon=2", "If-Modified-Since", "Fri, 15 Aug 2025 11:59:59 GMT"); rec.Code != http.StatusOK {
+		t.Fatalf("older If-Modified-Since status=%d", rec.Code)
+	}
+
+	// Without a modification time only the ETag validates.
+	fake.res.Meta.Modified = time.Time{}
+	if rec = get("/v1/forecast?lat=1&lon=2", "If-Modified-Since", "Fri, 15 Aug 2025 12:00:00 GMT"); rec.Code != http.StatusOK ||
+		rec.Header().Get("Last-Modified") != "" {
+		t.Fatalf("no modification time: status=%d Last-Modified=%q", rec.Code, rec.Header().Get("Last-Modified"))
+	}
+
+	fake.res.Today.ShortForecast = "Rain"
+	if rec = get("/v1/forecast?lat=1&lon=2", "If-None-Match", etag); rec.Code != http.StatusOK {
+		t.Fatalf("changed forecast status=%d", rec.Code)
+	}
+
+	fake.res.Meta.Expires = time.Time{}
+	if cc := get("/v1/forecast?lat=1&lon=2").Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Fatalf("uncached Cache-Control=%q", cc)
+	}
+	fake.err = errors.New("upstream down")
+	if rec = get("/v1/forecast?lat=1&lon=2"); rec.Header().Get("ETag") != "" || rec.Header().Get("Cache-Control") != "" {
+		t.Fatalf("error response has caching headers: %v", rec.Header())
+	}
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// MetricsWriter writes metric families in the Prometheus text exposition format.
+type MetricsWriter interface {
+	WriteMetrics(w io.Writer) error
+}
+
+// MetricsHandler serves GET /metrics with the families of every source, in order.
+func MetricsHandler(log *slog.Logger, sources ...MetricsWriter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, s := range sources {
+			if err := s.WriteMetrics(w); err != nil {
+				log.WarnContext(r.Context(), "write metrics", "err", err)
+				return
+			}
+		}
+	})
+}
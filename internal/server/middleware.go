@@ -2,20 +2,24 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
-)
-
-type ctxKey int
 
-const reqIDKey ctxKey = 0
+	logpkg "weather-service/internal/log"
+	"weather-service/internal/ratelimit"
+)
 
-// WithMiddleware wraps the provided handler with standard middleware (logging, recovery, request ID).
-func WithMiddleware(next http.Handler) http.Handler {
-	return requestID(recoverer(logger(next)))
+// WithMiddleware wraps the provided handler with standard middleware (compression,
+// request ID, caller identity, recovery, logging). compressionLevel is 1 (fastest) to 9
+// (smallest), or 0 to send responses uncompressed.
+func WithMiddleware(next http.Handler, compressionLevel int) http.Handler {
+	return compress(compressionLevel)(requestID(callerIdentity(recoverer(logger(next)))))
 }
 
 // logger logs the request and response.
@@ -24,14 +28,12 @@ func logger(next http.Handler) http.Handler {
 		start := time.Now()
 		ww := &responseWriter{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(ww, r)
-		reqID := GetRequestID(r.Context())
-		slogger := slog.Default()
-		slogger.Info("http_request",
+		// request_id is attached by the log handler from the request context.
+		slog.Default().InfoContext(r.Context(), "http_request",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status", ww.status,
 			"duration_ms", time.Since(start).Milliseconds(),
-			"request_id", reqID,
 		)
 	})
 }
@@ -41,7 +43,7 @@ func recoverer(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
-				slog.Default().Error("panic recovered", "err", rec)
+				slog.Default().ErrorContext(r.Context(), "panic recovered", "err", rec)
 				writeErr(w, http.StatusInternalServerError, http.ErrAbortHandler)
 			}
 		}()
@@ -56,18 +58,50 @@ func requestID(next http.Handler) http.Handler {
 		if id == "" {
 			id = genID()
 		}
-		ctx := context.WithValue(r.Context(), reqIDKey, id)
+		ctx := logpkg.WithRequestID(r.Context(), id)
 		w.Header().Set("X-Request-ID", id)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// callerIdentity records the subject of a verified client certificate as the
+// caller identity (see logpkg.WithCaller).
+func callerIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			subject := r.TLS.VerifiedChains[0][0].Subject.String()
+			r = r.WithContext(logpkg.WithCaller(r.Context(), subject))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithRateLimit answers 429 Too Many Requests, with a Retry-After header, to
+// callers over limiter's limit (see ratelimit.Key). /healthz and /readyz are
+// never limited, so probes keep working however busy their address is. With a
+// nil limiter it returns next unchanged. The caller identity is read from the
+// request context, so next must be wrapped by WithMiddleware.
+func WithRateLimit(next http.Handler, limiter *ratelimit.Limiter) http.Handler {
+	if limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ok, wait := limiter.Allow(ratelimit.Key(r.Context(), r.RemoteAddr)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeErr(w, http.StatusTooManyRequests, errors.New("rate limit exceeded"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // GetRequestID extracts the request ID from context if present.
 func GetRequestID(ctx context.Context) string {
-	if v, ok := ctx.Value(reqIDKey).(string); ok {
-		return v
-	}
-	return ""
+	return logpkg.RequestID(ctx)
 }
 
 type responseWriter struct {
@@ -81,6 +115,18 @@ func (w *responseWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// Flush forwards to the underlying writer so streaming handlers work behind the middleware.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // genID generates a random ID using UUID v4.
 func genID() string {
 	return uuid.NewString()
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 
+	logpkg "weather-service/internal/log"
+	"weather-service/internal/ratelimit"
 	"weather-service/internal/server"
 )
 
@@ -23,7 +25,7 @@ func TestRequestIDGeneratedAndPropagated(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	})
 
-	h := server.WithMiddleware(handler)
+	h := server.WithMiddleware(handler, 0)
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	rec := httptest.NewRecorder()
@@ -54,7 +56,7 @@ func TestRequestIDUsesProvidedHeader(t *testing.T) {
 		w.WriteHeader(http.StatusNoContent)
 	})
 
-	h := server.WithMiddleware(handler)
+	h := server.WithMiddleware(handler, 0)
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	req.Header.Set("X-Request-ID", want)
@@ -70,16 +72,24 @@ func TestRequestIDUsesProvidedHeader(t *testing.T) {
 	}
 }
 
+// setDefaultLogger makes h the default slog handler until the test ends.
+func setDefaultLogger(t *testing.T, h slog.Handler) {
+	t.Helper()
+	prev := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+}
+
 func TestLoggerWritesStatusAndPath(t *testing.T) {
 	var buf bytes.Buffer
 	// Capture logs from middleware.logger via default logger
-	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	setDefaultLogger(t, slog.NewTextHandler(&buf, nil))
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusCreated)
 	})
 
-	h := server.WithMiddleware(handler)
+	h := server.WithMiddleware(handler, 0)
 
 	req := httptest.NewRequest(http.MethodGet, "/log-test", nil)
 	rec := httptest.NewRecorder()
@@ -104,7 +114,7 @@ func TestRecovererHandlesPanic(t *testing.T) {
 		panic("boom")
 	})
 
-	h := server.WithMiddleware(handler)
+	h := server.WithMiddleware(handler, 0)
 
 	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
 	rec := httptest.NewRecorder()
@@ -126,3 +136,59 @@ func TestRecovererHandlesPanic(t *testing.T) {
 		t.Fatalf("error field = %v", m["error"])
 	}
 }
+
+func TestLoggerIncludesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	setDefaultLogger(t, logpkg.NewHandler(&buf, logpkg.FormatJSON, slog.LevelInfo))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slog.Default().InfoContext(r.Context(), "inside handler")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-json")
+	server.WithMiddleware(handler, 0).ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid json log line %q: %v", line, err)
+		}
+		if rec["request_id"] != "req-json" {
+			t.Fatalf("request_id missing from %s", line)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	h := server.WithMiddleware(server.WithRateLimit(ok, ratelimit.New(1, 2)), 0)
+	get := func(path, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for range 2 {
+		if rec := get("/v1/forecast", "192.0.2.1:1000"); rec.Code != http.StatusNoContent {
+			t.Fatalf("within the burst: status=%d", rec.Code)
+		}
+	}
+	rec := get("/v1/forecast", "192.0.2.1:2000")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("over the burst: status=%d Retry-After=%q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec = get("/readyz", "192.0.2.1:3000"); rec.Code != http.StatusNoContent {
+		t.Fatalf("probe while limited: status=%d", rec.Code)
+	}
+	if rec = get("/v1/forecast", "192.0.2.2:1000"); rec.Code != http.StatusNoContent {
+		t.Fatalf("other address: status=%d", rec.Code)
+	}
+}
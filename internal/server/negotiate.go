@@ -0,0 +1,292 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/history"
+	"weather-service/internal/verify"
+)
+
+// Response formats of the forecast, history and verification endpoints.
+const (
+	formatJSON = "json"
+	formatXML  = "xml"
+	formatCSV  = "csv"
+	formatText = "text"
+)
+
+var contentTypes = map[string]string{
+	formatJSON: "application/json; charset=utf-8",
+	formatXML:  "application/xml; charset=utf-8",
+	formatCSV:  "text/csv; charset=utf-8",
+	formatText: "text/plain; charset=utf-8",
+}
+
+// mediaFormats maps Accept media ranges to formats. Wildcards pick the default
+// for their type.
+var mediaFormats = map[string]string{
+	"*/*":              formatJSON,
+	"application/*":    formatJSON,
+	"application/json": formatJSON,
+	"application/xml":  formatXML,
+	"text/xml":         formatXML,
+	"text/csv":         formatCSV,
+	"text/plain":       formatText,
+	"text/*":           formatText,
+}
+
+var errNotAcceptable = errors.New("supported formats: application/json, application/xml, text/csv, text/plain " +
+	"(or ?format=json|xml|csv|text)")
+
+// negotiate picks the response format from ?format= or else the Accept header,
+// defaulting to JSON. Otherwise it answers 406 and returns false.
+func negotiate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	w.Header().Add("Vary", "Accept")
+	if f := strings.ToLower(r.URL.Query().Get("format")); f != "" {
+		if f == "txt" {
+			f = formatText
+		}
+		if _, ok := contentTypes[f]; ok {
+			return f, true
+		}
+		writeErr(w, http.StatusNotAcceptable, fmt.Errorf("unknown format %q; %w", f, errNotAcceptable))
+		return "", false
+	}
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return formatJSON, true
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		f, ok := mediaFormats[mt]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, has := params["q"]; has {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = f, q
+		}
+	}
+	if best == "" {
+		writeErr(w, http.StatusNotAcceptable, errNotAcceptable)
+		return "", false
+	}
+	return best, true
+}
+
+// render writes a forecast payload in format.
+func render(w http.ResponseWriter, format string, code int, v any) {
+	if format == formatJSON {
+		writeJSON(w, code, v)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypes[format])
+	w.WriteHeader(code)
+	switch format {
+	case formatXML:
+		_, _ = io.WriteString(w, xml.Header)
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		_ = enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: rootElement(v)}})
+		_, _ = io.WriteString(w, "\n")
+	case formatCSV:
+		header, rows := table(v)
+		cw := csv.NewWriter(w)
+		_ = cw.Write(header)
+		_ = cw.WriteAll(rows)
+	case formatText:
+		writeText(w, v)
+	}
+}
+
+// renderErr writes an error in format.
+func renderErr(w http.ResponseWriter, format string, code int, err error) {
+	render(w, format, code, errorBody{Error: http.StatusText(code), Msg: err.Error()})
+}
+
+// errorBody is the payload of every error response.
+type errorBody struct {
+	Error string `json:"error" xml:"error"`
+	Msg   string `json:"msg" xml:"msg"`
+}
+
+func rootElement(v any) string {
+	switch v.(type) {
+	case forecast.Result, forecast.DailyResult:
+		return "forecast"
+	case historyWindow, historyAsOf:
+		return "history"
+	case historyChanges:
+		return "changes"
+	case verificationBody:
+		return "verification"
+	case errorBody:
+		return "error"
+	default:
+		return "response"
+	}
+}
+
+// table flattens a payload into CSV rows: one row per forecast period, per
+// archived or changed period, or per verification score.
+func table(v any) ([]string, [][]string) {
+	header := []string{"lat", "lon", "date", "timeZone", "period", "name", "temperature", "unit", "classification",
+		"shortForecast", "source", "updated"}
+	row := func(lat, lon float64, date, tz, kind string, p forecast.PeriodSummary, src string, m *forecast.Meta) []string {
+		return []string{ftoa(lat), ftoa(lon), date, tz, kind, p.Name, strconv.Itoa(p.Temperature.Value),
+			p.Temperature.Unit, p.Temperature.Type, p.ShortForecast, src, updated(m)}
+	}
+	switch r := v.(type) {
+	case forecast.Result:
+		return header, [][]string{row(r.Coords.Lat, r.Coords.Lon, r.Date, r.TimeZone, "today", r.Today, r.Source, r.Meta)}
+	case forecast.DailyResult:
+		var rows [][]string
+		if r.Day != nil {
+			rows = append(rows, row(r.Coords.Lat, r.Coords.Lon, r.Date, r.TimeZone, "day", *r.Day, r.Source, r.Meta))
+		}
+		if r.Night != nil {
+			rows = append(rows, row(r.Coords.Lat, r.Coords.Lon, r.Date, r.TimeZone, "night", *r.Night, r.Source, r.Meta))
+		}
+		return header, rows
+	case historyWindow:
+		return historyTable(r.historyCell, r.Forecasts)
+	case historyAsOf:
+		return historyTable(r.historyCell, []history.Forecast{r.Forecast})
+	case historyChanges:
+		return changesTable(r)
+	case verificationBody:
+		return verificationTable(r)
+	case errorBody:
+		return []string{"error", "msg"}, [][]string{{r.Error, r.Msg}}
+	default:
+		b, _ := json.Marshal(v)
+		return []string{"value"}, [][]string{{string(b)}}
+	}
+}
+
+// writeText renders a payload for reading in a terminal.
+func writeText(w io.Writer, v any) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+	line := func(label string, p forecast.PeriodSummary) {
+		fmt.Fprintf(tw, "%s:\t%s\t%d°%s (%s)\t%s\n", label, p.Name, p.Temperature.Value, p.Temperature.Unit,
+			p.Temperature.Type, p.ShortForecast)
+	}
+	switch r := v.(type) {
+	case forecast.Result:
+		fmt.Fprintf(tw, "Forecast for %s, %s on %s (%s)\n", ftoa(r.Coords.Lat), ftoa(r.Coords.Lon), r.Date, r.TimeZone)
+		line("Today", r.Today)
+		fmt.Fprintf(tw, "Source:\t%s, updated %s\n", r.Source, updated(r.Meta))
+	case forecast.DailyResult:
+		fmt.Fprintf(tw, "Forecast for %s, %s on %s (%s)\n", ftoa(r.Coords.Lat), ftoa(r.Coords.Lon), r.Date, r.TimeZone)
+		if r.Day != nil {
+			line("Day", *r.Day)
+		}
+		if r.Night != nil {
+			line("Night", *r.Night)
+		}
+		fmt.Fprintf(tw, "Source:\t%s, updated %s\n", r.Source, updated(r.Meta))
+	case errorBody:
+		fmt.Fprintf(tw, "%s: %s\n", r.Error, r.Msg)
+	default:
+		b, _ := json.MarshalIndent(v, "", "  ")
+		fmt.Fprintf(tw, "%s\n", b)
+	}
+}
+
+// historyTable has a row per period of each archived forecast.
+func historyTable(c historyCell, fcs []history.Forecast) ([]string, [][]string) {
+	header := []string{"lat", "lon", "gridCell", "issuedAt", "supersededAt", "name", "startTime", "endTime",
+		"temperature", "unit", "shortForecast"}
+	var rows [][]string
+	for _, fc := range fcs {
+		superseded := ""
+		if fc.SupersededAt != nil {
+			superseded = fc.SupersededAt.Format(time.RFC3339)
+		}
+		for _, p := range fc.Periods {
+			rows = append(rows, []string{ftoa(c.Coords.Lat), ftoa(c.Coords.Lon), c.GridCell,
+				fc.IssuedAt.Format(time.RFC3339), superseded, p.Name, p.StartTime.Format(time.RFC3339),
+				p.EndTime.Format(time.RFC3339), strconv.Itoa(p.Temperature), p.TemperatureUnit, p.ShortForecast})
+		}
+	}
+	return header, rows
+}
+
+// changesTable has a row per changed period of each update.
+func changesTable(r historyChanges) ([]string, [][]string) {
+	header := []string{"lat", "lon", "gridCell", "from", "to", "status", "start", "name", "temperature", "unit",
+		"temperatureDelta", "classificationChanged", "shortForecastChanged"}
+	var rows [][]string
+	for _, c := range r.Changes {
+		for _, p := range c.Periods {
+			s := p.After
+			if s == nil {
+				s = p.Before
+			}
+			var name, temp, unit string
+			if s != nil {
+				name, temp, unit = s.Name, strconv.Itoa(s.Temperature.Value), s.Temperature.Unit
+			}
+			rows = append(rows, []string{ftoa(r.Coords.Lat), ftoa(r.Coords.Lon), r.GridCell,
+				c.From.Format(time.RFC3339), c.To.Format(time.RFC3339), p.Status, p.Start.Format(time.RFC3339),
+				name, temp, unit, strconv.Itoa(p.TemperatureDelta), strconv.FormatBool(p.ClassificationChanged),
+				strconv.FormatBool(p.ShortForecastChanged)})
+		}
+	}
+	return header, rows
+}
+
+// verificationTable has a row per location, lead time and high/low.
+func verificationTable(r verificationBody) ([]string, [][]string) {
+	header := []string{"lat", "lon", "leadDays", "kind", "verified", "bias", "mae", "hitRate"}
+	var rows [][]string
+	for _, loc := range r.Locations {
+		for _, l := range loc.Leads {
+			for _, k := range []struct {
+				kind  string
+				score *verify.Score
+			}{{"high", l.High}, {"low", l.Low}} {
+				if k.score == nil {
+					continue
+				}
+				rows = append(rows, []string{ftoa(loc.Lat), ftoa(loc.Lon), strconv.Itoa(l.LeadDays), k.kind,
+					strconv.Itoa(k.score.Verified), ftoa(k.score.Bias), ftoa(k.score.MAE), ftoa(k.score.HitRate)})
+			}
+		}
+	}
+	return header, rows
+}
+
+func updated(m *forecast.Meta) string {
+	if m == nil {
+		return ""
+	}
+	return m.Updated
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"weather-service/internal/nws"
+	"weather-service/internal/stream"
+)
+
+// StreamHandler serves live forecast updates as Server-Sent Events.
+type StreamHandler struct {
+	log       *slog.Logger
+	hub       *stream.Hub
+	heartbeat time.Duration
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewStreamHandler creates the SSE handler. A comment line is written every
+// heartbeat so proxies keep idle connections open.
+func NewStreamHandler(log *slog.Logger, hub *stream.Hub, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{log: log, hub: hub, heartbeat: heartbeat, done: make(chan struct{})}
+}
+
+// Close ends every open stream. Register it with http.Server.RegisterOnShutdown,
+// since Shutdown otherwise waits for streams that never finish on their own.
+func (h *StreamHandler) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// Register adds the stream route to mux.
+func (h *StreamHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/forecast/stream", h.Stream)
+}
+
+// Stream handles GET /v1/forecast/stream?lat=&lon=. It sends the current result
+// as a "forecast" event right away, and then one event per upstream change.
+// Alerts in effect, and each one issued later, are sent as "alert" events. Every
+// event id is a stream.Cursor, so a client reconnecting with Last-Event-ID is
+// sent neither the forecast version nor the alerts it already has.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	lat, lon, err := parseLatLon(r.URL.Query().Get("lat"), r.URL.Query().Get("lon"))
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	rc := http.NewResponseController(w)
+
+	cur, sub, err := h.hub.Subscribe(r.Context(), lat, lon)
+	if err != nil {
+		writeErr(w, http.StatusBadGateway, err)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	cursor := stream.ParseCursor(r.Header.Get("Last-Event-ID"))
+	send := func(u stream.Update) error {
+		if u.ID == cursor.Update {
+			return nil
+		}
+		cursor.Update = u.ID
+		b, err := json.Marshal(u.Result)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(w, "id: %s\nevent: forecast\ndata: %s\n\n", cursor, b); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	sendAlert := func(a nws.Alert) error {
+		if cursor.Sent(a) {
+			return nil
+		}
+		cursor.AddAlert(a)
+		b, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(w, "id: %s\nevent: alert\ndata: %s\n\n", cursor, b); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	if err = send(cur); err != nil {
+		return
+	}
+	if err = rc.Flush(); err != nil {
+		h.log.WarnContext(r.Context(), "stream flush unsupported", "err", err)
+		return
+	}
+
+	beat := time.NewTicker(h.heartbeat)
+	defer beat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case u := <-sub.C:
+			err = send(u)
+		case a := <-sub.Alerts:
+			err = sendAlert(a)
+		case <-beat.C:
+			if _, err = fmt.Fprint(w, ": heartbeat\n\n"); err == nil {
+				err = rc.Flush()
+			}
+		}
+		if err != nil {
+			h.log.DebugContext(r.Context(), "stream closed", "err", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+	"weather-service/internal/server"
+	"weather-service/internal/stream"
+)
+
+func newStreamServer(t *testing.T, f *fakeSvc, heartbeat time.Duration, alerts ...nws.Alert) *httptest.Server {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	active := func(context.Context, float64, float64) ([]nws.Alert, error) { return alerts, nil }
+	h := server.NewStreamHandler(logger, stream.NewHub(f, f.GridCell, active, time.Hour, logger), heartbeat)
+	mux := http.NewServeMux()
+	h.Register(mux)
+	srv := httptest.NewServer(server.WithMiddleware(mux, 5))
+	t.Cleanup(func() {
+		h.Close()
+		srv.Close()
+	})
+	return srv
+}
+
+func openStream(t *testing.T, url, lastID string) (*bufio.Reader, func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatalf("open stream: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		cancel()
+		t.Fatalf("content-type=%q", ct)
+	}
+	return bufio.NewReader(resp.Body), func() {
+		cancel()
+		resp.Body.Close()
+	}
+}
+
+// readBlock reads one SSE block (event or comment) up to the blank line.
+func readBlock(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v (so far %q)", err, b.String())
+		}
+		if line == "\n" {
+			return b.String()
+		}
+		b.WriteString(line)
+	}
+}
+
+func TestStreamSendsCurrentResult(t *testing.T) {
+	f := &fakeSvc{res: forecast.Result{Date: "2025-08-13", Meta: &forecast.Meta{Updated: "u1"}}}
+	srv := newStreamServer(t, f, time.Hour)
+
+	r, closeFn := openStream(t, srv.URL+"/v1/forecast/stream?lat=10&lon=20", "")
+	defer closeFn()
+	block := readBlock(t, r)
+	if !strings.Contains(block, "id: 2025-08-13/u1\n") || !strings.Contains(block, "event: forecast\n") ||
+		!strings.Contains(block, `"updated":"u1"`) {
+		t.Fatalf("unexpected first event: %q", block)
+	}
+}
+
+func TestStreamResumesAndHeartbeats(t *testing.T) {
+	f := &fakeSvc{res: forecast.Result{Date: "2025-08-13", Meta: &forecast.Meta{Updated: "u1"}}}
+	srv := newStreamServer(t, f, 10*time.Millisecond)
+
+	// The client already has this version, so only heartbeats follow.
+	r, closeFn := openStream(t, srv.URL+"/v1/forecast/stream?lat=10&lon=20", "2025-08-13/u1")
+	defer closeFn()
+	if block := readBlock(t, r); block != ": heartbeat\n" {
+		t.Fatalf("expected heartbeat, got %q", block)
+	}
+}
+
+func TestStreamSendsActiveAlerts(t *testing.T) {
+	f := &fakeSvc{res: forecast.Result{Date: "2025-08-13", Meta: &forecast.Meta{Updated: "u1"}}}
+	srv := newStreamServer(t, f, time.Hour, nws.Alert{ID: "urn:alert:1", Event: "Heat Advisory"})
+
+	r, closeFn := openStream(t, srv.URL+"/v1/forecast/stream?lat=10&lon=20", "")
+	defer closeFn()
+	readBlock(t, r) // forecast
+	block := readBlock(t, r)
+	if !strings.HasPrefix(block, "id: 2025-08-13/u1;") || !strings.Contains(block, "event: alert\n") ||
+		!strings.Contains(block, `"event":"Heat Advisory"`) {
+		t.Fatalf("unexpected alert event: %q", block)
+	}
+}
+
+func TestStreamResumesAfterAlert(t *testing.T) {
+	f := &fakeSvc{res: forecast.Result{Date: "2025-08-13", Meta: &forecast.Meta{Updated: "u1"}}}
+	srv := newStreamServer(t, f, 50*time.Millisecond, nws.Alert{ID: "urn:alert:1", Event: "Heat Advisory"})
+
+	r, closeFn := openStream(t, srv.URL+"/v1/forecast/stream?lat=10&lon=20", "")
+	readBlock(t, r) // forecast
+	block := readBlock(t, r)
+	closeFn()
+	id, _, _ := strings.Cut(strings.TrimPrefix(block, "id: "), "\n")
+
+	// Reconnecting after the alert event repeats neither the forecast nor the alert.
+	r, closeFn = openStream(t, srv.URL+"/v1/forecast/stream?lat=10&lon=20", id)
+	defer closeFn()
+	if block = readBlock(t, r); block != ": heartbeat\n" {
+		t.Fatalf("expected heartbeat, got %q", block)
+	}
+}
+
+func TestStreamBadParams(t *testing.T) {
+	srv := newStreamServer(t, &fakeSvc{}, time.Hour)
+	resp, err := http.Get(srv.URL + "/v1/forecast/stream?lat=100&lon=0")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status=%d want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"weather-service/internal/subscription"
+)
+
+// maxSubscriptionBody bounds the size of a subscription request body.
+const maxSubscriptionBody = 1 << 16
+
+// SubscriptionHandler exposes the webhook subscription API.
+type SubscriptionHandler struct {
+	log   *slog.Logger
+	store *subscription.Store
+}
+
+// NewSubscriptionHandler creates the subscription HTTP handler.
+func NewSubscriptionHandler(log *slog.Logger, store *subscription.Store) *SubscriptionHandler {
+	return &SubscriptionHandler{log: log, store: store}
+}
+
+// Register adds the public subscription routes to mux. Reading and deleting a
+// subscription requires the token returned when it was created; listing every
+// subscription is an operator route (List).
+func (h *SubscriptionHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/subscriptions", h.Create)
+	mux.HandleFunc("GET /v1/subscriptions/{id}", h.Get)
+	mux.HandleFunc("DELETE /v1/subscriptions/{id}", h.Delete)
+}
+
+// Create handles POST /v1/subscriptions. The response is the only place the
+// signing secret and the access token are ever returned.
+func (h *SubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Lat         float64                `json:"lat"`
+		Lon         float64                `json:"lon"`
+		Triggers    []subscription.Trigger `json:"triggers"`
+		CallbackURL string                 `json:"callbackURL"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubscriptionBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	sub, err := h.store.Create(subscription.Subscription{
+		Lat:         req.Lat,
+		Lon:         req.Lon,
+		Triggers:    req.Triggers,
+		CallbackURL: req.CallbackURL,
+	})
+	if err != nil {
+		if errors.Is(err, subscription.ErrInvalid) {
+			writeErr(w, http.StatusBadRequest, err)
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.log.InfoContext(r.Context(), "subscription created", "subscription", sub.ID, "callback", sub.CallbackURL)
+	w.Header().Set("Location", "/v1/subscriptions/"+sub.ID)
+	sub.TokenHash = ""
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+// List handles GET /admin/subscriptions on the admin listener.
+func (h *SubscriptionHandler) List(w http.ResponseWriter, _ *http.Request) {
+	subs := h.store.List()
+	for i := range subs {
+		subs[i] = redact(subs[i])
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"subscriptions": subs})
+}
+
+// Get handles GET /v1/subscriptions/{id}.
+func (h *SubscriptionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, redact(sub))
+}
+
+// Delete handles DELETE /v1/subscriptions/{id}.
+func (h *SubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+	ok, err := h.store.Delete(sub.ID)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		writeErr(w, http.StatusNotFound, errors.New("subscription not found"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeadLetters handles GET /admin/subscriptions/dead-letters on the admin listener.
+func (h *SubscriptionHandler) DeadLetters(w http.ResponseWriter, _ *http.Request) {
+	dead := h.store.DeadLetters()
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(dead), "deadLetters": dead})
+}
+
+// authorize looks up the subscription named in the path for the bearer token
+// in the request, answering 401 without a token and 404 when the token does not
+// match, so IDs cannot be probed.
+func (h *SubscriptionHandler) authorize(w http.ResponseWriter, r *http.Request) (subscription.Subscription, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="subscriptions"`)
+		writeErr(w, http.StatusUnauthorized, errors.New("subscription token required"))
+		return subscription.Subscription{}, false
+	}
+	sub, ok := h.store.Authorize(r.PathValue("id"), token)
+	if !ok {
+		writeErr(w, http.StatusNotFound, errors.New("subscription not found"))
+		return subscription.Subscription{}, false
+	}
+	return sub, true
+}
+
+// redact removes the credentials from a stored subscription.
+func redact(sub subscription.Subscription) subscription.Subscription {
+	sub.Secret, sub.Token, sub.TokenHash = "", "", ""
+	return sub
+}
@@ -0,0 +1,133 @@
+package server_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"weather-service/internal/server"
+	"weather-service/internal/subscription"
+)
+
+func newSubsMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	store, err := subscription.OpenStore(filepath.Join(t.TempDir(), "subs.json"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	mux := http.NewServeMux()
+	h := server.NewSubscriptionHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), store)
+	h.Register(mux)
+	mux.HandleFunc("GET /admin/subscriptions", h.List)
+	return mux
+}
+
+func withToken(method, target, token string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestSubscriptionLifecycle(t *testing.T) {
+	mux := newSubsMux(t)
+
+	body := `{"lat":39.7,"lon":-104.9,"callbackURL":"https://hooks.example.com/w",
+		"triggers":[{"type":"temperature_above","threshold":90}]}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/subscriptions", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	created := decodeBody[subscription.Subscription](t, rec.Body.Bytes())
+	if created.ID == "" || created.Secret == "" || created.Token == "" || created.TokenHash != "" {
+		t.Fatalf("expected id, secret and token only: %+v", created)
+	}
+	path := "/v1/subscriptions/" + created.ID
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, withToken(http.MethodGet, path, created.Token))
+	got := decodeBody[subscription.Subscription](t, rec.Body.Bytes())
+	if rec.Code != http.StatusOK || got.Secret != "" || got.Token != "" || got.TokenHash != "" {
+		t.Fatalf("get: status=%d credentials leaked: %+v", rec.Code, got)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/subscriptions", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.Secret) ||
+		strings.Contains(rec.Body.String(), "tokenHash") {
+		t.Fatalf("admin list: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, withToken(http.MethodDelete, path, created.Token))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status=%d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, withToken(http.MethodGet, path, created.Token))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("get after delete: status=%d", rec.Code)
+	}
+}
+
+func TestSubscriptionRequiresToken(t *testing.T) {
+	mux := newSubsMux(t)
+	body := `{"lat":39.7,"lon":-104.9,"callbackURL":"https://hooks.example.com/w",
+		"triggers":[{"type":"classification_change"}]}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/subscriptions", strings.NewReader(body)))
+	created := decodeBody[subscription.Subscription](t, rec.Body.Bytes())
+	path := "/v1/subscriptions/" + created.ID
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"get without token", http.MethodGet, path, "", http.StatusUnauthorized},
+		{"delete without token", http.MethodDelete, path, "", http.StatusUnauthorized},
+		{"get with wrong token", http.MethodGet, path, "nope", http.StatusNotFound},
+		{"delete with wrong token", http.MethodDelete, path, "nope", http.StatusNotFound},
+		{"unknown id", http.MethodGet, "/v1/subscriptions/missing", created.Token, http.StatusNotFound},
+		{"no public list", http.MethodGet, "/v1/subscriptions", created.Token, http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, withToken(c.method, c.path, c.token))
+			if rec.Code != c.want {
+				t.Fatalf("status=%d want %d", rec.Code, c.want)
+			}
+		})
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, withToken(http.MethodGet, path, created.Token))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("subscription gone after rejected deletes: status=%d", rec.Code)
+	}
+}
+
+func TestSubscriptionCreateInvalid(t *testing.T) {
+	mux := newSubsMux(t)
+	for _, body := range []string{
+		`{"lat":10,"lon":20,"callbackURL":"https://x.example","triggers":[]}`,
+		`{"lat":10,"lon":20,"callbackURL":"not a url","triggers":[{"type":"classification_change"}]}`,
+		`{"lat":10,"lon":20,"callback":"https://x.example"}`,
+		`not json`,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/subscriptions", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status=%d want 400", body, rec.Code)
+		}
+	}
+}
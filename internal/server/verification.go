@@ -0,0 +1,44 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"weather-service/internal/verify"
+)
+
+// VerificationHandler serves forecast accuracy scores.
+type VerificationHandler struct {
+	log      *slog.Logger
+	verifier *verify.Verifier
+}
+
+// NewVerificationHandler creates the forecast verification HTTP handler.
+func NewVerificationHandler(log *slog.Logger, v *verify.Verifier) *VerificationHandler {
+	return &VerificationHandler{log: log, verifier: v}
+}
+
+// Register adds the verification routes to mux.
+func (h *VerificationHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/verification", h.Scores)
+}
+
+// verificationBody is the body of a GET /v1/verification response.
+type verificationBody struct {
+	Locations []verify.Report `json:"locations" xml:"location"`
+}
+
+// Scores handles GET /v1/verification: bias, mean absolute error and
+// classification hit rate of the recorded high and low forecasts, per location
+// and lead time, in the negotiated format and honouring conditional requests.
+func (h *VerificationHandler) Scores(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiate(w, r)
+	if !ok {
+		return
+	}
+	res := verificationBody{Locations: h.verifier.Reports()}
+	if notModified(w, r, etagOf(format, res), nil) {
+		return
+	}
+	render(w, format, http.StatusOK, res)
+}
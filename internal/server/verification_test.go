@@ -0,0 +1,50 @@
+package server_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/prefetch"
+	"weather-service/internal/server"
+	"weather-service/internal/verify"
+)
+
+func TestVerificationHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	v := verify.New(&fakeSvc{}, nil, forecast.NewBandsVar(forecast.Bands{ColdMax: 50, HotMin: 85}), verify.Options{
+		Targets:  func() []prefetch.Location { return nil },
+		Interval: time.Hour,
+	}, logger)
+	h := server.NewVerificationHandler(logger, v)
+	mux := http.NewServeMux()
+	h.Register(mux)
+	mux.Handle("GET /metrics", server.MetricsHandler(logger, v))
+
+	rec := serve(mux, http.MethodGet, "/v1/verification")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"locations":[]}` {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/v1/verification", nil)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	if mux.ServeHTTP(rec, req); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match status=%d", rec.Code)
+	}
+	rec = serve(mux, http.MethodGet, "/v1/verification?format=csv")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "lat,lon,leadDays,kind,verified,bias,mae,hitRate" {
+		t.Fatalf("csv: status=%d body=%s", rec.Code, rec.Body)
+	}
+	rec = serve(mux, http.MethodGet, "/metrics")
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("content-type=%q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "# TYPE weather_forecast_bias_degrees gauge") {
+		t.Fatalf("metrics=%s", rec.Body)
+	}
+}
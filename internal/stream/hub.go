@@ -0,0 +1,286 @@
+// Package stream fans out live forecast updates and alerts to many viewers
+// while polling the forecast service once per NWS grid cell.
+package stream
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+)
+
+// alertBuffer is how many alerts a viewer can fall behind by before further
+// ones are dropped for it.
+const alertBuffer = 16
+
+// AlertsFunc returns the alerts in effect at a location, like nws.Client.Alerts.
+type AlertsFunc func(ctx context.Context, lat, lon float64) ([]nws.Alert, error)
+
+// GridCellFunc resolves coordinates to their grid cell, like
+// forecast.Internal.GridCell.
+type GridCellFunc func(ctx context.Context, lat, lon float64) (string, error)
+
+// Update is a forecast result together with an opaque ID identifying its
+// upstream version, suitable for the SSE id field and Last-Event-ID.
+type Update struct {
+	ID     string
+	Result forecast.Result
+}
+
+// maxCursorAlerts bounds how many alerts a Cursor remembers; the oldest are
+// forgotten first.
+const maxCursorAlerts = 32
+
+// Cursor is what one viewer has been sent: the UpdateID of the latest forecast
+// and the alerts. Its String form is used as the id of every event, so the
+// Last-Event-ID of a reconnecting viewer restores it whichever kind of event
+// came last.
+type Cursor struct {
+	Update string
+	alerts []string // alertKey of each alert sent, oldest first
+}
+
+// ParseCursor restores the Cursor whose String is id. Unknown ids yield an
+// empty Cursor, or one holding id as its UpdateID.
+func ParseCursor(id string) Cursor {
+	update, alerts, ok := strings.Cut(id, ";")
+	c := Cursor{Update: update}
+	if ok && alerts != "" {
+		c.alerts = strings.Split(alerts, ",")
+	}
+	return c
+}
+
+// String encodes c as an SSE event id.
+func (c Cursor) String() string {
+	if len(c.alerts) == 0 {
+		return c.Update
+	}
+	return c.Update + ";" + strings.Join(c.alerts, ",")
+}
+
+// Sent reports whether a was sent already.
+func (c Cursor) Sent(a nws.Alert) bool {
+	return slices.Contains(c.alerts, alertKey(a))
+}
+
+// AddAlert records that a was sent.
+func (c *Cursor) AddAlert(a nws.Alert) {
+	c.alerts = append(c.alerts, alertKey(a))
+	if n := len(c.alerts); n > maxCursorAlerts {
+		c.alerts = slices.Clone(c.alerts[n-maxCursorAlerts:])
+	}
+}
+
+// alertKey is a short stand-in for an alert ID, which is a long URN.
+func alertKey(a nws.Alert) string {
+	sum := sha256.Sum256([]byte(a.ID))
+	return hex.EncodeToString(sum[:4])
+}
+
+// UpdateID identifies the upstream version of a result: it changes when NWS
+// publishes a new forecast (updateTime) or when "today" rolls over.
+func UpdateID(res forecast.Result) string {
+	updated := ""
+	if res.Meta != nil {
+		updated = res.Meta.Updated
+	}
+	return res.Date + "/" + updated
+}
+
+// Hub shares one polling loop per grid cell between all of its subscribers.
+// It is safe for concurrent use.
+type Hub struct {
+	svc      forecast.Service
+	cells    GridCellFunc
+	alerts   AlertsFunc
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	topics map[string]*topic
+}
+
+type topic struct {
+	cell     string
+	lat, lon float64 // coordinates the loop polls with
+	lastID   string
+	active   []nws.Alert // alerts in effect at the last successful check
+	subs     map[*Subscription]struct{}
+	cancel   context.CancelFunc
+}
+
+// Subscription receives updates for one viewer. Updates carry the viewer's own
+// coordinates. Slow viewers only ever see the latest update. Alerts receives the
+// alerts in effect when the viewer joined, then each newly issued one.
+type Subscription struct {
+	C      <-chan Update
+	Alerts <-chan nws.Alert
+
+	c        chan Update
+	alerts   chan nws.Alert
+	lat, lon float64
+	hub      *Hub
+	topic    *topic
+	once     sync.Once
+}
+
+// NewHub constructs a Hub polling each active grid cell every interval. cells
+// groups viewers by grid cell; alerts looks up the alerts streamed alongside
+// forecasts, and nil streams none.
+func NewHub(svc forecast.Service, cells GridCellFunc, alerts AlertsFunc, interval time.Duration,
+	logger *slog.Logger) *Hub {
+	return &Hub{svc: svc, cells: cells, alerts: alerts, interval: interval, logger: logger, topics: make(map[string]*topic)}
+}
+
+// Subscribe returns the current update for the coordinates and a Subscription
+// delivering later updates for their grid cell. Call Close when done.
+func (h *Hub) Subscribe(ctx context.Context, lat, lon float64) (Update, *Subscription, error) {
+	cell, err := h.cells(ctx, lat, lon)
+	if err != nil {
+		return Update{}, nil, err
+	}
+	res, err := h.svc.GetTodaysForcast(ctx, lat, lon)
+	if err != nil {
+		return Update{}, nil, err
+	}
+	cur := Update{ID: UpdateID(res), Result: res}
+
+	c := make(chan Update, 1)
+	alerts := make(chan nws.Alert, alertBuffer)
+	sub := &Subscription{C: c, Alerts: alerts, c: c, alerts: alerts, lat: lat, lon: lon, hub: h}
+
+	h.mu.Lock()
+	t, ok := h.topics[cell]
+	if !ok {
+		loopCtx, cancel := context.WithCancel(context.Background())
+		t = &topic{cell: cell, lat: lat, lon: lon, lastID: cur.ID, subs: make(map[*Subscription]struct{}), cancel: cancel}
+		h.topics[cell] = t
+		go h.run(loopCtx, t)
+	}
+	t.subs[sub] = struct{}{}
+	sub.topic = t
+	for _, a := range t.active {
+		sub.sendAlert(ctx, a)
+	}
+	h.mu.Unlock()
+	return cur, sub, nil
+}
+
+// Topics reports how many grid cells are currently being polled.
+func (h *Hub) Topics() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.topics)
+}
+
+// Close leaves the topic; the topic's polling loop stops with its last viewer.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		h := s.hub
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(s.topic.subs, s)
+		if len(s.topic.subs) == 0 {
+			s.topic.cancel()
+			delete(h.topics, s.topic.cell)
+		}
+	})
+}
+
+// send delivers u, replacing an unread older update if the viewer is behind.
+func (s *Subscription) send(u Update) {
+	u.Result.Coords.Lat, u.Result.Coords.Lon = s.lat, s.lon
+	for {
+		select {
+		case s.c <- u:
+			return
+		default:
+		}
+		select {
+		case <-s.c:
+		default:
+		}
+	}
+}
+
+// sendAlert delivers a, dropping it if the viewer has fallen alertBuffer behind.
+func (s *Subscription) sendAlert(ctx context.Context, a nws.Alert) {
+	select {
+	case s.alerts <- a:
+	default:
+		s.hub.logger.WarnContext(ctx, "stream viewer too slow, alert dropped", "cell", s.topic.cell, "alert", a.ID)
+	}
+}
+
+// run polls the topic's grid cell until ctx is cancelled, broadcasting results
+// whose UpdateID differs from the last one seen and alerts not in effect at the
+// previous check. The first alert check runs right away, so the first viewers
+// get the alerts already in effect without waiting for a tick.
+func (h *Hub) run(ctx context.Context, t *topic) {
+	h.checkAlerts(ctx, t)
+	tick := time.NewTicker(h.interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+		h.checkAlerts(ctx, t)
+		res, err := h.svc.GetTodaysForcast(ctx, t.lat, t.lon)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.logger.WarnContext(ctx, "stream poll failed", "cell", t.cell, "err", err)
+			}
+			continue
+		}
+		u := Update{ID: UpdateID(res), Result: res}
+
+		h.mu.Lock()
+		if u.ID != t.lastID {
+			t.lastID = u.ID
+			for sub := range t.subs {
+				sub.send(u)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// checkAlerts fetches the alerts in effect in t's cell and sends the new ones to
+// every viewer. A failed lookup keeps the previous set.
+func (h *Hub) checkAlerts(ctx context.Context, t *topic) {
+	if h.alerts == nil {
+		return
+	}
+	alerts, err := h.alerts(ctx, t.lat, t.lon)
+	if err != nil {
+		if ctx.Err() == nil {
+			h.logger.WarnContext(ctx, "stream alerts lookup failed", "cell", t.cell, "err", err)
+		}
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seen := make(map[string]bool, len(t.active))
+	for _, a := range t.active {
+		seen[a.ID] = true
+	}
+	for _, a := range alerts {
+		if seen[a.ID] {
+			continue
+		}
+		for sub := range t.subs {
+			sub.sendAlert(ctx, a)
+		}
+	}
+	t.active = alerts
+}
@@ -0,0 +1,202 @@
+package stream_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+	"weather-service/internal/stream"
+)
+
+// fakeSvc serves a single grid cell whose updateTime can be changed.
+type fakeSvc struct {
+	forecast.Service
+	mu      sync.Mutex
+	updated string
+	polls   int
+}
+
+func (f *fakeSvc) GridCell(context.Context, float64, float64) (string, error) { return "cell-1", nil }
+
+func (f *fakeSvc) GetTodaysForcast(_ context.Context, lat, lon float64) (forecast.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polls++
+	var res forecast.Result
+	res.Coords.Lat, res.Coords.Lon = lat, lon
+	res.Date = "2025-08-13"
+	res.Meta = &forecast.Meta{Updated: f.updated}
+	return res, nil
+}
+
+func (f *fakeSvc) setUpdated(u string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updated = u
+}
+
+func newHub(svc *fakeSvc) *stream.Hub {
+	return stream.NewHub(svc, svc.GridCell, nil, 5*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestHubSharesOneLoopPerCell(t *testing.T) {
+	svc := &fakeSvc{updated: "v1"}
+	hub := newHub(svc)
+	ctx := context.Background()
+
+	cur1, sub1, err := hub.Subscribe(ctx, 39.70, -104.90)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	_, sub2, err := hub.Subscribe(ctx, 39.71, -104.91)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if cur1.ID != "2025-08-13/v1" || hub.Topics() != 1 {
+		t.Fatalf("cur=%q topics=%d", cur1.ID, hub.Topics())
+	}
+
+	svc.setUpdated("v2")
+	for _, s := range []*stream.Subscription{sub1, sub2} {
+		select {
+		case u := <-s.C:
+			if u.ID != "2025-08-13/v2" {
+				t.Fatalf("unexpected update %q", u.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no update delivered")
+		}
+	}
+	// Each viewer keeps its own coordinates even though the cell is polled once.
+	select {
+	case u := <-sub2.C:
+		t.Fatalf("duplicate update %q", u.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	sub1.Close()
+	sub2.Close()
+	sub2.Close() // idempotent
+	if hub.Topics() != 0 {
+		t.Fatalf("topic not torn down after last viewer left")
+	}
+}
+
+func TestSubscriptionKeepsViewerCoords(t *testing.T) {
+	svc := &fakeSvc{updated: "v1"}
+	hub := newHub(svc)
+	_, first, _ := hub.Subscribe(context.Background(), 1, 1)
+	defer first.Close()
+	_, second, _ := hub.Subscribe(context.Background(), 2, 2)
+	defer second.Close()
+
+	svc.setUpdated("v2")
+	select {
+	case u := <-second.C:
+		if u.Result.Coords.Lat != 2 || u.Result.Coords.Lon != 2 {
+			t.Fatalf("coords=%+v want viewer's (2,2)", u.Result.Coords)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no update delivered")
+	}
+}
+
+// fakeAlerts serves a changeable set of alerts and can be made to fail.
+type fakeAlerts struct {
+	mu     sync.Mutex
+	active []nws.Alert
+	err    error
+}
+
+func (f *fakeAlerts) get(context.Context, float64, float64) ([]nws.Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.active, f.err
+}
+
+func (f *fakeAlerts) set(err error, active ...nws.Alert) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.active, f.err = active, err
+}
+
+func nextAlert(t *testing.T, s *stream.Subscription) string {
+	t.Helper()
+	select {
+	case a := <-s.Alerts:
+		return a.ID
+	case <-time.After(time.Second):
+		t.Fatal("no alert delivered")
+		return ""
+	}
+}
+
+func TestHubStreamsNewAlerts(t *testing.T) {
+	alerts := &fakeAlerts{active: []nws.Alert{{ID: "a1"}}}
+	svc := &fakeSvc{updated: "v1"}
+	hub := stream.NewHub(svc, svc.GridCell, alerts.get, 5*time.Millisecond,
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	_, first, err := hub.Subscribe(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer first.Close()
+	if id := nextAlert(t, first); id != "a1" {
+		t.Fatalf("first viewer got %q, want the alert in effect", id)
+	}
+	// A later viewer of the cell is sent the alerts already in effect too.
+	_, second, _ := hub.Subscribe(context.Background(), 1.01, 1.01)
+	defer second.Close()
+	if id := nextAlert(t, second); id != "a1" {
+		t.Fatalf("second viewer got %q", id)
+	}
+
+	alerts.set(errors.New("upstream down"))
+	time.Sleep(20 * time.Millisecond)
+	alerts.set(nil, nws.Alert{ID: "a1"}, nws.Alert{ID: "a2"})
+	for _, s := range []*stream.Subscription{first, second} {
+		if id := nextAlert(t, s); id != "a2" {
+			t.Fatalf("got %q, want only the new alert a2", id)
+		}
+	}
+	select {
+	case a := <-first.Alerts:
+		t.Fatalf("alert %q sent twice", a.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestCursor(t *testing.T) {
+	c := stream.ParseCursor("")
+	if c.Update != "" || c.String() != "" {
+		t.Fatalf("empty cursor=%+v", c)
+	}
+	c.Update = "2025-08-13/u1"
+	heat, wind := nws.Alert{ID: "urn:alert:heat"}, nws.Alert{ID: "urn:alert:wind"}
+	c.AddAlert(heat)
+
+	got := stream.ParseCursor(c.String())
+	if got.Update != "2025-08-13/u1" || !got.Sent(heat) || got.Sent(wind) {
+		t.Fatalf("parsed %q: %+v", c.String(), got)
+	}
+	if got = stream.ParseCursor("2025-08-13/u1"); got.Update != "2025-08-13/u1" || got.Sent(heat) {
+		t.Fatalf("forecast-only id: %+v", got)
+	}
+
+	// Only the most recent alerts are remembered.
+	for i := range 40 {
+		c.AddAlert(nws.Alert{ID: fmt.Sprintf("urn:alert:%d", i)})
+	}
+	got = stream.ParseCursor(c.String())
+	if got.Sent(heat) || !got.Sent(nws.Alert{ID: "urn:alert:39"}) || !got.Sent(nws.Alert{ID: "urn:alert:8"}) {
+		t.Fatalf("cursor after 41 alerts: %q", c.String())
+	}
+}
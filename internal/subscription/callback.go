@@ -0,0 +1,69 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errPrivateCallback is returned for callbacks that would reach the service's
+// own host or network rather than the public internet.
+var errPrivateCallback = errors.New("callbackURL must not point at a loopback, private or link-local address")
+
+// allowPrivate lets tests deliver to httptest servers on loopback.
+var allowPrivate = false
+
+// publicAddr reports whether a callback may be delivered to ip.
+func publicAddr(ip netip.Addr) bool {
+	if allowPrivate {
+		return true
+	}
+	ip = ip.Unmap()
+	return ip.IsValid() && ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// checkCallbackHost rejects hosts that are known to be internal before any
+// lookup: loopback names and non-public IP literals. Other names are checked
+// again, once resolved, when the dispatcher dials them.
+func checkCallbackHost(host string) error {
+	if allowPrivate {
+		return nil
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errPrivateCallback
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !publicAddr(ip) {
+		return errPrivateCallback
+	}
+	return nil
+}
+
+// NewCallbackClient returns the HTTP client the Dispatcher should deliver with.
+// Its dialer refuses non-public addresses after DNS resolution, so a callback
+// host that resolves (or is later rebound, or redirects) to an internal address
+// is never connected to. Proxies are not used, since they would dial for us.
+func NewCallbackClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addr.Addr()) {
+				return fmt.Errorf("dial %s: %w", address, errPrivateCallback)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,166 @@
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	// SignatureHeader carries "sha256=<hex HMAC of the body>" keyed by the subscription secret.
+	SignatureHeader = "X-Weather-Signature"
+	// EventHeader carries the trigger type of the delivered event.
+	EventHeader = "X-Weather-Event"
+	// DeliveryHeader carries the event ID, stable across retries for deduplication.
+	DeliveryHeader = "X-Weather-Delivery"
+
+	queueSize = 256
+	workers   = 4
+)
+
+// Sign returns the SignatureHeader value for body signed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type job struct {
+	url    string
+	secret string
+	event  Event
+}
+
+// Dispatcher delivers events to callback URLs with exponential backoff, moving
+// events that still fail after maxAttempts to the store's dead-letter list.
+type Dispatcher struct {
+	store       *Store
+	client      *http.Client
+	logger      *slog.Logger
+	maxAttempts int
+	backoff     time.Duration
+	queue       chan job
+}
+
+// NewDispatcher constructs a Dispatcher. backoff is the delay before the first
+// retry; it doubles on every further attempt.
+func NewDispatcher(store *Store, client *http.Client, logger *slog.Logger, maxAttempts int,
+	backoff time.Duration,
+) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		client:      client,
+		logger:      logger,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		queue:       make(chan job, queueSize),
+	}
+}
+
+// Enqueue schedules delivery of e to sub. When the queue is full the event is
+// dead-lettered immediately rather than blocking the poller.
+func (d *Dispatcher) Enqueue(ctx context.Context, sub Subscription, e Event) {
+	j := job{url: sub.CallbackURL, secret: sub.Secret, event: e}
+	select {
+	case d.queue <- j:
+	default:
+		d.deadLetter(ctx, j, 0, "delivery queue full")
+	}
+}
+
+// Run delivers queued events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	done := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-d.queue:
+					d.deliver(ctx, j)
+				}
+			}
+		}()
+	}
+	for i := 0; i < workers; i++ {
+		<-done
+	}
+}
+
+// deliver attempts a job up to maxAttempts times.
+func (d *Dispatcher) deliver(ctx context.Context, j job) {
+	body, err := json.Marshal(j.event)
+	if err != nil {
+		d.deadLetter(ctx, j, 0, err.Error())
+		return
+	}
+	wait := d.backoff
+	var lastErr error
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		if lastErr = d.post(ctx, j, body); lastErr == nil {
+			d.logger.InfoContext(ctx, "webhook delivered", "subscription", j.event.SubscriptionID,
+				"event", j.event.ID, "attempt", attempt)
+			return
+		}
+		d.logger.WarnContext(ctx, "webhook delivery failed", "subscription", j.event.SubscriptionID,
+			"event", j.event.ID, "attempt", attempt, "err", lastErr)
+		if attempt == d.maxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			d.deadLetter(ctx, j, attempt, "shutdown: "+lastErr.Error())
+			return
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	d.deadLetter(ctx, j, d.maxAttempts, lastErr.Error())
+}
+
+// post sends one signed delivery; any non-2xx answer is an error.
+func (d *Dispatcher) post(ctx context.Context, j job, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(j.secret, body))
+	req.Header.Set(EventHeader, string(j.event.Type))
+	req.Header.Set(DeliveryHeader, j.event.ID)
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck // body is drained and discarded
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (d *Dispatcher) deadLetter(ctx context.Context, j job, attempts int, reason string) {
+	d.logger.ErrorContext(ctx, "webhook dead-lettered", "subscription", j.event.SubscriptionID,
+		"event", j.event.ID, "attempts", attempts, "err", reason)
+	err := d.store.AddDeadLetter(DeadLetter{
+		Event:       j.event,
+		CallbackURL: j.url,
+		Attempts:    attempts,
+		LastError:   reason,
+		FailedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		d.logger.ErrorContext(ctx, "persist dead letter", "err", err)
+	}
+}
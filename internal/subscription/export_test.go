@@ -0,0 +1,9 @@
+package subscription
+
+import "testing"
+
+// AllowPrivateCallbacks lets t's subscriptions use httptest servers on loopback.
+func AllowPrivateCallbacks(t *testing.T) {
+	allowPrivate = true
+	t.Cleanup(func() { allowPrivate = false })
+}
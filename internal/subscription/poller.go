@@ -0,0 +1,133 @@
+package subscription
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+)
+
+// Poller periodically refreshes every subscribed location through the forecast
+// Service (and therefore its cache, so subscriptions sharing a location or grid
+// cell cost one upstream fetch) and enqueues events for triggers that fire.
+type Poller struct {
+	store      *Store
+	svc        forecast.Service
+	alerts     AlertsFunc
+	dispatcher *Dispatcher
+	interval   time.Duration
+	logger     *slog.Logger
+}
+
+// NewPoller constructs a Poller that checks subscriptions every interval.
+// alerts looks up the alerts new_alert triggers watch; nil disables them.
+func NewPoller(store *Store, svc forecast.Service, alerts AlertsFunc, dispatcher *Dispatcher,
+	interval time.Duration, logger *slog.Logger,
+) *Poller {
+	return &Poller{store: store, svc: svc, alerts: alerts, dispatcher: dispatcher, interval: interval, logger: logger}
+}
+
+// Run polls until ctx is done.
+func (p *Poller) Run(ctx context.Context) {
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+	for {
+		p.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Poll checks every subscription once. The first observation of a subscription
+// only records a baseline; events fire on later changes. When the period moves
+// on (day to night, or to the next date) the new period becomes the baseline.
+// The observed states are persisted together at the end of the pass.
+func (p *Poller) Poll(ctx context.Context) {
+	states := make(map[string]State)
+	defer func() {
+		if err := p.store.SetStates(states); err != nil {
+			p.logger.ErrorContext(ctx, "persist subscription states", "subscriptions", len(states), "err", err)
+		}
+	}()
+	pass := make(map[[2]float64][]nws.Alert) // alerts by location, fetched once per pass
+	for _, sub := range p.store.List() {
+		if ctx.Err() != nil {
+			return
+		}
+		res, err := p.svc.GetTodaysForcast(ctx, sub.Lat, sub.Lon)
+		if err != nil {
+			p.logger.WarnContext(ctx, "subscription poll failed", "subscription", sub.ID, "err", err)
+			continue
+		}
+		cur := State{
+			Date:           res.Date,
+			Daytime:        res.IsDaytime,
+			Temperature:    res.Today.Temperature.Value,
+			Unit:           res.Today.Temperature.Unit,
+			Classification: res.Today.Temperature.Type,
+			ShortForecast:  res.Today.ShortForecast,
+			ObservedAt:     time.Now().UTC(),
+		}
+		alerts, alertsOK := p.activeAlerts(ctx, sub, pass)
+		if alertsOK {
+			for _, a := range alerts {
+				cur.Alerts = append(cur.Alerts, a.ID)
+			}
+		} else if sub.Last != nil {
+			cur.Alerts = sub.Last.Alerts // unknown this pass; keep them so nothing fires twice
+		}
+		if sub.Last != nil && Comparable(*sub.Last, cur) {
+			for _, t := range Evaluate(sub.Triggers, *sub.Last, cur) {
+				p.dispatcher.Enqueue(ctx, sub, p.event(sub, t, cur, nil))
+			}
+		}
+		if sub.Last != nil && alertsOK {
+			for _, a := range NewAlerts(*sub.Last, alerts) {
+				p.dispatcher.Enqueue(ctx, sub, p.event(sub, Trigger{Type: TriggerNewAlert}, cur, &a))
+			}
+		}
+		states[sub.ID] = cur
+	}
+}
+
+// activeAlerts returns the alerts in effect at sub's location when it has a
+// new_alert trigger, and false when they are not watched or the lookup failed.
+func (p *Poller) activeAlerts(ctx context.Context, sub Subscription, pass map[[2]float64][]nws.Alert,
+) ([]nws.Alert, bool) {
+	if p.alerts == nil || !sub.HasTrigger(TriggerNewAlert) {
+		return nil, false
+	}
+	loc := [2]float64{sub.Lat, sub.Lon}
+	if alerts, ok := pass[loc]; ok {
+		return alerts, true
+	}
+	alerts, err := p.alerts(ctx, sub.Lat, sub.Lon)
+	if err != nil {
+		p.logger.WarnContext(ctx, "subscription alerts lookup failed", "subscription", sub.ID, "err", err)
+		return nil, false
+	}
+	pass[loc] = alerts
+	return alerts, true
+}
+
+func (p *Poller) event(sub Subscription, t Trigger, cur State, alert *nws.Alert) Event {
+	return Event{
+		ID:             uuid.NewString(),
+		SubscriptionID: sub.ID,
+		Type:           t.Type,
+		Lat:            sub.Lat,
+		Lon:            sub.Lon,
+		Threshold:      t.Threshold,
+		Alert:          alert,
+		Previous:       *sub.Last,
+		Current:        cur,
+		OccurredAt:     cur.ObservedAt,
+	}
+}
@@ -0,0 +1,233 @@
+package subscription
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	secretBytes    = 32
+	tokenBytes     = 32
+	maxDeadLetters = 1000
+)
+
+// ErrInvalid is wrapped by errors returned for subscriptions that fail validation.
+var ErrInvalid = errors.New("invalid subscription")
+
+// DeadLetter is an event whose delivery was abandoned after exhausting retries.
+type DeadLetter struct {
+	Event       Event     `json:"event"`
+	CallbackURL string    `json:"callbackURL"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"lastError"`
+	FailedAt    time.Time `json:"failedAt"`
+}
+
+// Store keeps subscriptions and dead letters in memory and persists every change
+// to a JSON file so they survive restarts. It is safe for concurrent use.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	subs map[string]Subscription
+	dead []DeadLetter
+}
+
+type storeFile struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	DeadLetters   []DeadLetter   `json:"deadLetters"`
+}
+
+// OpenStore loads the store at path, starting empty when the file does not exist yet.
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path, subs: make(map[string]Subscription)}
+	b, err := os.ReadFile(path) //nolint:gosec // path is operator supplied
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read subscription store: %w", err)
+	}
+	var f storeFile
+	if err = json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parse subscription store %s: %w", path, err)
+	}
+	for _, sub := range f.Subscriptions {
+		s.subs[sub.ID] = sub
+	}
+	s.dead = f.DeadLetters
+	return s, nil
+}
+
+// Create validates sub, assigns it an ID, creation time, signing secret and
+// access token, and persists it. The returned subscription includes the secret
+// and the token; only the token's hash is stored.
+func (s *Store) Create(sub Subscription) (Subscription, error) {
+	if err := sub.Validate(); err != nil {
+		return Subscription{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	secret, err := randomHex(secretBytes)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("generate secret: %w", err)
+	}
+	token, err := randomHex(tokenBytes)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("generate token: %w", err)
+	}
+	sub.ID = uuid.NewString()
+	sub.Secret = secret
+	sub.Token = ""
+	sub.TokenHash = hashToken(token)
+	sub.CreatedAt = time.Now().UTC()
+	sub.Last = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs[sub.ID] = sub
+	if err := s.saveLocked(); err != nil {
+		delete(s.subs, sub.ID)
+		return Subscription{}, err
+	}
+	sub.Token = token
+	return sub, nil
+}
+
+// Authorize returns the subscription with the given ID if token is its access
+// token. An unknown ID and a wrong token are indistinguishable to the caller.
+func (s *Store) Authorize(id, token string) (Subscription, bool) {
+	sub, ok := s.Get(id)
+	if !ok || sub.TokenHash == "" {
+		return Subscription{}, false
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(sub.TokenHash)) != 1 {
+		return Subscription{}, false
+	}
+	return sub, true
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the subscription with the given ID.
+func (s *Store) Get(id string) (Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[id]
+	return sub, ok
+}
+
+// List returns every subscription ordered by creation time.
+func (s *Store) List() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		out = append(out, sub)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Delete removes a subscription and reports whether it existed.
+func (s *Store) Delete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[id]; !ok {
+		return false, nil
+	}
+	delete(s.subs, id)
+	return true, s.saveLocked()
+}
+
+// SetStates records the latest observed states of subscriptions, keyed by ID,
+// with a single write of the store. Unknown IDs (deleted while a poll was in
+// flight) are ignored.
+func (s *Store) SetStates(states map[string]State) error {
+	if len(states) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, st := range states {
+		sub, ok := s.subs[id]
+		if !ok {
+			continue
+		}
+		sub.Last = &st
+		s.subs[id] = sub
+	}
+	return s.saveLocked()
+}
+
+// AddDeadLetter records an abandoned delivery, keeping only the most recent ones.
+func (s *Store) AddDeadLetter(d DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dead = append(s.dead, d)
+	if len(s.dead) > maxDeadLetters {
+		s.dead = s.dead[len(s.dead)-maxDeadLetters:]
+	}
+	return s.saveLocked()
+}
+
+// DeadLetters returns the recorded abandoned deliveries, oldest first.
+func (s *Store) DeadLetters() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]DeadLetter(nil), s.dead...)
+}
+
+// saveLocked writes the store atomically (temp file + rename). s.mu must be held.
+func (s *Store) saveLocked() error {
+	f := storeFile{DeadLetters: s.dead}
+	for _, sub := range s.subs {
+		f.Subscriptions = append(f.Subscriptions, sub)
+	}
+	sort.Slice(f.Subscriptions, func(i, j int) bool { return f.Subscriptions[i].ID < f.Subscriptions[j].ID })
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create subscription store dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".subscriptions-*.json")
+	if err != nil {
+		return fmt.Errorf("write subscription store: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op after a successful rename
+	if _, err = tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write subscription store: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("write subscription store: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write subscription store: %w", err)
+	}
+	return nil
+}
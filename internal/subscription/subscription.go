@@ -0,0 +1,169 @@
+// Package subscription implements webhook subscriptions for forecast changes:
+// a file-backed store, a poller that evaluates triggers against fresh forecast
+// results, and a dispatcher that delivers signed payloads with retries.
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"time"
+
+	"weather-service/internal/nws"
+)
+
+// TriggerType names a condition that causes a webhook delivery.
+type TriggerType string
+
+// Supported trigger types.
+const (
+	// TriggerClassificationChange fires when today's hot/moderate/cold classification changes.
+	TriggerClassificationChange TriggerType = "classification_change"
+	// TriggerTemperatureAbove fires when today's temperature rises above Threshold.
+	TriggerTemperatureAbove TriggerType = "temperature_above"
+	// TriggerTemperatureBelow fires when today's temperature falls below Threshold.
+	TriggerTemperatureBelow TriggerType = "temperature_below"
+	// TriggerNewAlert fires once for every NWS alert issued for the location.
+	TriggerNewAlert TriggerType = "new_alert"
+)
+
+// AlertsFunc returns the alerts in effect at a location, like nws.Client.Alerts.
+type AlertsFunc func(ctx context.Context, lat, lon float64) ([]nws.Alert, error)
+
+// Trigger is one condition of a subscription.
+type Trigger struct {
+	Type      TriggerType `json:"type"`
+	Threshold *int        `json:"threshold,omitempty"` // Fahrenheit; temperature triggers only
+}
+
+// Subscription registers a callback URL for forecast changes at a location.
+type Subscription struct {
+	ID          string    `json:"id"`
+	Lat         float64   `json:"lat"`
+	Lon         float64   `json:"lon"`
+	Triggers    []Trigger `json:"triggers"`
+	CallbackURL string    `json:"callbackURL"`
+	// Secret is the HMAC-SHA256 key used to sign deliveries. It is only returned
+	// to the client when the subscription is created.
+	Secret string `json:"secret,omitempty"`
+	// Token is the bearer token that authorizes reading and deleting the
+	// subscription. It is only returned when the subscription is created; the
+	// store keeps its SHA-256 in TokenHash.
+	Token     string    `json:"token,omitempty"`
+	TokenHash string    `json:"tokenHash,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Last is the most recently observed forecast state, used to detect changes.
+	Last *State `json:"last,omitempty"`
+}
+
+// State is the part of a forecast result that triggers are evaluated against.
+type State struct {
+	Date           string    `json:"date"`
+	Daytime        bool      `json:"daytime"` // whether the period is the date's day or its night
+	Temperature    int       `json:"temperature"`
+	Unit           string    `json:"unit"`
+	Classification string    `json:"classification"`
+	ShortForecast  string    `json:"shortForecast"`
+	Alerts         []string  `json:"alerts,omitempty"` // IDs of the alerts in effect; new_alert subscriptions only
+	ObservedAt     time.Time `json:"observedAt"`
+}
+
+// Event is a triggered change, delivered as the webhook payload.
+type Event struct {
+	ID             string      `json:"id"`
+	SubscriptionID string      `json:"subscriptionId"`
+	Type           TriggerType `json:"type"`
+	Lat            float64     `json:"lat"`
+	Lon            float64     `json:"lon"`
+	Threshold      *int        `json:"threshold,omitempty"`
+	Alert          *nws.Alert  `json:"alert,omitempty"` // the issued alert; new_alert events only
+	Previous       State       `json:"previous"`
+	Current        State       `json:"current"`
+	OccurredAt     time.Time   `json:"occurredAt"`
+}
+
+// Validate reports every problem with a subscription request.
+func (s Subscription) Validate() error {
+	var errs []error
+	if math.IsNaN(s.Lat) || s.Lat < -90 || s.Lat > 90 {
+		errs = append(errs, errors.New("invalid lat"))
+	}
+	if math.IsNaN(s.Lon) || s.Lon < -180 || s.Lon > 180 {
+		errs = append(errs, errors.New("invalid lon"))
+	}
+	u, err := url.Parse(s.CallbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, errors.New("callbackURL must be an absolute http(s) URL"))
+	} else if err := checkCallbackHost(u.Hostname()); err != nil {
+		errs = append(errs, err)
+	}
+	if len(s.Triggers) == 0 {
+		errs = append(errs, errors.New("at least one trigger is required"))
+	}
+	for i, t := range s.Triggers {
+		switch t.Type {
+		case TriggerClassificationChange, TriggerNewAlert:
+			if t.Threshold != nil {
+				errs = append(errs, fmt.Errorf("triggers[%d]: %s takes no threshold", i, t.Type))
+			}
+		case TriggerTemperatureAbove, TriggerTemperatureBelow:
+			if t.Threshold == nil {
+				errs = append(errs, fmt.Errorf("triggers[%d]: %s requires a threshold", i, t.Type))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("triggers[%d]: unsupported type %q", i, t.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Comparable reports whether prev and cur describe the same period: the same
+// local date and both day or both night. The evening switch from "Today" to
+// "Tonight" is not a forecast change, so triggers are not evaluated across it.
+func Comparable(prev, cur State) bool {
+	return prev.Date == cur.Date && prev.Daytime == cur.Daytime
+}
+
+// Evaluate compares the previous and current state of the same period (see
+// Comparable) and returns the triggers that fired. Temperature triggers fire on
+// crossings only, so a location that stays above a threshold is reported once
+// rather than on every poll. New alerts are not tied to a period; see NewAlerts.
+func Evaluate(triggers []Trigger, prev, cur State) []Trigger {
+	var fired []Trigger
+	for _, t := range triggers {
+		switch t.Type {
+		case TriggerClassificationChange:
+			if prev.Classification != cur.Classification {
+				fired = append(fired, t)
+			}
+		case TriggerTemperatureAbove:
+			if t.Threshold != nil && prev.Temperature <= *t.Threshold && cur.Temperature > *t.Threshold {
+				fired = append(fired, t)
+			}
+		case TriggerTemperatureBelow:
+			if t.Threshold != nil && prev.Temperature >= *t.Threshold && cur.Temperature < *t.Threshold {
+				fired = append(fired, t)
+			}
+		}
+	}
+	return fired
+}
+
+// HasTrigger reports whether the subscription has a trigger of type tt.
+func (s Subscription) HasTrigger(tt TriggerType) bool {
+	return slices.ContainsFunc(s.Triggers, func(t Trigger) bool { return t.Type == tt })
+}
+
+// NewAlerts returns the alerts in cur that were not in effect in prev.
+func NewAlerts(prev State, cur []nws.Alert) []nws.Alert {
+	var fresh []nws.Alert
+	for _, a := range cur {
+		if !slices.Contains(prev.Alerts, a.ID) {
+			fresh = append(fresh, a)
+		}
+	}
+	return fresh
+}
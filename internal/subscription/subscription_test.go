@@ -0,0 +1,410 @@
+package subscription_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+	"weather-service/internal/subscription"
+)
+
+func intp(i int) *int { return &i }
+
+func discard() *slog.Logger { return slog.New(slog.NewTextHandler(io.Discard, nil)) }
+
+func validSub() subscription.Subscription {
+	return subscription.Subscription{
+		Lat:         39.7,
+		Lon:         -104.9,
+		CallbackURL: "https://hooks.example.com/weather",
+		Triggers: []subscription.Trigger{
+			{Type: subscription.TriggerClassificationChange},
+			{Type: subscription.TriggerTemperatureAbove, Threshold: intp(90)},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := validSub().Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bad := subscription.Subscription{
+		Lat:         91,
+		CallbackURL: "ftp://x",
+		Triggers: []subscription.Trigger{
+			{Type: subscription.TriggerTemperatureBelow},
+			{Type: subscription.TriggerNewAlert, Threshold: intp(1)},
+		},
+	}
+	err := bad.Validate()
+	if err == nil {
+		t.Fatalf("expected validation errors")
+	}
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 4 {
+		t.Fatalf("expected 4 errors, got %v", err)
+	}
+}
+
+func TestValidateRejectsInternalCallbacks(t *testing.T) {
+	for _, u := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://api.localhost/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	} {
+		sub := validSub()
+		sub.CallbackURL = u
+		if err := sub.Validate(); err == nil {
+			t.Errorf("%s: expected rejection", u)
+		}
+	}
+}
+
+func TestCallbackClientRefusesPrivateAddresses(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { calls.Add(1) }))
+	defer srv.Close()
+
+	// Names that pass Validate are checked again once resolved, at dial time.
+	if _, err := subscription.NewCallbackClient(time.Second).Post(srv.URL, "application/json", nil); err == nil {
+		t.Fatal("expected the dial to be refused")
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("private address was reached %d times", calls.Load())
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	triggers := validSub().Triggers
+	triggers = append(triggers, subscription.Trigger{Type: subscription.TriggerTemperatureBelow, Threshold: intp(40)})
+	state := func(temp int, class string) subscription.State {
+		return subscription.State{Temperature: temp, Classification: class}
+	}
+	cases := []struct {
+		name      string
+		prev, cur subscription.State
+		want      []subscription.TriggerType
+	}{
+		{"no change", state(70, "moderate"), state(72, "moderate"), nil},
+		{"crosses above", state(84, "moderate"), state(91, "hot"),
+			[]subscription.TriggerType{subscription.TriggerClassificationChange, subscription.TriggerTemperatureAbove}},
+		{"stays above", state(92, "hot"), state(95, "hot"), nil},
+		{"crosses below", state(46, "moderate"), state(39, "cold"),
+			[]subscription.TriggerType{subscription.TriggerClassificationChange, subscription.TriggerTemperatureBelow}},
+	}
+	for _, c := range cases {
+		fired := subscription.Evaluate(triggers, c.prev, c.cur)
+		if len(fired) != len(c.want) {
+			t.Fatalf("%s: fired %v want %v", c.name, fired, c.want)
+		}
+		for i := range fired {
+			if fired[i].Type != c.want[i] {
+				t.Fatalf("%s: fired %v want %v", c.name, fired, c.want)
+			}
+		}
+	}
+}
+
+func TestStorePersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "subscriptions.json")
+	store, err := subscription.OpenStore(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	sub, err := store.Create(validSub())
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if sub.ID == "" || len(sub.Secret) != 64 {
+		t.Fatalf("expected id and 32-byte hex secret, got %+v", sub)
+	}
+	if _, err = store.Create(subscription.Subscription{}); !errors.Is(err, subscription.ErrInvalid) {
+		t.Fatalf("err=%v want ErrInvalid", err)
+	}
+	states := map[string]subscription.State{sub.ID: {Temperature: 70}, "deleted": {Temperature: 1}}
+	if err = store.SetStates(states); err != nil {
+		t.Fatalf("set state: %v", err)
+	}
+	if err = store.AddDeadLetter(subscription.DeadLetter{Attempts: 5}); err != nil {
+		t.Fatalf("dead letter: %v", err)
+	}
+
+	reopened, err := subscription.OpenStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got, ok := reopened.Get(sub.ID)
+	if !ok || got.Secret != sub.Secret || got.Last == nil || got.Last.Temperature != 70 {
+		t.Fatalf("subscription not persisted: %+v", got)
+	}
+	if len(reopened.DeadLetters()) != 1 {
+		t.Fatalf("dead letters not persisted")
+	}
+	if ok, err = reopened.Delete(sub.ID); !ok || err != nil {
+		t.Fatalf("delete: ok=%v err=%v", ok, err)
+	}
+	if len(reopened.List()) != 0 {
+		t.Fatalf("expected no subscriptions after delete")
+	}
+}
+
+func TestDispatcherSignsAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer srv.Close()
+
+	store, _ := subscription.OpenStore(filepath.Join(t.TempDir(), "s.json"))
+	sub, _ := store.Create(validSub())
+	sub.CallbackURL = srv.URL
+
+	d := subscription.NewDispatcher(store, srv.Client(), discard(), 5, time.Millisecond)
+	ctx := run(t, d)
+	d.Enqueue(ctx, sub, subscription.Event{ID: "evt-1", SubscriptionID: sub.ID, Type: subscription.TriggerClassificationChange})
+
+	select {
+	case r := <-received:
+		if got := r.Header.Get(subscription.SignatureHeader); got != subscription.Sign(sub.Secret, body) {
+			t.Fatalf("bad signature %q", got)
+		}
+		if r.Header.Get(subscription.DeliveryHeader) != "evt-1" {
+			t.Fatalf("missing delivery id")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("delivery not received")
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("calls=%d want 3", calls.Load())
+	}
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	store, _ := subscription.OpenStore(filepath.Join(t.TempDir(), "s.json"))
+	sub, _ := store.Create(validSub())
+	sub.CallbackURL = srv.URL
+
+	d := subscription.NewDispatcher(store, srv.Client(), discard(), 2, time.Millisecond)
+	ctx := run(t, d)
+	d.Enqueue(ctx, sub, subscription.Event{ID: "evt-2", SubscriptionID: sub.ID})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(store.DeadLetters()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("event was not dead-lettered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if dl := store.DeadLetters()[0]; dl.Attempts != 2 || dl.Event.ID != "evt-2" {
+		t.Fatalf("unexpected dead letter: %+v", dl)
+	}
+}
+
+// run starts d and, when the test ends, stops it and waits for in-flight
+// deliveries before the test's temporary directory is removed.
+func run(t *testing.T, d *subscription.Dispatcher) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return ctx
+}
+
+type fakeSvc struct {
+	forecast.Service
+	mu    sync.Mutex
+	temp  int
+	night bool
+}
+
+func (f *fakeSvc) set(temp int, night bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.temp, f.night = temp, night
+}
+
+func (f *fakeSvc) GetTodaysForcast(_ context.Context, _, _ float64) (forecast.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res forecast.Result
+	res.Date = "2025-08-13"
+	res.IsDaytime = !f.night
+	res.Today.Temperature.Value = f.temp
+	res.Today.Temperature.Type = forecast.Classify(f.temp, forecast.Bands{ColdMax: 45, HotMin: 85})
+	return res, nil
+}
+
+func TestPollerBaselineThenEvents(t *testing.T) {
+	events := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		events <- r.Header.Get(subscription.EventHeader)
+	}))
+	defer srv.Close()
+
+	store, _ := subscription.OpenStore(filepath.Join(t.TempDir(), "s.json"))
+	sub := validSub()
+	subscription.AllowPrivateCallbacks(t)
+	sub.CallbackURL = srv.URL
+	if _, err := store.Create(sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	svc := &fakeSvc{temp: 80}
+	d := subscription.NewDispatcher(store, srv.Client(), discard(), 1, time.Millisecond)
+	p := subscription.NewPoller(store, svc, nil, d, time.Hour, discard())
+	ctx := run(t, d)
+
+	p.Poll(ctx) // baseline only
+	svc.set(95, false)
+	p.Poll(ctx)
+
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-events:
+			got[e] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected 2 deliveries, got %v", got)
+		}
+	}
+	if !got["classification_change"] || !got["temperature_above"] {
+		t.Fatalf("unexpected events: %v", got)
+	}
+}
+
+func TestPollerRebaselinesWhenThePeriodChanges(t *testing.T) {
+	events := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		events <- r.Header.Get(subscription.EventHeader)
+	}))
+	defer srv.Close()
+
+	store, _ := subscription.OpenStore(filepath.Join(t.TempDir(), "s.json"))
+	sub := validSub()
+	subscription.AllowPrivateCallbacks(t)
+	sub.CallbackURL = srv.URL
+	sub.Triggers = append(sub.Triggers, subscription.Trigger{Type: subscription.TriggerTemperatureBelow, Threshold: intp(70)})
+	if _, err := store.Create(sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	svc := &fakeSvc{temp: 86}
+	d := subscription.NewDispatcher(store, srv.Client(), discard(), 1, time.Millisecond)
+	p := subscription.NewPoller(store, svc, nil, d, time.Hour, discard())
+	ctx := run(t, d)
+
+	p.Poll(ctx)
+	svc.set(62, true) // the evening's "Tonight" replaces "Today"
+	p.Poll(ctx)
+	svc.set(60, true)
+	p.Poll(ctx)
+	select {
+	case e := <-events:
+		t.Fatalf("day to night fired %s", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if got := store.List()[0].Last; got == nil || got.Daytime || got.Temperature != 60 {
+		t.Fatalf("baseline not moved to the night: %+v", got)
+	}
+}
+
+func TestPollerNewAlert(t *testing.T) {
+	events := make(chan subscription.Event, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var e subscription.Event
+		_ = json.NewDecoder(r.Body).Decode(&e)
+		events <- e
+	}))
+	defer srv.Close()
+
+	store, _ := subscription.OpenStore(filepath.Join(t.TempDir(), "s.json"))
+	sub := validSub()
+	subscription.AllowPrivateCallbacks(t)
+	sub.CallbackURL = srv.URL
+	sub.Triggers = []subscription.Trigger{{Type: subscription.TriggerNewAlert}}
+	if _, err := store.Create(sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	var (
+		mu     sync.Mutex
+		active = []nws.Alert{{ID: "a1", Event: "Heat Advisory"}}
+		fail   bool
+	)
+	alerts := func(context.Context, float64, float64) ([]nws.Alert, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			return nil, errors.New("upstream down")
+		}
+		return active, nil
+	}
+	svc := &fakeSvc{temp: 80}
+	d := subscription.NewDispatcher(store, srv.Client(), discard(), 1, time.Millisecond)
+	p := subscription.NewPoller(store, svc, alerts, d, time.Hour, discard())
+	ctx := run(t, d)
+
+	p.Poll(ctx) // a1 is the baseline
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	p.Poll(ctx) // a failed lookup keeps a1
+	mu.Lock()
+	fail = false
+	active = append(active, nws.Alert{ID: "a2", Event: "Flood Watch"})
+	mu.Unlock()
+	p.Poll(ctx)
+	svc.set(62, true) // alerts fire across periods
+	p.Poll(ctx)
+
+	select {
+	case e := <-events:
+		if e.Type != subscription.TriggerNewAlert || e.Alert == nil || e.Alert.ID != "a2" {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a new_alert delivery")
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected second event: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,115 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReloadInterval is how often Watch checks the certificate files.
+const ReloadInterval = 10 * time.Second
+
+// Certificate is a certificate and key loaded from PEM files and swapped for
+// the new pair when the files change. Connections already established keep
+// the certificate they were handshaken with; new handshakes use the latest one.
+type Certificate struct {
+	certFile, keyFile string
+	log               *slog.Logger
+
+	cert atomic.Pointer[tls.Certificate]
+
+	mu      sync.Mutex
+	version string // modification times and sizes of the files last loaded
+}
+
+// LoadCertificate loads the certificate and key at certFile and keyFile.
+func LoadCertificate(certFile, keyFile string, log *slog.Logger) (*Certificate, error) {
+	c := &Certificate{certFile: certFile, keyFile: keyFile, log: log}
+	if _, err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config.GetCertificate.
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// Reload loads the files again if they changed since the last load and reports
+// whether the certificate was replaced. On error the previous one stays in use.
+func (c *Certificate) Reload() (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	version, err := c.fileVersion()
+	if err != nil {
+		return false, err
+	}
+	if version == c.version {
+		return false, nil
+	}
+	pair, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		// Rotations write the two files one after the other; a mismatched pair
+		// is retried on the next check.
+		return false, fmt.Errorf("load certificate: %w", err)
+	}
+	if pair.Leaf == nil {
+		if pair.Leaf, err = x509.ParseCertificate(pair.Certificate[0]); err != nil {
+			return false, fmt.Errorf("parse certificate: %w", err)
+		}
+	}
+	c.cert.Store(&pair)
+	c.version = version
+	return true, nil
+}
+
+// Watch checks the files every interval until ctx is done, reloading them when
+// they change. Failures are logged and the previous certificate kept.
+func (c *Certificate) Watch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			reloaded, err := c.Reload()
+			switch {
+			case err != nil:
+				c.log.Warn("tls certificate reload failed; keeping the current one", "cert", c.certFile, "err", err)
+			case reloaded:
+				c.log.Info("tls certificate reloaded", "cert", c.certFile, "expires", c.expiry())
+			}
+		}
+	}
+}
+
+// fileVersion identifies the current contents of the files. Stat follows
+// symlinks, so atomically swapped mounts (as Kubernetes does for secrets)
+// count as changes.
+func (c *Certificate) fileVersion() (string, error) {
+	var v string
+	for _, name := range []string{c.certFile, c.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return "", fmt.Errorf("stat certificate file: %w", err)
+		}
+		v += fmt.Sprintf("%d:%d;", fi.ModTime().UnixNano(), fi.Size())
+	}
+	return v, nil
+}
+
+func (c *Certificate) expiry() time.Time {
+	if cert := c.cert.Load(); cert != nil && cert.Leaf != nil {
+		return cert.Leaf.NotAfter
+	}
+	return time.Time{}
+}
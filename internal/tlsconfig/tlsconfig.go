@@ -0,0 +1,90 @@
+// Package tlsconfig builds the server's TLS configuration: a certificate that
+// is reloaded when its files change, protocol limits and optional client
+// certificate verification.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Client certificate policies for Options.ClientAuth.
+const (
+	ClientAuthRequire       = "require"         // every client must present a certificate signed by the CA
+	ClientAuthVerifyIfGiven = "verify-if-given" // certificates are verified when presented, e.g. not by probes
+)
+
+// Options configures New.
+type Options struct {
+	// ClientCAFile is a PEM bundle of CAs for client certificates. Empty
+	// disables mutual TLS.
+	ClientCAFile string
+	ClientAuth   string   // ClientAuthRequire (default) or ClientAuthVerifyIfGiven
+	MinVersion   string   // "1.2" or "1.3"
+	CipherSuites []string // TLS 1.2 suites by name (see tls.CipherSuites); empty keeps Go's defaults
+}
+
+// New returns a server TLS configuration presenting cert.
+func New(opts Options, cert *Certificate) (*tls.Config, error) {
+	minVersion, err := Version(opts.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: cert.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	for _, name := range opts.CipherSuites {
+		id, ok := CipherSuite(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		cfg.CipherSuites = append(cfg.CipherSuites, id)
+	}
+
+	if opts.ClientCAFile == "" {
+		return cfg, nil
+	}
+	pem, err := os.ReadFile(opts.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("client CA bundle %s has no PEM certificates", opts.ClientCAFile)
+	}
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	if opts.ClientAuth == ClientAuthVerifyIfGiven {
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return cfg, nil
+}
+
+// Version parses a minimum TLS version, "1.2" or "1.3".
+func Version(s string) (uint16, error) {
+	switch s {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, errors.New("unsupported TLS version " + s + " (want 1.2 or 1.3)")
+	}
+}
+
+// CipherSuite returns the ID of a secure cipher suite named as in
+// tls.CipherSuites, e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256.
+func CipherSuite(name string) (uint16, bool) {
+	for _, cs := range tls.CipherSuites() {
+		if strings.EqualFold(cs.Name, name) {
+			return cs.ID, true
+		}
+	}
+	return 0, false
+}
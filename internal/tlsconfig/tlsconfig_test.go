@@ -0,0 +1,200 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	stdlog "log"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	logpkg "weather-service/internal/log"
+	"weather-service/internal/server"
+	"weather-service/internal/tlsconfig"
+)
+
+// issued is a certificate with its key, signed by itself or by a CA.
+type issued struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func issue(t *testing.T, cn string, serial int64, parent *issued, usage ...x509.ExtKeyUsage) *issued {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Weather"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  usage,
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &issued{cert: cert, key: key, der: der}
+}
+
+// write stores the certificate and key as PEM files in dir, with a modification
+// time of at so successive writes are told apart.
+func (c *issued) write(t *testing.T, dir string, at time.Time) (string, string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	for name, block := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: c.der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err := os.WriteFile(name, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return certFile, keyFile
+}
+
+func discard() *slog.Logger { return slog.New(slog.NewTextHandler(io.Discard, nil)) }
+
+func serial(t *testing.T, c *tlsconfig.Certificate) int64 {
+	t.Helper()
+	cert, err := c.GetCertificate(nil)
+	if err != nil || cert == nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	return cert.Leaf.SerialNumber.Int64()
+}
+
+func TestCertificateReload(t *testing.T) {
+	ca := issue(t, "Test CA", 1, nil)
+	dir := t.TempDir()
+	now := time.Now()
+	certFile, keyFile := issue(t, "localhost", 10, ca, x509.ExtKeyUsageServerAuth).write(t, dir, now)
+
+	c, err := tlsconfig.LoadCertificate(certFile, keyFile, discard())
+	if err != nil {
+		t.Fatalf("LoadCertificate: %v", err)
+	}
+	if serial(t, c) != 10 {
+		t.Fatalf("serial=%d want 10", serial(t, c))
+	}
+	if reloaded, err := c.Reload(); reloaded || err != nil {
+		t.Fatalf("unchanged files: reloaded=%v err=%v", reloaded, err)
+	}
+
+	issue(t, "localhost", 11, ca, x509.ExtKeyUsageServerAuth).write(t, dir, now.Add(time.Minute))
+	if reloaded, err := c.Reload(); !reloaded || err != nil || serial(t, c) != 11 {
+		t.Fatalf("rotation: reloaded=%v err=%v serial=%d", reloaded, err, serial(t, c))
+	}
+
+	// Half-written rotation: the new certificate next to the old key.
+	next := issue(t, "localhost", 12, ca, x509.ExtKeyUsageServerAuth)
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: next.der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Reload(); err == nil {
+		t.Fatal("mismatched key accepted")
+	}
+	if serial(t, c) != 11 {
+		t.Fatalf("serial=%d after failed reload, want 11", serial(t, c))
+	}
+}
+
+func TestMutualTLSCallerIdentity(t *testing.T) {
+	ca := issue(t, "Test CA", 1, nil)
+	certFile, keyFile := issue(t, "localhost", 2, ca, x509.ExtKeyUsageServerAuth).write(t, t.TempDir(), time.Now())
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tlsconfig.LoadCertificate(certFile, keyFile, discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := tlsconfig.New(tlsconfig.Options{ClientCAFile: caFile, MinVersion: "1.2",
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}}, cert)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(server.WithMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, logpkg.Caller(r.Context()))
+	}), 0))
+	srv.TLS = cfg
+	srv.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := issue(t, "forecast-ui", 3, ca, x509.ExtKeyUsageClientAuth)
+	get := func(certs ...tls.Certificate) (string, error) {
+		// httptest adds its own certificate, which is served to clients not
+		// sending SNI; naming the server selects GetCertificate instead.
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs: roots, Certificates: certs, ServerName: "localhost"}}}
+		defer c.CloseIdleConnections()
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	got, err := get(tls.Certificate{Certificate: [][]byte{client.der}, PrivateKey: client.key})
+	if err != nil {
+		t.Fatalf("with client certificate: %v", err)
+	}
+	if got != "CN=forecast-ui,O=Weather" {
+		t.Fatalf("caller=%q", got)
+	}
+	if _, err = get(); err == nil {
+		t.Fatal("request without a client certificate succeeded")
+	}
+}
+
+func TestNewRejectsBadOptions(t *testing.T) {
+	for _, opts := range []tlsconfig.Options{
+		{MinVersion: "1.0"},
+		{MinVersion: "1.2", CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+		{MinVersion: "1.2", ClientCAFile: filepath.Join(t.TempDir(), "missing.pem")},
+	} {
+		if _, err := tlsconfig.New(opts, nil); err == nil {
+			t.Errorf("New(%+v) succeeded", opts)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package verify
+
+import "time"
+
+// SetNow replaces the clock v uses.
+func SetNow(v *Verifier, now func() time.Time) { v.now = now }
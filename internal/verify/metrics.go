@@ -0,0 +1,49 @@
+package verify
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// metrics are the families WriteMetrics exposes, in output order.
+var metrics = []struct {
+	name, typ, help string
+	value           func(Score) float64
+}{
+	{"weather_forecast_verified_total", "counter", "Forecast temperatures compared with station observations.",
+		func(s Score) float64 { return float64(s.Verified) }},
+	{"weather_forecast_bias_degrees", "gauge", "Mean forecast minus observed temperature in Fahrenheit.",
+		func(s Score) float64 { return s.Bias }},
+	{"weather_forecast_mae_degrees", "gauge", "Mean absolute forecast temperature error in Fahrenheit.",
+		func(s Score) float64 { return s.MAE }},
+	{"weather_forecast_classification_hit_ratio", "gauge",
+		"Fraction of forecasts classified like the observed temperature.",
+		func(s Score) float64 { return s.HitRate }},
+}
+
+// WriteMetrics writes the scores in the Prometheus text exposition format,
+// labelled by location, lead_days and kind (high or low).
+func (v *Verifier) WriteMetrics(w io.Writer) error {
+	reports := v.Reports()
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+		for _, r := range reports {
+			for _, l := range r.Leads {
+				for _, k := range []struct {
+					kind string
+					s    *Score
+				}{{kindHigh, l.High}, {kindLow, l.Low}} {
+					if k.s == nil {
+						continue
+					}
+					fmt.Fprintf(&b, "%s{location=\"%.4f,%.4f\",lead_days=\"%d\",kind=%q} %g\n",
+						m.name, r.Lat, r.Lon, l.LeadDays, k.kind, m.value(*k.s))
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
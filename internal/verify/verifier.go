@@ -0,0 +1,639 @@
+// Package verify measures how accurate the forecasts the service serves turn
+// out to be, by comparing them with what the nearest station later observed.
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+	"weather-service/internal/prefetch"
+)
+
+const (
+	// dayStart and nightStart are the local hours NWS day and night periods
+	// begin: a date's high is observed from 06:00 to 18:00 and its low from
+	// 18:00 to 06:00 the next morning.
+	dayStart, nightStart = 6, 18
+	// settleDelay leaves time for the last observation of a night to be
+	// published before the date is scored.
+	settleDelay = time.Hour
+	// giveUpAfter is how long after settleDelay a date whose observations
+	// cannot be read is retried before it is dropped unscored.
+	giveUpAfter = 24 * time.Hour
+	// minSamples is the fewest observations a day or night needs to be scored.
+	minSamples = 6
+	// passTimeout bounds the upstream calls for one location or date.
+	passTimeout = 30 * time.Second
+	dateLayout  = "2006-01-02"
+)
+
+// Observer reads station observations. *nws.Client implements it.
+type Observer interface {
+	Stations(ctx context.Context, lat, lon float64) ([]string, error)
+	Observations(ctx context.Context, stationURL string, start, end time.Time) ([]nws.Observation, error)
+}
+
+// ScoreStore keeps scores, and the recorded forecasts of dates not yet scored,
+// across restarts. *history.Store implements it.
+type ScoreStore interface {
+	PutScore(key string, score []byte) error
+	Scores() (map[string][]byte, error)
+	PutPending(key string, forecasts []byte) error
+	DeletePending(key string) error
+	Pending() (map[string][]byte, error)
+}
+
+// Options configures a Verifier.
+type Options struct {
+	Targets  func() []prefetch.Location // locations to verify, looked up on every pass
+	LeadDays []int                      // how many days ahead recorded forecasts are made
+	Interval time.Duration              // how often forecasts are recorded and dates settled
+	Store    ScoreStore                 // where scores and pending forecasts are saved; nil keeps them in memory only
+}
+
+// Verifier records each target's daily forecasts at the configured lead times.
+// Once a date's night is over, it reads the nearest station's observations for
+// the date and scores the recorded high and low forecasts against the observed
+// daytime maximum and overnight minimum. Scores and recorded forecasts are
+// saved to Options.Store and loaded from it by New.
+type Verifier struct {
+	svc    forecast.Service
+	obs    Observer
+	bands  *forecast.BandsVar
+	opts   Options
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu     sync.Mutex
+	sites  map[prefetch.Location]*site
+	scores map[scoreKey]*tally
+}
+
+// site is what is known about one location.
+type site struct {
+	tz      *time.Location
+	station string
+	dates   map[string]*date // pending dates by local date
+}
+
+// date holds a pending date's recorded forecasts.
+type date struct {
+	forecasts map[int]prediction // by lead days
+}
+
+type prediction struct {
+	high, low *forecast.Temperature
+}
+
+type extreme struct {
+	v float64
+	n int
+}
+
+func (e *extreme) add(v float64, higher bool) {
+	if e.n == 0 || (higher && v > e.v) || (!higher && v < e.v) {
+		e.v = v
+	}
+	e.n++
+}
+
+const (
+	kindHigh = "high"
+	kindLow  = "low"
+)
+
+type scoreKey struct {
+	loc  prefetch.Location
+	lead int
+	kind string
+}
+
+// String is the key k is stored under.
+func (k scoreKey) String() string {
+	return fmt.Sprintf("%s/%d/%s", k.loc, k.lead, k.kind)
+}
+
+type tally struct {
+	n              int
+	sumErr, sumAbs float64
+	hits           int
+}
+
+// storedTally is the stored form of a tally.
+type storedTally struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	LeadDays int     `json:"leadDays"`
+	Kind     string  `json:"kind"`
+	N        int     `json:"n"`
+	SumErr   float64 `json:"sumErr"`
+	SumAbs   float64 `json:"sumAbs"`
+	Hits     int     `json:"hits"`
+}
+
+// storedDate is the stored form of a pending date.
+type storedDate struct {
+	Lat       float64            `json:"lat"`
+	Lon       float64            `json:"lon"`
+	TimeZone  string             `json:"timeZone"`
+	Date      string             `json:"date"`
+	Forecasts []storedPrediction `json:"forecasts"`
+}
+
+type storedPrediction struct {
+	LeadDays int                   `json:"leadDays"`
+	High     *forecast.Temperature `json:"high,omitempty"`
+	Low      *forecast.Temperature `json:"low,omitempty"`
+}
+
+// pendingKey is the key the forecasts for loc's date key are stored under.
+func pendingKey(loc prefetch.Location, key string) string {
+	return loc.String() + "/" + key
+}
+
+// New constructs a Verifier, loading the scores and pending forecasts saved in
+// opts.Store. bands classifies observed temperatures.
+func New(svc forecast.Service, obs Observer, bands *forecast.BandsVar, opts Options, logger *slog.Logger) *Verifier {
+	v := &Verifier{
+		svc:    svc,
+		obs:    obs,
+		bands:  bands,
+		opts:   opts,
+		logger: logger,
+		now:    time.Now,
+		sites:  make(map[prefetch.Location]*site),
+		scores: make(map[scoreKey]*tally),
+	}
+	if opts.Store != nil {
+		if err := v.load(); err != nil {
+			logger.Warn("verification scores not loaded", "err", err)
+		}
+		if err := v.loadPending(); err != nil {
+			logger.Warn("pending verification forecasts not loaded", "err", err)
+		}
+	}
+	return v
+}
+
+// load reads the saved scores.
+func (v *Verifier) load() error {
+	saved, err := v.opts.Store.Scores()
+	if err != nil {
+		return err
+	}
+	for key, b := range saved {
+		var st storedTally
+		if err = json.Unmarshal(b, &st); err != nil {
+			return fmt.Errorf("decode verification score %s: %w", key, err)
+		}
+		k := scoreKey{loc: prefetch.Location{Lat: st.Lat, Lon: st.Lon}, lead: st.LeadDays, kind: st.Kind}
+		v.scores[k] = &tally{n: st.N, sumErr: st.SumErr, sumAbs: st.SumAbs, hits: st.Hits}
+	}
+	return nil
+}
+
+// loadPending reads the saved forecasts of the dates not yet scored.
+func (v *Verifier) loadPending() error {
+	saved, err := v.opts.Store.Pending()
+	if err != nil {
+		return err
+	}
+	for key, b := range saved {
+		var sd storedDate
+		if err = json.Unmarshal(b, &sd); err != nil {
+			return fmt.Errorf("decode pending verification %s: %w", key, err)
+		}
+		loc := prefetch.Location{Lat: sd.Lat, Lon: sd.Lon}
+		s, ok := v.sites[loc]
+		if !ok {
+			tz, err := nws.LoadLocation(sd.TimeZone, time.Time{})
+			if err != nil {
+				return fmt.Errorf("pending verification %s: %w", key, err)
+			}
+			s = &site{tz: tz, dates: make(map[string]*date)}
+			v.sites[loc] = s
+		}
+		d := s.pending(sd.Date)
+		for _, p := range sd.Forecasts {
+			d.forecasts[p.LeadDays] = prediction{high: p.High, low: p.Low}
+		}
+	}
+	return nil
+}
+
+// Run records and scores every Interval until ctx is done.
+func (v *Verifier) Run(ctx context.Context) {
+	t := time.NewTicker(v.opts.Interval)
+	defer t.Stop()
+	for {
+		v.Verify(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Verify records the targets' forecasts, then scores the dates whose nights
+// are over. It returns how many forecasts were scored.
+func (v *Verifier) Verify(ctx context.Context) int {
+	targets := v.opts.Targets()
+	for _, loc := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := v.record(ctx, loc); err != nil {
+			v.logger.WarnContext(ctx, "verification skipped location", "location", loc.String(), "err", err)
+		}
+	}
+
+	n := 0
+	changed := make(map[scoreKey]bool)
+	for _, d := range v.due() {
+		if ctx.Err() != nil {
+			break
+		}
+		// Locations outside NWS coverage have no stations; they are only
+		// logged at debug level so they do not fill the log every pass.
+		scored, err := v.settle(ctx, d, changed)
+		if err != nil {
+			v.logger.DebugContext(ctx, "verification observations failed", "location", d.loc.String(), "date", d.key, "err", err)
+		}
+		n += scored
+	}
+
+	v.mu.Lock()
+	for loc, s := range v.sites {
+		if len(s.dates) == 0 && !contains(targets, loc) {
+			delete(v.sites, loc)
+		}
+	}
+	v.mu.Unlock()
+	v.save(ctx, changed)
+	return n
+}
+
+// record stores loc's forecasts for the lead times not yet recorded today.
+func (v *Verifier) record(ctx context.Context, loc prefetch.Location) error {
+	ctx, cancel := context.WithTimeout(ctx, passTimeout)
+	defer cancel()
+	s, err := v.site(ctx, loc)
+	if err != nil {
+		return err
+	}
+	today := v.now().In(s.tz)
+	for _, lead := range v.opts.LeadDays {
+		day := today.AddDate(0, 0, lead)
+		key := day.Format(dateLayout)
+		if v.recorded(s, key, lead) {
+			continue
+		}
+		res, err := v.svc.GetDailyForecast(ctx, loc.Lat, loc.Lon, day)
+		if errors.Is(err, forecast.ErrDateOutOfRange) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		v.mu.Lock()
+		s.pending(key).forecasts[lead] = prediction{high: res.High, low: res.Low}
+		v.mu.Unlock()
+		v.savePending(ctx, loc, s, key)
+	}
+	return nil
+}
+
+// savePending writes the forecasts recorded for loc's date key to the store.
+// Failures are logged; the date is written again when its next forecast is
+// recorded.
+func (v *Verifier) savePending(ctx context.Context, loc prefetch.Location, s *site, key string) {
+	if v.opts.Store == nil {
+		return
+	}
+	v.mu.Lock()
+	sd := storedDate{Lat: loc.Lat, Lon: loc.Lon, TimeZone: s.tz.String(), Date: key}
+	if d, ok := s.dates[key]; ok {
+		for lead, p := range d.forecasts {
+			sd.Forecasts = append(sd.Forecasts, storedPrediction{LeadDays: lead, High: p.high, Low: p.low})
+		}
+	}
+	v.mu.Unlock()
+	sort.Slice(sd.Forecasts, func(i, j int) bool { return sd.Forecasts[i].LeadDays < sd.Forecasts[j].LeadDays })
+	b, err := json.Marshal(sd)
+	if err == nil {
+		err = v.opts.Store.PutPending(pendingKey(loc, key), b)
+	}
+	if err != nil {
+		v.logger.WarnContext(ctx, "pending verification not saved", "location", loc.String(), "date", key, "err", err)
+	}
+}
+
+// dropPending deletes the stored forecasts of loc's date key once it is
+// scored or given up on.
+func (v *Verifier) dropPending(ctx context.Context, loc prefetch.Location, key string) {
+	if v.opts.Store == nil {
+		return
+	}
+	if err := v.opts.Store.DeletePending(pendingKey(loc, key)); err != nil {
+		v.logger.WarnContext(ctx, "pending verification not deleted", "location", loc.String(), "date", key, "err", err)
+	}
+}
+
+// recorded reports whether the forecast for date key made lead days ahead was
+// recorded already.
+func (v *Verifier) recorded(s *site, key string, lead int) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	d, ok := s.dates[key]
+	if !ok {
+		return false
+	}
+	_, ok = d.forecasts[lead]
+	return ok
+}
+
+// site returns loc's state, resolving its time zone on first use.
+func (v *Verifier) site(ctx context.Context, loc prefetch.Location) (*site, error) {
+	v.mu.Lock()
+	s, ok := v.sites[loc]
+	v.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+	res, err := v.svc.GetPeriods(ctx, loc.Lat, loc.Lon)
+	if err != nil {
+		return nil, err
+	}
+	tz, err := nws.LoadLocation(res.TimeZone, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	s = &site{tz: tz, dates: make(map[string]*date)}
+	v.mu.Lock()
+	v.sites[loc] = s
+	v.mu.Unlock()
+	return s, nil
+}
+
+// pending returns the pending date for key, adding it if needed. v.mu must
+// be held.
+func (s *site) pending(key string) *date {
+	d, ok := s.dates[key]
+	if !ok {
+		d = &date{forecasts: make(map[int]prediction)}
+		s.dates[key] = d
+	}
+	return d
+}
+
+// dueDate is a pending date whose night is over.
+type dueDate struct {
+	loc   prefetch.Location
+	site  *site
+	key   string
+	start time.Time // the date's dayStart; its night ends a day later
+}
+
+// due returns the pending dates whose nights are over, dropping those that
+// do not parse.
+func (v *Verifier) due() []dueDate {
+	now := v.now()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var out []dueDate
+	for loc, s := range v.sites {
+		for key := range s.dates {
+			day, err := time.ParseInLocation(dateLayout, key, s.tz)
+			if err != nil {
+				delete(s.dates, key)
+				continue
+			}
+			start := time.Date(day.Year(), day.Month(), day.Day(), dayStart, 0, 0, 0, s.tz)
+			over := time.Date(day.Year(), day.Month(), day.Day()+1, dayStart, 0, 0, 0, s.tz)
+			if !now.Before(over.Add(settleDelay)) {
+				out = append(out, dueDate{loc: loc, site: s, key: key, start: start})
+			}
+		}
+	}
+	return out
+}
+
+// settle reads the observations for d and scores and discards its recorded
+// forecasts, returning how many were scored and adding their keys to
+// changed. A date whose observations cannot be read is kept for the next pass
+// until giveUpAfter has passed.
+func (v *Verifier) settle(ctx context.Context, d dueDate, changed map[scoreKey]bool) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, passTimeout)
+	defer cancel()
+	end := time.Date(d.start.Year(), d.start.Month(), d.start.Day()+1, dayStart, 0, 0, 0, d.site.tz)
+	high, low, err := v.observe(ctx, d, end)
+	if err != nil {
+		if !v.now().Before(end.Add(settleDelay + giveUpAfter)) {
+			v.mu.Lock()
+			delete(d.site.dates, d.key)
+			v.mu.Unlock()
+			v.dropPending(ctx, d.loc, d.key)
+		}
+		return 0, err
+	}
+
+	bands := v.bands.Load()
+	v.mu.Lock()
+	pd, ok := d.site.dates[d.key]
+	if !ok {
+		v.mu.Unlock()
+		return 0, nil
+	}
+	n := 0
+	for lead, p := range pd.forecasts {
+		if p.high != nil && high.n >= minSamples {
+			k := scoreKey{d.loc, lead, kindHigh}
+			v.score(k, *p.high, high.v, bands)
+			changed[k] = true
+			n++
+		}
+		if p.low != nil && low.n >= minSamples {
+			k := scoreKey{d.loc, lead, kindLow}
+			v.score(k, *p.low, low.v, bands)
+			changed[k] = true
+			n++
+		}
+	}
+	delete(d.site.dates, d.key)
+	v.mu.Unlock()
+	v.dropPending(ctx, d.loc, d.key)
+	return n, nil
+}
+
+// observe returns the daytime maximum and overnight minimum the station near
+// d observed from d.start up to end, resolving the station on first use.
+func (v *Verifier) observe(ctx context.Context, d dueDate, end time.Time) (high, low extreme, err error) {
+	v.mu.Lock()
+	station := d.site.station
+	v.mu.Unlock()
+	if station == "" {
+		stations, err := v.obs.Stations(ctx, d.loc.Lat, d.loc.Lon)
+		if err != nil {
+			return high, low, err
+		}
+		if len(stations) == 0 {
+			return high, low, errors.New("no observation stations")
+		}
+		station = stations[0]
+		v.mu.Lock()
+		d.site.station = station
+		v.mu.Unlock()
+	}
+	obs, err := v.obs.Observations(ctx, station, d.start, end)
+	if err != nil {
+		return high, low, err
+	}
+	night := time.Date(d.start.Year(), d.start.Month(), d.start.Day(), nightStart, 0, 0, 0, d.site.tz)
+	for _, o := range obs {
+		f, ok := o.Fahrenheit()
+		switch {
+		case !ok || o.Timestamp.Before(d.start) || !o.Timestamp.Before(end):
+		case o.Timestamp.Before(night):
+			high.add(f, true)
+		default:
+			low.add(f, false)
+		}
+	}
+	return high, low, nil
+}
+
+// score adds the error of forecast fc against the observed temperature.
+// v.mu must be held.
+func (v *Verifier) score(k scoreKey, fc forecast.Temperature, observed float64, bands forecast.Bands) {
+	t, ok := v.scores[k]
+	if !ok {
+		t = &tally{}
+		v.scores[k] = t
+	}
+	e := float64(fc.Value) - observed
+	t.n++
+	t.sumErr += e
+	t.sumAbs += math.Abs(e)
+	if fc.Type == forecast.Classify(int(math.Round(observed)), bands) {
+		t.hits++
+	}
+}
+
+// save writes the changed scores to the store. Failures are logged; the
+// scores are written again the next time they change.
+func (v *Verifier) save(ctx context.Context, changed map[scoreKey]bool) {
+	if v.opts.Store == nil || len(changed) == 0 {
+		return
+	}
+	v.mu.Lock()
+	out := make(map[string]storedTally, len(changed))
+	for k := range changed {
+		t := v.scores[k]
+		out[k.String()] = storedTally{
+			Lat: k.loc.Lat, Lon: k.loc.Lon, LeadDays: k.lead, Kind: k.kind,
+			N: t.n, SumErr: t.sumErr, SumAbs: t.sumAbs, Hits: t.hits,
+		}
+	}
+	v.mu.Unlock()
+	for key, st := range out {
+		b, err := json.Marshal(st)
+		if err == nil {
+			err = v.opts.Store.PutScore(key, b)
+		}
+		if err != nil {
+			v.logger.WarnContext(ctx, "verification score not saved", "score", key, "err", err)
+		}
+	}
+}
+
+// Score summarizes how one kind of forecast temperature verified.
+type Score struct {
+	Verified int     `json:"verified" xml:"verified"` // forecasts compared with observations
+	Bias     float64 `json:"bias" xml:"bias"`         // mean forecast minus observed temperature, °F
+	MAE      float64 `json:"mae" xml:"mae"`           // mean absolute error, °F
+	HitRate  float64 `json:"hitRate" xml:"hitRate"`   // fraction classified as the observed temperature was
+}
+
+// Lead holds the scores of forecasts made LeadDays ahead.
+type Lead struct {
+	LeadDays int    `json:"leadDays" xml:"leadDays"`
+	High     *Score `json:"high,omitempty" xml:"high,omitempty"`
+	Low      *Score `json:"low,omitempty" xml:"low,omitempty"`
+}
+
+// Report holds a location's scores, by lead time.
+type Report struct {
+	Lat   float64 `json:"lat" xml:"lat"`
+	Lon   float64 `json:"lon" xml:"lon"`
+	Leads []Lead  `json:"leads" xml:"lead"`
+}
+
+// Reports returns the scores so far, by location and then lead time.
+func (v *Verifier) Reports() []Report {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	byLoc := make(map[prefetch.Location]map[int]*Lead)
+	for k, t := range v.scores {
+		leads, ok := byLoc[k.loc]
+		if !ok {
+			leads = make(map[int]*Lead)
+			byLoc[k.loc] = leads
+		}
+		l, ok := leads[k.lead]
+		if !ok {
+			l = &Lead{LeadDays: k.lead}
+			leads[k.lead] = l
+		}
+		s := &Score{
+			Verified: t.n,
+			Bias:     round(t.sumErr / float64(t.n)),
+			MAE:      round(t.sumAbs / float64(t.n)),
+			HitRate:  round(float64(t.hits) / float64(t.n)),
+		}
+		if k.kind == kindHigh {
+			l.High = s
+		} else {
+			l.Low = s
+		}
+	}
+
+	reports := make([]Report, 0, len(byLoc))
+	for loc, leads := range byLoc {
+		r := Report{Lat: loc.Lat, Lon: loc.Lon}
+		for _, l := range leads {
+			r.Leads = append(r.Leads, *l)
+		}
+		sort.Slice(r.Leads, func(i, j int) bool { return r.Leads[i].LeadDays < r.Leads[j].LeadDays })
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Lat != reports[j].Lat {
+			return reports[i].Lat < reports[j].Lat
+		}
+		return reports[i].Lon < reports[j].Lon
+	})
+	return reports
+}
+
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+func contains(locs []prefetch.Location, loc prefetch.Location) bool {
+	for _, l := range locs {
+		if l == loc {
+			return true
+		}
+	}
+	return false
+}
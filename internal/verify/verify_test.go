@@ -0,0 +1,233 @@
+package verify_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-service/internal/forecast"
+	"weather-service/internal/nws"
+	"weather-service/internal/prefetch"
+	"weather-service/internal/verify"
+)
+
+var depot = prefetch.Location{Lat: 39.7392, Lon: -104.9903}
+
+// fakeSvc forecasts a 90°F high and a 60°F low for every date.
+type fakeSvc struct {
+	forecast.Service
+	daily int
+}
+
+func (f *fakeSvc) GetPeriods(context.Context, float64, float64) (forecast.PeriodsResult, error) {
+	return forecast.PeriodsResult{TimeZone: "America/Denver"}, nil
+}
+
+func (f *fakeSvc) GetDailyForecast(_ context.Context, _, _ float64, date time.Time) (forecast.DailyResult, error) {
+	f.daily++
+	if date.Day() > 20 {
+		return forecast.DailyResult{}, forecast.ErrDateOutOfRange
+	}
+	return forecast.DailyResult{
+		High: &forecast.Temperature{Value: 90, Unit: "F", Type: "hot"},
+		Low:  &forecast.Temperature{Value: 60, Unit: "F", Type: "moderate"},
+	}, nil
+}
+
+// fakeObserver reports temp(at) every hour up to now.
+type fakeObserver struct {
+	now  func() time.Time
+	temp func(time.Time) float64
+	err  error
+}
+
+func (o *fakeObserver) Stations(context.Context, float64, float64) ([]string, error) {
+	return []string{"https://api.weather.gov/stations/KDEN"}, o.err
+}
+
+func (o *fakeObserver) Observations(_ context.Context, _ string, start, end time.Time) ([]nws.Observation, error) {
+	var obs []nws.Observation
+	for at := end.Add(-time.Hour); !at.Before(start); at = at.Add(-time.Hour) {
+		if at.After(o.now()) {
+			continue
+		}
+		v := o.temp(at)
+		obs = append(obs, nws.Observation{Timestamp: at, Temperature: nws.Measurement{UnitCode: "wmoUnit:degF", Value: &v}})
+	}
+	return obs, o.err
+}
+
+// fakeStore keeps scores and pending forecasts in memory.
+type fakeStore struct {
+	scores, pending map[string][]byte
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{scores: make(map[string][]byte), pending: make(map[string][]byte)}
+}
+
+func (s *fakeStore) PutScore(key string, score []byte) error {
+	s.scores[key] = score
+	return nil
+}
+
+func (s *fakeStore) Scores() (map[string][]byte, error) { return s.scores, nil }
+
+func (s *fakeStore) PutPending(key string, forecasts []byte) error {
+	s.pending[key] = forecasts
+	return nil
+}
+
+func (s *fakeStore) DeletePending(key string) error {
+	delete(s.pending, key)
+	return nil
+}
+
+func (s *fakeStore) Pending() (map[string][]byte, error) { return s.pending, nil }
+
+func TestVerify(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 8, 13, 8, 0, 0, 0, denver)
+	clock := func() time.Time { return now }
+	// Days peak at 92°F on the 13th and 84°F on the 14th; nights bottom out at 58°F.
+	obs := &fakeObserver{now: clock, temp: func(at time.Time) float64 {
+		switch h := at.Hour(); {
+		case h >= 6 && h < 18 && at.Day() == 13:
+			return 92 - float64(h%3)
+		case h >= 6 && h < 18:
+			return 84 - float64(h%3)
+		}
+		return 58 + float64(at.Hour()%2)
+	}}
+	svc := &fakeSvc{}
+	store := newFakeStore()
+	opts := verify.Options{
+		Targets:  func() []prefetch.Location { return []prefetch.Location{depot} },
+		LeadDays: []int{0, 1},
+		Interval: time.Hour,
+		Store:    store,
+	}
+	bands := forecast.NewBandsVar(forecast.Bands{ColdMax: 50, HotMin: 85})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	v := verify.New(svc, obs, bands, opts, logger)
+	verify.SetNow(v, clock)
+
+	scored := 0
+	for ; !now.After(time.Date(2025, 8, 15, 8, 0, 0, 0, denver)); now = now.Add(time.Hour) {
+		scored += v.Verify(context.Background())
+	}
+	// The 13th: lead 0 high and low. The 14th: leads 0 and 1, high and low.
+	if scored != 6 {
+		t.Fatalf("scored %d forecasts, want 6", scored)
+	}
+	if svc.daily != 6 {
+		t.Fatalf("recorded %d forecasts, want one per date and lead", svc.daily)
+	}
+
+	reports := v.Reports()
+	if len(reports) != 1 || len(reports[0].Leads) != 2 {
+		t.Fatalf("reports=%+v", reports)
+	}
+	lead0, lead1 := reports[0].Leads[0], reports[0].Leads[1]
+	if want := (verify.Score{Verified: 2, Bias: 2, MAE: 4, HitRate: 0.5}); lead0.LeadDays != 0 || *lead0.High != want {
+		t.Fatalf("lead 0 high=%+v want %+v", *lead0.High, want)
+	}
+	if want := (verify.Score{Verified: 2, Bias: 2, MAE: 2, HitRate: 1}); *lead0.Low != want {
+		t.Fatalf("lead 0 low=%+v want %+v", *lead0.Low, want)
+	}
+	if want := (verify.Score{Verified: 1, Bias: 6, MAE: 6, HitRate: 0}); lead1.LeadDays != 1 || *lead1.High != want {
+		t.Fatalf("lead 1 high=%+v want %+v", *lead1.High, want)
+	}
+
+	// The scores survive a restart.
+	// Only the 15th and 16th are still pending.
+	if len(store.scores) != 4 || len(store.pending) != 2 {
+		t.Fatalf("saved %d scores and %d pending dates, want 4 and 2", len(store.scores), len(store.pending))
+	}
+	if got := verify.New(svc, obs, bands, opts, logger).Reports(); !reflect.DeepEqual(got, reports) {
+		t.Fatalf("reloaded reports=%+v want %+v", got, reports)
+	}
+
+	var b strings.Builder
+	if err = v.WriteMetrics(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE weather_forecast_mae_degrees gauge\n",
+		`weather_forecast_mae_degrees{location="39.7392,-104.9903",lead_days="0",kind="high"} 4` + "\n",
+		`weather_forecast_verified_total{location="39.7392,-104.9903",lead_days="1",kind="low"} 1` + "\n",
+		`weather_forecast_classification_hit_ratio{location="39.7392,-104.9903",lead_days="0",kind="high"} 0.5` + "\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestVerifyWithoutObservations(t *testing.T) {
+	now := time.Date(2025, 8, 13, 8, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	obs := &fakeObserver{now: clock, temp: func(time.Time) float64 { return 70 }, err: errors.New("no stations")}
+	v := verify.New(&fakeSvc{}, obs, forecast.NewBandsVar(forecast.Bands{ColdMax: 50, HotMin: 85}), verify.Options{
+		Targets:  func() []prefetch.Location { return []prefetch.Location{depot} },
+		LeadDays: []int{0},
+		Interval: time.Hour,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	verify.SetNow(v, clock)
+	for range 72 {
+		if n := v.Verify(context.Background()); n != 0 {
+			t.Fatalf("scored %d forecasts without observations", n)
+		}
+		now = now.Add(time.Hour)
+	}
+	if r := v.Reports(); len(r) != 0 {
+		t.Fatalf("reports=%+v", r)
+	}
+}
+
+func TestVerifyKeepsPendingForecastsAcrossRestarts(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 8, 13, 8, 0, 0, 0, denver)
+	clock := func() time.Time { return now }
+	obs := &fakeObserver{now: clock, temp: func(time.Time) float64 { return 70 }}
+	bands := forecast.NewBandsVar(forecast.Bands{ColdMax: 50, HotMin: 85})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := newFakeStore()
+	opts := verify.Options{
+		Targets:  func() []prefetch.Location { return []prefetch.Location{depot} },
+		LeadDays: []int{0, 1},
+		Interval: time.Hour,
+		Store:    store,
+	}
+	v := verify.New(&fakeSvc{}, obs, bands, opts, logger)
+	verify.SetNow(v, clock)
+	v.Verify(context.Background())
+	if len(store.pending) != 2 {
+		t.Fatalf("saved %d pending dates, want 2", len(store.pending))
+	}
+
+	// After a restart the location is no longer a target, yet the forecasts
+	// recorded for the 13th and 14th are still scored.
+	now = time.Date(2025, 8, 15, 8, 0, 0, 0, denver)
+	opts.Targets = func() []prefetch.Location { return nil }
+	svc := &fakeSvc{}
+	v = verify.New(svc, obs, bands, opts, logger)
+	verify.SetNow(v, clock)
+	if n := v.Verify(context.Background()); n != 4 {
+		t.Fatalf("scored %d forecasts after restart, want 4", n)
+	}
+	if svc.daily != 0 || len(store.pending) != 0 {
+		t.Fatalf("recorded %d forecasts, %d dates still pending", svc.daily, len(store.pending))
+	}
+}